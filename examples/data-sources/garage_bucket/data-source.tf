@@ -0,0 +1,9 @@
+data "garage_bucket" "assets" {
+  global_alias       = "assets"
+  error_if_not_found = false
+}
+
+resource "garage_bucket" "assets" {
+  count        = data.garage_bucket.assets.exists ? 0 : 1
+  global_alias = "assets"
+}
@@ -0,0 +1,10 @@
+data "garage_bucket_aliases" "site" {
+  bucket_id = "0b5a8cbd6c4f4a1e9c1c2f3d4e5f6a7b8c9d0e1f2a3b4c5d6e7f8a9b0c1d2e3f"
+}
+
+# Bring every existing alias of the bucket under management
+import {
+  for_each = toset(data.garage_bucket_aliases.site.import_ids)
+  to       = garage_bucket_alias.imported[each.value]
+  id       = each.value
+}
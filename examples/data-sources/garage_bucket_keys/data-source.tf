@@ -0,0 +1,7 @@
+data "garage_bucket_keys" "site" {
+  bucket_id = "0b5a8cbd6c4f4a1e9c1c2f3d4e5f6a7b8c9d0e1f2a3b4c5d6e7f8a9b0c1d2e3f"
+}
+
+output "site_writers" {
+  value = [for k in data.garage_bucket_keys.site.keys : k.name if k.write]
+}
@@ -0,0 +1,9 @@
+data "garage_bucket_list" "all" {}
+
+data "garage_bucket_list" "team_a" {
+  global_alias_prefix = "team-a-"
+}
+
+output "team_a_bytes" {
+  value = data.garage_bucket_list.team_a.total_bytes
+}
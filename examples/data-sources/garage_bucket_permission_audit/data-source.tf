@@ -0,0 +1,16 @@
+data "garage_bucket_permission_audit" "site" {
+  bucket_id = "0b5a8cbd6c4f4a1e9c1c2f3d4e5f6a7b8c9d0e1f2a3b4c5d6e7f8a9b0c1d2e3f"
+
+  desired {
+    access_key_id = "GK31c2f218a2e44f485b94239e"
+    read          = true
+    write         = true
+  }
+}
+
+output "permission_drift" {
+  value = {
+    missing = data.garage_bucket_permission_audit.site.missing
+    extra   = data.garage_bucket_permission_audit.site.extra
+  }
+}
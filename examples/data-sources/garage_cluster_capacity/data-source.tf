@@ -0,0 +1,5 @@
+data "garage_cluster_capacity" "cluster" {}
+
+output "garage_available_bytes" {
+  value = data.garage_cluster_capacity.cluster.available_capacity
+}
@@ -0,0 +1,8 @@
+data "garage_key" "app" {
+  access_key_id      = "GK0123456789abcdef01234567"
+  error_if_not_found = false
+}
+
+output "app_key_exists" {
+  value = data.garage_key.app.exists
+}
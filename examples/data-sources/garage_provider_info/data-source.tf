@@ -0,0 +1,5 @@
+data "garage_provider_info" "this" {}
+
+output "garage_api_version" {
+  value = data.garage_provider_info.this.api_version
+}
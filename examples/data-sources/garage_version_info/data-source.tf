@@ -0,0 +1,5 @@
+data "garage_version_info" "cluster" {}
+
+output "garage_upgrade_complete" {
+  value = data.garage_version_info.cluster.all_same
+}
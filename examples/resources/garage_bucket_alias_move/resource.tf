@@ -0,0 +1,12 @@
+# Point the "site" alias at the new bucket; it is briefly unbound during the move
+resource "garage_bucket" "old" {
+  global_alias = "site"
+}
+
+resource "garage_bucket" "new" {}
+
+resource "garage_bucket_alias_move" "site" {
+  alias          = "site"
+  from_bucket_id = garage_bucket.old.id
+  to_bucket_id   = garage_bucket.new.id
+}
@@ -0,0 +1,3 @@
+# Bucket key permissions are imported as <bucket_id>:<access_key_id>.
+# read, write and owner are set from the permissions on the server.
+terraform import garage_bucket_key.example 0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef:GK0123456789abcdef01234567
@@ -0,0 +1,5 @@
+# State that the CI key must never have access to the archive bucket
+resource "garage_bucket_key_revoke" "ci_archive" {
+  bucket_id     = garage_bucket.archive.id
+  access_key_id = garage_key.ci.access_key_id
+}
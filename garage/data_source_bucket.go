@@ -0,0 +1,126 @@
+package garage
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+/*
+Data source: garage_bucket
+
+Looks up a single bucket by ID or global alias through BucketAPI.GetBucketInfo.
+With error_if_not_found = false a missing bucket is reported through
+exists = false instead of failing, so configurations can create the bucket
+conditionally.
+*/
+
+func dataSourceBucket() *schema.Resource {
+	return &schema.Resource{
+		Description: "Looks up a bucket by ID or global alias.",
+		ReadContext: dataSourceBucketRead,
+		Schema: map[string]*schema.Schema{
+			"bucket_id": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"bucket_id", "global_alias"},
+				Description:  "Bucket ID (UUID) to look up. Exactly one of `bucket_id` and `global_alias` must be set.",
+			},
+			"global_alias": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"bucket_id", "global_alias"},
+				Description:  "Global alias of the bucket to look up.",
+			},
+			"error_if_not_found": errorIfNotFoundSchema("bucket"),
+			"exists":             existsSchema("bucket"),
+			"global_aliases": {
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Computed:    true,
+				Description: "List of all global aliases bound to the bucket.",
+			},
+			"website_access_enabled": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether static website hosting is enabled for the bucket.",
+			},
+			"objects": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Number of objects stored in the bucket.",
+			},
+			"bytes": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Total bytes used by objects in the bucket.",
+			},
+		},
+	}
+}
+
+func dataSourceBucketRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	p := m.(*garageProvider)
+
+	req := p.client.BucketAPI.GetBucketInfo(p.withToken(ctx))
+	lookup := d.Get("bucket_id").(string)
+	if alias, ok := getOkString(d, "global_alias"); ok {
+		req = req.GlobalAlias(alias)
+		lookup = alias
+	} else {
+		req = req.Id(lookup)
+	}
+
+	bucket, httpResp, err := req.Execute()
+	if err != nil && (httpResp == nil || httpResp.StatusCode != http.StatusNotFound) {
+		return createDiagnostics(err, httpResp)
+	}
+	if err != nil || bucket == nil {
+		return dataSourceNotFound(d, lookup, "bucket not found", fmt.Sprintf("no bucket matches %q", lookup))
+	}
+
+	d.SetId(bucket.Id)
+	_ = d.Set("exists", true)
+	_ = d.Set("global_aliases", bucket.GlobalAliases)
+	_ = d.Set("website_access_enabled", bucket.WebsiteAccess)
+	_ = d.Set("objects", int(bucket.Objects))
+	_ = d.Set("bytes", int(bucket.Bytes))
+	return nil
+}
+
+// errorIfNotFoundSchema is the shared error_if_not_found argument of lookup data sources.
+func errorIfNotFoundSchema(kind string) *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeBool,
+		Optional:    true,
+		Default:     true,
+		Description: fmt.Sprintf("Fail when the %s does not exist. When `false`, `exists` is set to `false` and the other attributes are left empty instead.", kind),
+	}
+}
+
+// existsSchema is the shared exists attribute of lookup data sources.
+func existsSchema(kind string) *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeBool,
+		Computed:    true,
+		Description: fmt.Sprintf("Whether the %s exists. Always `true` unless `error_if_not_found` is `false`.", kind),
+	}
+}
+
+// dataSourceNotFound fails a lookup data source, or records exists = false under
+// the lookup value when error_if_not_found is disabled.
+func dataSourceNotFound(d *schema.ResourceData, lookup, summary, detail string) diag.Diagnostics {
+	if d.Get("error_if_not_found").(bool) {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  summary,
+			Detail:   detail + "; set error_if_not_found = false to get exists = false instead",
+		}}
+	}
+	d.SetId(lookup)
+	_ = d.Set("exists", false)
+	return nil
+}
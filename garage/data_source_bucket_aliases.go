@@ -0,0 +1,107 @@
+package garage
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+/*
+Data source: garage_bucket_aliases
+
+Lists every alias bound to one bucket, using BucketAPI.GetBucketInfo:
+  - global aliases come from GlobalAliases
+  - local aliases are collected per key with the same reflective extraction
+    used by garage_bucket_alias
+
+import_ids holds the garage_bucket_alias ID of each alias (global:<alias> and
+local:<access_key_id>:<alias>) so import blocks can be generated with for_each.
+*/
+
+func dataSourceBucketAliases() *schema.Resource {
+	return &schema.Resource{
+		Description: "Lists all global and local aliases of a Garage bucket.",
+		ReadContext: dataSourceBucketAliasesRead,
+		Schema: map[string]*schema.Schema{
+			/* ------------------------------ Inputs ------------------------------ */
+
+			"bucket_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "ID of the bucket (UUID).",
+			},
+
+			/* ------------------------------ Outputs ----------------------------- */
+
+			"global_aliases": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Global aliases bound to the bucket.",
+			},
+			"local_aliases": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Local aliases bound to the bucket, one entry per access key and alias.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"access_key_id": {Type: schema.TypeString, Computed: true, Description: "Access key the alias is scoped to."},
+						"alias":         {Type: schema.TypeString, Computed: true, Description: "Local alias name."},
+					},
+				},
+			},
+			"import_ids": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "`garage_bucket_alias` import IDs for every alias, global aliases first.",
+			},
+		},
+	}
+}
+
+func dataSourceBucketAliasesRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	p := m.(*garageProvider)
+	bucketID := d.Get("bucket_id").(string)
+
+	info, httpResp, err := p.getBucketInfo(ctx, bucketID)
+	if err != nil {
+		if httpResp != nil && httpResp.StatusCode == http.StatusNotFound {
+			return diag.Errorf("bucket %q not found", bucketID)
+		}
+		return createDiagnostics(err, httpResp)
+	}
+	if info == nil {
+		return diag.Errorf("bucket %q not found", bucketID)
+	}
+
+	globals := info.GetGlobalAliases()
+	importIDs := make([]string, 0, len(globals))
+	for _, ga := range globals {
+		importIDs = append(importIDs, globalAliasID(ga))
+	}
+
+	locals := make([]interface{}, 0)
+	for _, k := range info.GetKeys() {
+		keyID := k.GetAccessKeyId()
+		for _, alias := range keyLocalAliases(k) {
+			locals = append(locals, map[string]interface{}{
+				"access_key_id": keyID,
+				"alias":         alias,
+			})
+			importIDs = append(importIDs, localAliasID(keyID, alias))
+		}
+	}
+
+	if globals == nil {
+		globals = []string{}
+	}
+	_ = d.Set("global_aliases", globals)
+	_ = d.Set("local_aliases", locals)
+	_ = d.Set("import_ids", importIDs)
+
+	d.SetId(bucketID)
+	return nil
+}
@@ -0,0 +1,47 @@
+package garage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestDataSourceBucketAliasesGlobalAndLocal(t *testing.T) {
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path != "/v2/GetBucketInfo" || r.URL.Query().Get("id") != "bucket-1" {
+			t.Fatalf("unexpected request %s", r.URL.String())
+		}
+		payload := aliasBucketInfoPayload("bucket-1", []string{"site", "site-old"}, "GK123", "app", []string{"mine", "a:b"})
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(payload))}, nil
+	}))
+
+	d := schema.TestResourceDataRaw(t, dataSourceBucketAliases().Schema, map[string]interface{}{
+		"bucket_id": "bucket-1",
+	})
+	if diags := dataSourceBucketAliasesRead(context.Background(), d, p); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+
+	if got := d.Get("global_aliases").([]interface{}); !reflect.DeepEqual(got, []interface{}{"site", "site-old"}) {
+		t.Fatalf("unexpected global aliases %v", got)
+	}
+	wantLocals := []interface{}{
+		map[string]interface{}{"access_key_id": "GK123", "alias": "mine"},
+		map[string]interface{}{"access_key_id": "GK123", "alias": "a:b"},
+	}
+	if got := d.Get("local_aliases").([]interface{}); !reflect.DeepEqual(got, wantLocals) {
+		t.Fatalf("unexpected local aliases %v", got)
+	}
+	wantIDs := []interface{}{"global:site", "global:site-old", "local:GK123:mine", "local:GK123:a%3Ab"}
+	if got := d.Get("import_ids").([]interface{}); !reflect.DeepEqual(got, wantIDs) {
+		t.Fatalf("unexpected import ids %v", got)
+	}
+	if d.Id() != "bucket-1" {
+		t.Fatalf("expected id bucket-1, got %q", d.Id())
+	}
+}
@@ -0,0 +1,88 @@
+package garage
+
+import (
+	"context"
+	"net/http"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+/*
+Data source: garage_bucket_keys
+
+Lists the access keys holding at least one permission on a bucket, from the
+Keys of a single BucketAPI.GetBucketInfo call. Keys that are listed without any
+permission (e.g. only bound through a local alias) are left out. This is the
+read-only counterpart of garage_bucket_key.
+
+Keys are sorted by access key ID.
+*/
+
+func dataSourceBucketKeys() *schema.Resource {
+	return &schema.Resource{
+		Description: "Lists the access keys that hold any permission on a Garage bucket.",
+		ReadContext: dataSourceBucketKeysRead,
+		Schema: map[string]*schema.Schema{
+			"bucket_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "ID of the bucket (UUID).",
+			},
+			"keys": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Keys with at least one of read, write or owner on the bucket.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"access_key_id": {Type: schema.TypeString, Computed: true, Description: "Access key ID."},
+						"name":          {Type: schema.TypeString, Computed: true, Description: "Human-friendly name of the access key."},
+						"read":          {Type: schema.TypeBool, Computed: true, Description: "Whether the key can read from the bucket."},
+						"write":         {Type: schema.TypeBool, Computed: true, Description: "Whether the key can write to the bucket."},
+						"owner":         {Type: schema.TypeBool, Computed: true, Description: "Whether the key owns the bucket."},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceBucketKeysRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	p := m.(*garageProvider)
+	bucketID := d.Get("bucket_id").(string)
+
+	info, httpResp, err := p.getBucketInfo(ctx, bucketID)
+	if err != nil {
+		if httpResp != nil && httpResp.StatusCode == http.StatusNotFound {
+			return diag.Errorf("bucket %q not found", bucketID)
+		}
+		return createDiagnostics(err, httpResp)
+	}
+	if info == nil {
+		return diag.Errorf("bucket %q not found", bucketID)
+	}
+
+	keys := make([]interface{}, 0, len(info.Keys))
+	for _, k := range info.GetKeys() {
+		perms := k.GetPermissions()
+		if !hasAnyBucketKeyPerm(&perms) {
+			continue
+		}
+		keys = append(keys, map[string]interface{}{
+			"access_key_id": k.GetAccessKeyId(),
+			"name":          k.GetName(),
+			"read":          perms.GetRead(),
+			"write":         perms.GetWrite(),
+			"owner":         perms.GetOwner(),
+		})
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].(map[string]interface{})["access_key_id"].(string) < keys[j].(map[string]interface{})["access_key_id"].(string)
+	})
+
+	_ = d.Set("keys", keys)
+	d.SetId(bucketID)
+	return nil
+}
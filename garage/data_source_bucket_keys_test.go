@@ -0,0 +1,56 @@
+package garage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestDataSourceBucketKeysOnlyPermissioned(t *testing.T) {
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path != "/v2/GetBucketInfo" || r.URL.Query().Get("id") != "bucket-1" {
+			t.Fatalf("unexpected request %s", r.URL.String())
+		}
+		payload := bucketInfoPayloadWithKeys("bucket-1",
+			bucketInfoKey("GKalias", "alias-only", bucketKeyPermissions{}),
+			bucketInfoKey("GKapp", "app", bucketKeyPermissions{Read: true, Owner: true}),
+		)
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(payload))}, nil
+	}))
+
+	d := schema.TestResourceDataRaw(t, dataSourceBucketKeys().Schema, map[string]interface{}{
+		"bucket_id": "bucket-1",
+	})
+	if diags := dataSourceBucketKeysRead(context.Background(), d, p); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+
+	want := []interface{}{
+		map[string]interface{}{"access_key_id": "GKapp", "name": "app", "read": true, "write": false, "owner": true},
+	}
+	if got := d.Get("keys").([]interface{}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected keys %v", got)
+	}
+	if d.Id() != "bucket-1" {
+		t.Fatalf("expected id bucket-1, got %q", d.Id())
+	}
+}
+
+func TestDataSourceBucketKeysBucketNotFound(t *testing.T) {
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(`{"code":"NoSuchBucket","message":"not found"}`))}, nil
+	}))
+
+	d := schema.TestResourceDataRaw(t, dataSourceBucketKeys().Schema, map[string]interface{}{
+		"bucket_id": "missing",
+	})
+	diags := dataSourceBucketKeysRead(context.Background(), d, p)
+	if len(diags) != 1 || !strings.Contains(diags[0].Summary, `bucket "missing" not found`) {
+		t.Fatalf("expected a not found error, got %#v", diags)
+	}
+}
@@ -0,0 +1,142 @@
+package garage
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+/*
+Data source: garage_bucket_list
+
+Enumerates all buckets of the cluster:
+  - List: BucketAPI.ListBuckets(ctx).Execute()
+  - Info: BucketAPI.GetBucketInfo(ctx).Id(id).Execute() for objects/bytes
+
+The admin API returns every bucket in a single ListBuckets response, so there is
+no page token to follow. The global_alias_prefix filter is applied client-side
+before usage is fetched, so filtered-out buckets cost no extra call.
+*/
+
+func dataSourceBucketList() *schema.Resource {
+	return &schema.Resource{
+		Description: "Lists all Garage buckets with their global aliases and usage.",
+		ReadContext: dataSourceBucketListRead,
+		Schema: map[string]*schema.Schema{
+			/* ------------------------------ Inputs ------------------------------ */
+
+			"global_alias_prefix": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only return buckets having at least one global alias starting with this prefix.",
+			},
+
+			/* ------------------------------ Outputs ----------------------------- */
+
+			"buckets": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Matching buckets.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {Type: schema.TypeString, Computed: true, Description: "Bucket ID."},
+						"global_aliases": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Description: "Global aliases bound to the bucket.",
+						},
+						"objects": {Type: schema.TypeInt, Computed: true, Description: "Number of objects stored in the bucket."},
+						"bytes":   {Type: schema.TypeInt, Computed: true, Description: "Total bytes used by objects in the bucket."},
+					},
+				},
+			},
+			"total_objects": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Sum of `objects` over the matching buckets.",
+			},
+			"total_bytes": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Sum of `bytes` over the matching buckets.",
+			},
+		},
+	}
+}
+
+func dataSourceBucketListRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	p := m.(*garageProvider)
+
+	items, httpResp, err := p.client.BucketAPI.
+		ListBuckets(p.withToken(ctx)).
+		Execute()
+	if err != nil {
+		return createDiagnostics(err, httpResp)
+	}
+
+	prefix, _ := getOkString(d, "global_alias_prefix")
+
+	buckets := make([]interface{}, 0, len(items))
+	ids := make([]string, 0, len(items))
+	var totalObjects, totalBytes int64
+
+	for _, item := range items {
+		if prefix != "" && !hasAliasWithPrefix(item.GlobalAliases, prefix) {
+			continue
+		}
+
+		info, httpResp, err := p.getBucketInfo(ctx, item.Id)
+		if err != nil {
+			// deleted between list and read
+			if httpResp != nil && httpResp.StatusCode == http.StatusNotFound {
+				continue
+			}
+			return createDiagnostics(err, httpResp)
+		}
+		if info == nil {
+			continue
+		}
+
+		buckets = append(buckets, map[string]interface{}{
+			"id":             info.Id,
+			"global_aliases": info.GlobalAliases,
+			"objects":        int(info.Objects),
+			"bytes":          int(info.Bytes),
+		})
+		ids = append(ids, info.Id)
+		totalObjects += info.Objects
+		totalBytes += info.Bytes
+	}
+
+	if err := d.Set("buckets", buckets); err != nil {
+		return diag.FromErr(err)
+	}
+	_ = d.Set("total_objects", int(totalObjects))
+	_ = d.Set("total_bytes", int(totalBytes))
+
+	d.SetId(bucketSetID(ids))
+	return nil
+}
+
+func hasAliasWithPrefix(aliases []string, prefix string) bool {
+	for _, a := range aliases {
+		if strings.HasPrefix(a, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// bucketSetID derives a stable ID from the set of bucket IDs, independent of order.
+func bucketSetID(ids []string) string {
+	sorted := append([]string(nil), ids...)
+	sort.Strings(sorted)
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(sorted, ","))))
+}
@@ -0,0 +1,139 @@
+package garage
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	garageapi "git.deuxfleurs.fr/garage-sdk/garage-admin-sdk-golang"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func listBucketsJSON(buckets map[string][]string) string {
+	items := []garageapi.ListBucketsResponseItem{}
+	for id, aliases := range buckets {
+		items = append(items, garageapi.ListBucketsResponseItem{
+			Created:       time.Now().UTC(),
+			GlobalAliases: aliases,
+			Id:            id,
+			LocalAliases:  []garageapi.BucketLocalAlias{},
+		})
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}
+
+func bucketUsageJSON(id string, globals []string, objects, bytes int64) string {
+	resp := garageapi.GetBucketInfoResponse{
+		Bytes:         bytes,
+		Created:       time.Now().UTC(),
+		GlobalAliases: globals,
+		Id:            id,
+		Keys:          []garageapi.GetBucketInfoKey{},
+		Objects:       objects,
+		Quotas:        garageapi.ApiBucketQuotas{},
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}
+
+func newBucketListProvider(t *testing.T, infoCalls *[]string) *garageProvider {
+	aliases := map[string][]string{
+		"b1": {"team-a-logs"},
+		"b2": {"team-a-assets", "cdn"},
+		"b3": {"team-b-logs"},
+	}
+	usage := map[string][2]int64{
+		"b1": {10, 1000},
+		"b2": {5, 500},
+		"b3": {1, 1},
+	}
+	return newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		var body string
+		switch r.URL.Path {
+		case "/v2/ListBuckets":
+			body = listBucketsJSON(aliases)
+		case "/v2/GetBucketInfo":
+			id := r.URL.Query().Get("id")
+			*infoCalls = append(*infoCalls, id)
+			body = bucketUsageJSON(id, aliases[id], usage[id][0], usage[id][1])
+		default:
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Status:     "200 OK",
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+		}, nil
+	}))
+}
+
+func TestDataSourceBucketListAll(t *testing.T) {
+	var infoCalls []string
+	p := newBucketListProvider(t, &infoCalls)
+
+	d := schema.TestResourceDataRaw(t, dataSourceBucketList().Schema, map[string]interface{}{})
+	diags := dataSourceBucketListRead(context.Background(), d, p)
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+
+	if got := len(d.Get("buckets").([]interface{})); got != 3 {
+		t.Fatalf("expected 3 buckets, got %d", got)
+	}
+	if d.Get("total_objects").(int) != 16 || d.Get("total_bytes").(int) != 1501 {
+		t.Fatalf("unexpected totals objects=%v bytes=%v", d.Get("total_objects"), d.Get("total_bytes"))
+	}
+	if d.Id() != bucketSetID([]string{"b3", "b1", "b2"}) {
+		t.Fatalf("expected id derived from bucket ids, got %q", d.Id())
+	}
+}
+
+func TestDataSourceBucketListPrefixFilter(t *testing.T) {
+	var infoCalls []string
+	p := newBucketListProvider(t, &infoCalls)
+
+	d := schema.TestResourceDataRaw(t, dataSourceBucketList().Schema, map[string]interface{}{
+		"global_alias_prefix": "team-a-",
+	})
+	diags := dataSourceBucketListRead(context.Background(), d, p)
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+
+	buckets := d.Get("buckets").([]interface{})
+	if len(buckets) != 2 {
+		t.Fatalf("expected 2 filtered buckets, got %#v", buckets)
+	}
+	for _, b := range buckets {
+		if id := b.(map[string]interface{})["id"].(string); id == "b3" {
+			t.Fatalf("bucket b3 should have been filtered out")
+		}
+	}
+	if len(infoCalls) != 2 {
+		t.Fatalf("expected usage to be fetched only for matching buckets, got %v", infoCalls)
+	}
+	if d.Get("total_objects").(int) != 15 || d.Get("total_bytes").(int) != 1500 {
+		t.Fatalf("unexpected totals objects=%v bytes=%v", d.Get("total_objects"), d.Get("total_bytes"))
+	}
+}
+
+func TestBucketSetIDOrderIndependent(t *testing.T) {
+	if bucketSetID([]string{"a", "b"}) != bucketSetID([]string{"b", "a"}) {
+		t.Fatalf("expected bucket set id to ignore ordering")
+	}
+	if bucketSetID([]string{"a"}) == bucketSetID([]string{"a", "b"}) {
+		t.Fatalf("expected different sets to produce different ids")
+	}
+}
@@ -0,0 +1,159 @@
+package garage
+
+import (
+	"context"
+	"net/http"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+/*
+Data source: garage_bucket_permission_audit
+
+Compares the permissions keys hold on one bucket with a desired set, without
+changing anything. The bucket is fetched once with BucketAPI.GetBucketInfo and
+each key is resolved the same way garage_bucket_key reads its state.
+
+  - missing: permissions desired but not granted
+  - extra:   permissions granted but not desired, including every permission of
+             a key absent from `desired`
+
+Entries are sorted by access key, then read, write, owner.
+*/
+
+func dataSourceBucketPermissionAudit() *schema.Resource {
+	auditEntry := &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"access_key_id": {Type: schema.TypeString, Computed: true, Description: "Access key the entry refers to."},
+			"permission":    {Type: schema.TypeString, Computed: true, Description: "One of `read`, `write` or `owner`."},
+		},
+	}
+
+	return &schema.Resource{
+		Description: "Reports the difference between desired and actual key permissions on a Garage bucket.",
+		ReadContext: dataSourceBucketPermissionAuditRead,
+		Schema: map[string]*schema.Schema{
+			/* ------------------------------ Inputs ------------------------------ */
+
+			"bucket_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "ID of the bucket (UUID).",
+			},
+			"desired": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Desired permissions, one block per access key. Keys not listed are expected to have no access.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"access_key_id": {Type: schema.TypeString, Required: true, Description: "Access key ID."},
+						"read":          {Type: schema.TypeBool, Optional: true, Description: "Whether the key should be able to read."},
+						"write":         {Type: schema.TypeBool, Optional: true, Description: "Whether the key should be able to write."},
+						"owner":         {Type: schema.TypeBool, Optional: true, Description: "Whether the key should own the bucket."},
+					},
+				},
+			},
+
+			/* ------------------------------ Outputs ----------------------------- */
+
+			"missing": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        auditEntry,
+				Description: "Desired permissions the keys do not currently hold.",
+			},
+			"extra": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        auditEntry,
+				Description: "Permissions the keys currently hold that are not desired.",
+			},
+		},
+	}
+}
+
+func dataSourceBucketPermissionAuditRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	p := m.(*garageProvider)
+	bucketID := d.Get("bucket_id").(string)
+
+	info, httpResp, err := p.getBucketInfo(ctx, bucketID)
+	if err != nil {
+		if httpResp != nil && httpResp.StatusCode == http.StatusNotFound {
+			return diag.Errorf("bucket %q not found", bucketID)
+		}
+		return createDiagnostics(err, httpResp)
+	}
+	if info == nil {
+		return diag.Errorf("bucket %q not found", bucketID)
+	}
+
+	desired := map[string]bucketKeyPermissions{}
+	for _, raw := range d.Get("desired").([]interface{}) {
+		entry, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		keyID := entry["access_key_id"].(string)
+		perms := desired[keyID]
+		perms.Read = perms.Read || entry["read"] == true
+		perms.Write = perms.Write || entry["write"] == true
+		perms.Owner = perms.Owner || entry["owner"] == true
+		desired[keyID] = perms
+	}
+
+	keyIDs := make([]string, 0, len(desired)+len(info.Keys))
+	seen := map[string]bool{}
+	for keyID := range desired {
+		keyIDs = append(keyIDs, keyID)
+		seen[keyID] = true
+	}
+	for _, k := range info.GetKeys() {
+		if keyID := k.GetAccessKeyId(); !seen[keyID] {
+			keyIDs = append(keyIDs, keyID)
+			seen[keyID] = true
+		}
+	}
+	sort.Strings(keyIDs)
+
+	missing := make([]interface{}, 0)
+	extra := make([]interface{}, 0)
+	for _, keyID := range keyIDs {
+		actual, _, _ := bucketKeyStateFromInfo(info, keyID)
+		miss, ext := diffBucketKeyPermissions(desired[keyID], actual)
+		for _, perm := range miss {
+			missing = append(missing, map[string]interface{}{"access_key_id": keyID, "permission": perm})
+		}
+		for _, perm := range ext {
+			extra = append(extra, map[string]interface{}{"access_key_id": keyID, "permission": perm})
+		}
+	}
+
+	_ = d.Set("missing", missing)
+	_ = d.Set("extra", extra)
+
+	d.SetId(bucketID)
+	return nil
+}
+
+// diffBucketKeyPermissions lists the permissions in want but not in have
+// (missing) and in have but not in want (extra).
+func diffBucketKeyPermissions(want, have bucketKeyPermissions) (missing, extra []string) {
+	for _, c := range []struct {
+		name       string
+		want, have bool
+	}{
+		{"read", want.Read, have.Read},
+		{"write", want.Write, have.Write},
+		{"owner", want.Owner, have.Owner},
+	} {
+		switch {
+		case c.want && !c.have:
+			missing = append(missing, c.name)
+		case c.have && !c.want:
+			extra = append(extra, c.name)
+		}
+	}
+	return missing, extra
+}
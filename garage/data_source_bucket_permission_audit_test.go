@@ -0,0 +1,54 @@
+package garage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestDataSourceBucketPermissionAuditDivergence(t *testing.T) {
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path != "/v2/GetBucketInfo" || r.URL.Query().Get("id") != "bucket-1" {
+			t.Fatalf("unexpected request %s", r.URL.String())
+		}
+		payload := bucketInfoPayloadWithKeys("bucket-1",
+			bucketInfoKey("GKapp", "app", bucketKeyPermissions{Read: true, Owner: true}),
+			bucketInfoKey("GKstray", "stray", bucketKeyPermissions{Write: true}),
+		)
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(payload))}, nil
+	}))
+
+	d := schema.TestResourceDataRaw(t, dataSourceBucketPermissionAudit().Schema, map[string]interface{}{
+		"bucket_id": "bucket-1",
+		"desired": []interface{}{
+			map[string]interface{}{"access_key_id": "GKapp", "read": true, "write": true},
+			map[string]interface{}{"access_key_id": "GKnew", "read": true},
+		},
+	})
+	if diags := dataSourceBucketPermissionAuditRead(context.Background(), d, p); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+
+	wantMissing := []interface{}{
+		map[string]interface{}{"access_key_id": "GKapp", "permission": "write"},
+		map[string]interface{}{"access_key_id": "GKnew", "permission": "read"},
+	}
+	if got := d.Get("missing").([]interface{}); !reflect.DeepEqual(got, wantMissing) {
+		t.Fatalf("unexpected missing %v", got)
+	}
+	wantExtra := []interface{}{
+		map[string]interface{}{"access_key_id": "GKapp", "permission": "owner"},
+		map[string]interface{}{"access_key_id": "GKstray", "permission": "write"},
+	}
+	if got := d.Get("extra").([]interface{}); !reflect.DeepEqual(got, wantExtra) {
+		t.Fatalf("unexpected extra %v", got)
+	}
+	if d.Id() != "bucket-1" {
+		t.Fatalf("expected id bucket-1, got %q", d.Id())
+	}
+}
@@ -0,0 +1,71 @@
+package garage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func notFoundRoundTripper(t *testing.T, path string) keyRoundTripper {
+	return func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path != path {
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+		return &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(`{"code":"NoSuchBucket","message":"not found"}`))}, nil
+	}
+}
+
+func TestDataSourceBucketNotFound(t *testing.T) {
+	p := newTestProvider(notFoundRoundTripper(t, "/v2/GetBucketInfo"))
+
+	d := schema.TestResourceDataRaw(t, dataSourceBucket().Schema, map[string]interface{}{
+		"global_alias": "missing",
+	})
+	diags := dataSourceBucketRead(context.Background(), d, p)
+	if len(diags) != 1 || diags[0].Summary != "bucket not found" {
+		t.Fatalf("expected bucket not found diagnostic, got %#v", diags)
+	}
+
+	d = schema.TestResourceDataRaw(t, dataSourceBucket().Schema, map[string]interface{}{
+		"global_alias":       "missing",
+		"error_if_not_found": false,
+	})
+	if diags := dataSourceBucketRead(context.Background(), d, p); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	if d.Get("exists").(bool) {
+		t.Fatalf("expected exists to be false")
+	}
+	if d.Id() != "missing" {
+		t.Fatalf("expected lookup value as id, got %q", d.Id())
+	}
+	if aliases := d.Get("global_aliases").([]interface{}); len(aliases) != 0 {
+		t.Fatalf("expected no aliases, got %v", aliases)
+	}
+}
+
+func TestDataSourceBucketFound(t *testing.T) {
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Query().Get("id") != "bucket" {
+			t.Fatalf("expected lookup by id, got %s", r.URL.RawQuery)
+		}
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(bucketInfoJSON("bucket", []string{"site"}, 0)))}, nil
+	}))
+
+	d := schema.TestResourceDataRaw(t, dataSourceBucket().Schema, map[string]interface{}{
+		"bucket_id": "bucket",
+	})
+	if diags := dataSourceBucketRead(context.Background(), d, p); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	if !d.Get("exists").(bool) || d.Id() != "bucket" {
+		t.Fatalf("expected existing bucket, got exists=%v id=%q", d.Get("exists"), d.Id())
+	}
+	if aliases := d.Get("global_aliases").([]interface{}); len(aliases) != 1 || aliases[0] != "site" {
+		t.Fatalf("unexpected aliases %v", aliases)
+	}
+}
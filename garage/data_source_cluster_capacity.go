@@ -0,0 +1,88 @@
+package garage
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+/*
+Data source: garage_cluster_capacity
+
+Aggregates storage figures from ClusterAPI.GetClusterStatus for capacity
+planning. The total is the capacity assigned to nodes in the layout; used and
+available space come from the data partition statistics each node reports.
+Gateway nodes (no assigned capacity) and nodes that report no partition
+statistics simply do not contribute to the corresponding sums.
+*/
+
+func dataSourceClusterCapacity() *schema.Resource {
+	return &schema.Resource{
+		Description: "Reports the storage capacity assigned to the cluster and the space used and available on the nodes' data partitions.",
+		ReadContext: dataSourceClusterCapacityRead,
+		Schema: map[string]*schema.Schema{
+			"total_capacity": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Sum of the capacities assigned to nodes in the cluster layout, in bytes.",
+			},
+			"used_capacity": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Space used on the data partitions of the nodes reporting one, in bytes.",
+			},
+			"available_capacity": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Free space on the data partitions of the nodes reporting one, in bytes.",
+			},
+			"storage_nodes": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Number of nodes with a capacity assigned in the layout.",
+			},
+		},
+	}
+}
+
+func dataSourceClusterCapacityRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	p := m.(*garageProvider)
+
+	status, httpResp, err := p.client.ClusterAPI.
+		GetClusterStatus(p.withToken(ctx)).
+		Execute()
+	if err != nil {
+		return createDiagnostics(err, httpResp)
+	}
+	if status == nil {
+		return emptyResponseDiagnostics("GetClusterStatus")
+	}
+
+	var total, used, available int64
+	storageNodes := 0
+	for i := range status.Nodes {
+		n := &status.Nodes[i]
+		if role, ok := n.GetRoleOk(); ok && role != nil {
+			if c, ok := role.GetCapacityOk(); ok && c != nil {
+				total += *c
+				storageNodes++
+			}
+		}
+		if part, ok := n.GetDataPartitionOk(); ok && part != nil {
+			available += part.GetAvailable()
+			if u := part.GetTotal() - part.GetAvailable(); u > 0 {
+				used += u
+			}
+		}
+	}
+
+	_ = d.Set("total_capacity", int(total))
+	_ = d.Set("used_capacity", int(used))
+	_ = d.Set("available_capacity", int(available))
+	_ = d.Set("storage_nodes", storageNodes)
+
+	d.SetId("cluster-capacity:" + strconv.FormatInt(status.LayoutVersion, 10))
+	return nil
+}
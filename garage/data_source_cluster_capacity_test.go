@@ -0,0 +1,59 @@
+package garage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestDataSourceClusterCapacityTotals(t *testing.T) {
+	calls := 0
+	p := newTestProvider(clusterStatusResponder(t, []string{`{"layoutVersion":3,"nodes":[
+		{"draining":false,"id":"node-1","isUp":true,"role":{"zone":"a","tags":[],"capacity":1000},"dataPartition":{"available":600,"total":1000}},
+		{"draining":false,"id":"node-2","isUp":true,"role":{"zone":"b","tags":[],"capacity":2000},"dataPartition":{"available":1500,"total":2000}},
+		{"draining":false,"id":"gateway","isUp":true,"role":{"zone":"a","tags":[],"capacity":null}}]}`}, &calls))
+
+	d := schema.TestResourceDataRaw(t, dataSourceClusterCapacity().Schema, map[string]interface{}{})
+	if diags := dataSourceClusterCapacityRead(context.Background(), d, p); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+
+	for key, want := range map[string]int{
+		"total_capacity":     3000,
+		"used_capacity":      900,
+		"available_capacity": 2100,
+		"storage_nodes":      2,
+	} {
+		if got := d.Get(key).(int); got != want {
+			t.Fatalf("expected %s %d, got %d", key, want, got)
+		}
+	}
+	if d.Id() != "cluster-capacity:3" {
+		t.Fatalf("unexpected id %q", d.Id())
+	}
+}
+
+// clusterStatusResponder answers GetClusterStatus with statuses in order,
+// repeating the last one, and counts the calls.
+func clusterStatusResponder(t *testing.T, statuses []string, calls *int) keyRoundTripper {
+	return func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path != "/v2/GetClusterStatus" {
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+		body := statuses[len(statuses)-1]
+		if *calls < len(statuses) {
+			body = statuses[*calls]
+		}
+		*calls++
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Status:     "200 OK",
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+		}, nil
+	}
+}
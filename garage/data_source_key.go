@@ -0,0 +1,77 @@
+package garage
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+/*
+Data source: garage_key
+
+Looks up a single access key through AccessKeyAPI.GetKeyInfo. The secret is
+never requested. With error_if_not_found = false a missing key is reported
+through exists = false instead of failing.
+*/
+
+func dataSourceKey() *schema.Resource {
+	return &schema.Resource{
+		Description: "Looks up an access key by ID. The secret access key is not exposed.",
+		ReadContext: dataSourceKeyRead,
+		Schema: map[string]*schema.Schema{
+			"access_key_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Access key ID to look up.",
+			},
+			"error_if_not_found": errorIfNotFoundSchema("key"),
+			"exists":             existsSchema("key"),
+			"name": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Human-friendly label of the access key.",
+			},
+			"created": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Timestamp (RFC3339) when the key was created, in the provider `display_timezone` (UTC by default).",
+			},
+			"expired": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "True if the key is expired.",
+			},
+		},
+	}
+}
+
+func dataSourceKeyRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	p := m.(*garageProvider)
+
+	keyID := d.Get("access_key_id").(string)
+	resp, httpResp, err := p.client.AccessKeyAPI.
+		GetKeyInfo(p.withToken(ctx)).
+		Id(keyID).
+		Execute()
+	if err != nil && (httpResp == nil || httpResp.StatusCode != http.StatusNotFound) {
+		return createDiagnostics(err, httpResp)
+	}
+	if err != nil || resp == nil {
+		return dataSourceNotFound(d, keyID, "access key not found", fmt.Sprintf("access_key_id %q does not exist", keyID))
+	}
+
+	created := ""
+	if t, ok := resp.GetCreatedOk(); ok && t != nil && !t.IsZero() {
+		created = p.formatTimestamp(*t)
+	}
+
+	d.SetId(resp.GetAccessKeyId())
+	_ = d.Set("exists", true)
+	_ = d.Set("name", resp.GetName())
+	_ = d.Set("created", created)
+	_ = d.Set("expired", resp.GetExpired())
+	return nil
+}
@@ -0,0 +1,34 @@
+package garage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestDataSourceKeyNotFound(t *testing.T) {
+	p := newTestProvider(notFoundRoundTripper(t, "/v2/GetKeyInfo"))
+
+	d := schema.TestResourceDataRaw(t, dataSourceKey().Schema, map[string]interface{}{
+		"access_key_id": "GKmissing",
+	})
+	diags := dataSourceKeyRead(context.Background(), d, p)
+	if len(diags) != 1 || diags[0].Summary != "access key not found" {
+		t.Fatalf("expected access key not found diagnostic, got %#v", diags)
+	}
+
+	d = schema.TestResourceDataRaw(t, dataSourceKey().Schema, map[string]interface{}{
+		"access_key_id":      "GKmissing",
+		"error_if_not_found": false,
+	})
+	if diags := dataSourceKeyRead(context.Background(), d, p); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	if d.Get("exists").(bool) || d.Get("name").(string) != "" {
+		t.Fatalf("expected exists=false and empty fields, got exists=%v name=%q", d.Get("exists"), d.Get("name"))
+	}
+	if d.Id() != "GKmissing" {
+		t.Fatalf("expected lookup value as id, got %q", d.Id())
+	}
+}
@@ -0,0 +1,65 @@
+package garage
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+/*
+Data source: garage_provider_info
+
+Exposes what version detection found while configuring the provider. No API
+call is made unless lazy_connect deferred detection, in which case it runs here.
+api_calls reports the per-path request counts when collect_api_metrics is set.
+*/
+
+func dataSourceProviderInfo() *schema.Resource {
+	return &schema.Resource{
+		Description: "Reports which admin API version served the provider and the detected Garage version.",
+		ReadContext: dataSourceProviderInfoRead,
+		Schema: map[string]*schema.Schema{
+			"api_version": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Admin API version that answered version detection (`v2` or `v1`).",
+			},
+			"garage_version": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Lowest Garage version reported by the cluster nodes.",
+			},
+			"api_calls": {
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeInt},
+				Description: "Admin API requests made so far by this provider instance, keyed by request path. Empty unless `collect_api_metrics` is enabled on the provider.",
+			},
+		},
+	}
+}
+
+func dataSourceProviderInfoRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	p := m.(*garageProvider)
+
+	// with lazy_connect, detection may not have run yet
+	if err := p.ensureConnected(ctx); err != nil {
+		return diag.FromErr(err)
+	}
+
+	apiVersion, garageVersion := p.detectedVersion()
+	_ = d.Set("api_version", apiVersion)
+	_ = d.Set("garage_version", garageVersion)
+
+	calls := map[string]interface{}{}
+	if p.apiCalls != nil {
+		for path, n := range p.apiCalls.snapshot() {
+			calls[path] = n
+		}
+	}
+	_ = d.Set("api_calls", calls)
+
+	d.SetId(apiVersion + ":" + garageVersion)
+	return nil
+}
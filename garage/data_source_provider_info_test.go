@@ -0,0 +1,71 @@
+package garage
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestDataSourceProviderInfoAfterV2Detection(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"layoutVersion":1,"nodes":[{"draining":false,"id":"node-1","isUp":true,"garageVersion":"2.2.0"},{"draining":false,"id":"node-2","isUp":true,"garageVersion":"v2.1.0"}]}`)
+	}))
+	defer server.Close()
+
+	provider := Provider()
+	pd := schema.TestResourceDataRaw(t, provider.Schema, map[string]interface{}{
+		"host":  server.URL,
+		"token": "token",
+	})
+	cfg, diags := providerConfigure(context.Background(), pd)
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+
+	d := schema.TestResourceDataRaw(t, dataSourceProviderInfo().Schema, map[string]interface{}{})
+	if diags := dataSourceProviderInfoRead(context.Background(), d, cfg); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	if v := d.Get("api_version").(string); v != "v2" {
+		t.Fatalf("expected api_version v2, got %q", v)
+	}
+	if v := d.Get("garage_version").(string); v != "2.1.0" {
+		t.Fatalf("expected lowest node version, got %q", v)
+	}
+	if d.Id() == "" {
+		t.Fatalf("expected id to be set")
+	}
+}
+
+func TestDataSourceProviderInfoAPICalls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"layoutVersion":1,"nodes":[{"draining":false,"id":"node-1","isUp":true,"garageVersion":"2.2.0"}]}`)
+	}))
+	defer server.Close()
+
+	provider := Provider()
+	pd := schema.TestResourceDataRaw(t, provider.Schema, map[string]interface{}{
+		"host":                server.URL,
+		"token":               "token",
+		"collect_api_metrics": true,
+	})
+	cfg, diags := providerConfigure(context.Background(), pd)
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+
+	d := schema.TestResourceDataRaw(t, dataSourceProviderInfo().Schema, map[string]interface{}{})
+	if diags := dataSourceProviderInfoRead(context.Background(), d, cfg); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	calls := d.Get("api_calls").(map[string]interface{})
+	if calls["/v2/GetClusterStatus"] != 1 || len(calls) != 1 {
+		t.Fatalf("expected the detection call to be counted, got %v", calls)
+	}
+}
@@ -0,0 +1,96 @@
+package garage
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+/*
+Data source: garage_version_info
+
+Summarises the Garage versions running across the cluster, as reported by
+ClusterAPI.GetClusterStatus. Useful as a pre-upgrade check, e.g. to assert that
+a rolling upgrade has reached every node before changing configuration.
+*/
+
+func dataSourceVersionInfo() *schema.Resource {
+	return &schema.Resource{
+		Description: "Reports the lowest, highest and distinct Garage versions across cluster nodes.",
+		ReadContext: dataSourceVersionInfoRead,
+		Schema: map[string]*schema.Schema{
+			"min_version": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Lowest Garage version reported by a node.",
+			},
+			"max_version": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Highest Garage version reported by a node.",
+			},
+			"all_same": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether every node runs the same Garage version.",
+			},
+			"versions": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Distinct Garage versions across nodes, in ascending order.",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func dataSourceVersionInfoRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	p := m.(*garageProvider)
+
+	status, httpResp, err := p.client.ClusterAPI.
+		GetClusterStatus(p.withToken(ctx)).
+		Execute()
+	if err != nil {
+		return createDiagnostics(err, httpResp)
+	}
+
+	// allow_unversioned_nodes skips nodes reporting no version, e.g. while bootstrapping
+	minSeen, maxSeen, err := clusterSemverRangeFromV2(status, p.allowUnversionedNodes)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if minSeen == nil {
+		return diag.Errorf("cluster status reported no node with a garageVersion")
+	}
+
+	// every reported node version was validated above, so parsing cannot fail here
+	distinct := map[string]*semver.Version{}
+	for _, n := range status.Nodes {
+		if n.GetGarageVersion() == "" {
+			continue
+		}
+		norm, _ := normalizeVersion(n.GetGarageVersion())
+		v, _ := semver.NewVersion(norm)
+		distinct[v.String()] = v
+	}
+	sorted := make([]*semver.Version, 0, len(distinct))
+	for _, v := range distinct {
+		sorted = append(sorted, v)
+	}
+	sort.Sort(semver.Collection(sorted))
+	versions := make([]string, 0, len(sorted))
+	for _, v := range sorted {
+		versions = append(versions, v.String())
+	}
+
+	_ = d.Set("min_version", minSeen.String())
+	_ = d.Set("max_version", maxSeen.String())
+	_ = d.Set("all_same", len(versions) == 1)
+	_ = d.Set("versions", versions)
+
+	d.SetId(minSeen.String() + ":" + maxSeen.String())
+	return nil
+}
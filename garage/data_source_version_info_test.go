@@ -0,0 +1,61 @@
+package garage
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestDataSourceVersionInfoMixedVersions(t *testing.T) {
+	calls := 0
+	p := newTestProvider(clusterStatusResponder(t, []string{`{"layoutVersion":1,"nodes":[
+		{"draining":false,"id":"node-1","isUp":true,"garageVersion":"v2.1.0"},
+		{"draining":false,"id":"node-2","isUp":true,"garageVersion":"2.0.0"},
+		{"draining":false,"id":"node-3","isUp":true,"garageVersion":"2.1.0"}]}`}, &calls))
+
+	d := schema.TestResourceDataRaw(t, dataSourceVersionInfo().Schema, map[string]interface{}{})
+	if diags := dataSourceVersionInfoRead(context.Background(), d, p); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+
+	if v := d.Get("min_version").(string); v != "2.0.0" {
+		t.Fatalf("expected min_version 2.0.0, got %q", v)
+	}
+	if v := d.Get("max_version").(string); v != "2.1.0" {
+		t.Fatalf("expected max_version 2.1.0, got %q", v)
+	}
+	if d.Get("all_same").(bool) {
+		t.Fatalf("expected all_same to be false")
+	}
+	want := []interface{}{"2.0.0", "2.1.0"}
+	if got := d.Get("versions").([]interface{}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected versions %v, got %v", want, got)
+	}
+}
+
+func TestDataSourceVersionInfoAllowUnversionedNodes(t *testing.T) {
+	calls := 0
+	status := `{"layoutVersion":0,"nodes":[
+		{"draining":false,"id":"node-1","isUp":true,"garageVersion":"2.1.0"},
+		{"draining":false,"id":"node-2","isUp":true}]}`
+	p := newTestProvider(clusterStatusResponder(t, []string{status, status}, &calls))
+
+	d := schema.TestResourceDataRaw(t, dataSourceVersionInfo().Schema, map[string]interface{}{})
+	if diags := dataSourceVersionInfoRead(context.Background(), d, p); !diags.HasError() {
+		t.Fatalf("expected an unversioned node to fail without allow_unversioned_nodes")
+	}
+
+	p.allowUnversionedNodes = true
+	if diags := dataSourceVersionInfoRead(context.Background(), d, p); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	if v := d.Get("min_version").(string); v != "2.1.0" {
+		t.Fatalf("expected min_version of the versioned node, got %q", v)
+	}
+	want := []interface{}{"2.1.0"}
+	if got := d.Get("versions").([]interface{}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected versions %v, got %v", want, got)
+	}
+}
@@ -1,13 +1,17 @@
 package garage
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
 
+	garage "git.deuxfleurs.fr/garage-sdk/garage-admin-sdk-golang"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 type garageAPIError struct {
@@ -17,11 +21,38 @@ type garageAPIError struct {
 }
 
 func createDiagnostics(err error, resp *http.Response) diag.Diagnostics {
+	var tooLarge *responseTooLargeError
+	if errors.As(err, &tooLarge) {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Garage API response too large",
+			Detail:   fmt.Sprintf("%v; raise max_response_size on the provider if responses this large are expected", tooLarge),
+		}}
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return timeoutDiagnostics(err)
+	}
 	if resp == nil {
 		return diag.FromErr(err)
 	}
 	defer resp.Body.Close()
 
+	// any 2xx is a success whatever the exact code; an error here means the
+	// body did not decode into the expected model
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  fmt.Sprintf("unexpected Garage API response (%d %s)", resp.StatusCode, http.StatusText(resp.StatusCode)),
+			Detail:   fmt.Sprintf("The request succeeded but its response could not be read: %v. The Garage version may not match the API this provider was built for.", err),
+		}}
+	}
+
 	summary := fmt.Sprintf("Garage API error (%d %s)", resp.StatusCode, http.StatusText(resp.StatusCode))
 
 	d := diag.Diagnostic{
@@ -29,25 +60,121 @@ func createDiagnostics(err error, resp *http.Response) diag.Diagnostics {
 		Summary:  summary,
 	}
 
+	// the SDK error already carries the body it read (and possibly a decoded model)
+	if detail := openAPIErrorDetail(err); detail != "" {
+		d.Detail = detail
+		return diag.Diagnostics{d}
+	}
+
 	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
-	if len(body) > 0 {
-		// Try JSON
-		var ge garageAPIError
-		if json.Unmarshal(body, &ge) == nil {
-			if msg := strings.TrimSpace(firstNonEmpty(ge.Message, ge.Error, ge.Detail)); msg != "" {
-				d.Detail = msg
-				return diag.Diagnostics{d}
+	if detail := errorBodyDetail(body); detail != "" {
+		d.Detail = detail
+		return diag.Diagnostics{d}
+	}
+
+	d.Detail = "empty response body"
+	return diag.Diagnostics{d}
+}
+
+// timeoutDiagnostics reports a request that ran into a deadline, with the
+// provider timeout that expired when it is known.
+func timeoutDiagnostics(err error) diag.Diagnostics {
+	detail := fmt.Sprintf("%v\n\nThe Garage admin API did not answer in time. Increase request_timeout (or read_timeout / write_timeout) on the provider, or the resource's timeouts if it sets any.", err)
+	var rt *requestTimeoutError
+	if errors.As(err, &rt) {
+		detail = fmt.Sprintf("The Garage admin API did not answer within the configured timeout of %s. Increase request_timeout (or read_timeout / write_timeout for reads and writes) on the provider if the cluster needs longer.", rt.timeout)
+	}
+	return diag.Diagnostics{{
+		Severity: diag.Error,
+		Summary:  "Garage API request timed out",
+		Detail:   detail,
+	}}
+}
+
+// openAPIErrorDetail extracts a message from a *garage.GenericOpenAPIError,
+// preferring its decoded model over the raw body. It returns "" for other errors.
+func openAPIErrorDetail(err error) string {
+	var apiErr *garage.GenericOpenAPIError
+	if !errors.As(err, &apiErr) {
+		return ""
+	}
+	if model := apiErr.Model(); model != nil {
+		if raw, mErr := json.Marshal(model); mErr == nil {
+			var ge garageAPIError
+			if json.Unmarshal(raw, &ge) == nil {
+				if msg := strings.TrimSpace(firstNonEmpty(ge.Message, ge.Error, ge.Detail)); msg != "" {
+					return msg
+				}
 			}
 		}
-		// Fallback: raw text
-		if raw := strings.TrimSpace(string(body)); raw != "" {
-			d.Detail = raw
-			return diag.Diagnostics{d}
+	}
+	return errorBodyDetail(apiErr.Body())
+}
+
+// errorBodyDetail returns the message of a Garage JSON error body, or the trimmed
+// raw text when it is not one. An HTML page is summarised instead, since it
+// comes from whatever answered in front of the admin API.
+func errorBodyDetail(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	var ge garageAPIError
+	if json.Unmarshal(body, &ge) == nil {
+		if msg := strings.TrimSpace(firstNonEmpty(ge.Message, ge.Error, ge.Detail)); msg != "" {
+			return msg
+		}
+	}
+	text := strings.TrimSpace(string(body))
+	if isHTMLBody(text) {
+		if len(text) > 200 {
+			text = text[:200] + "…"
 		}
+		return "The response is an HTML page, not a Garage API error: a reverse proxy or another service answered the request. Check that host and scheme point to the Garage admin API.\n\n" + text
 	}
+	return text
+}
 
-	d.Detail = "empty response body"
-	return diag.Diagnostics{d}
+// isHTMLBody reports whether a response body is an HTML document.
+func isHTMLBody(text string) bool {
+	lower := strings.ToLower(text)
+	return strings.HasPrefix(lower, "<!doctype html") || strings.HasPrefix(lower, "<html")
+}
+
+// isAlreadyExists reports whether a create failed because the object already
+// exists: a 409, or an error message saying so (Garage answers some alias
+// conflicts with a 400).
+func isAlreadyExists(err error, resp *http.Response) bool {
+	if resp != nil && resp.StatusCode == http.StatusConflict {
+		return true
+	}
+	return strings.Contains(strings.ToLower(openAPIErrorDetail(err)), "already exist")
+}
+
+// alreadyExistsDiagnostics reports a create conflict together with the
+// terraform import command that brings the existing object under management.
+// importID is the ID the resource's importer expects.
+func alreadyExistsDiagnostics(resourceType, importID string, err error, resp *http.Response) diag.Diagnostics {
+	diags := createDiagnostics(err, resp)
+	detail := diags[0].Detail
+	if detail == "" {
+		detail = diags[0].Summary
+	}
+	return diag.Diagnostics{{
+		Severity: diag.Error,
+		Summary:  resourceType + " already exists",
+		Detail: fmt.Sprintf("%s\n\nTo manage the existing object with Terraform, import it instead of creating it:\n\n  terraform import %s.<name> %s",
+			detail, resourceType, importID),
+	}}
+}
+
+// emptyResponseDiagnostics reports an SDK call that returned neither a result
+// nor an error, instead of dereferencing the nil result.
+func emptyResponseDiagnostics(operation string) diag.Diagnostics {
+	return diag.Diagnostics{{
+		Severity: diag.Error,
+		Summary:  "empty response from API",
+		Detail:   fmt.Sprintf("%s returned no error but also no response body", operation),
+	}}
 }
 
 func firstNonEmpty(values ...string) string {
@@ -58,3 +185,55 @@ func firstNonEmpty(values ...string) string {
 	}
 	return ""
 }
+
+// crudFunc is the shape shared by the SDK's Create/Read/Update/DeleteContext funcs.
+type crudFunc = func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics
+
+// withResourceDiagnostics returns a wrapper for CRUD functions so every error
+// diagnostic they return names the affected resource. The resource ID is used
+// when known; before it is assigned (e.g. a failed create) the non-empty values
+// of keys are joined instead.
+func withResourceDiagnostics(kind string, keys ...string) func(crudFunc) crudFunc {
+	return func(fn crudFunc) crudFunc {
+		return func(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+			diags := fn(ctx, d, m)
+			if !diags.HasError() {
+				return diags
+			}
+			return annotateDiagnostics(diags, kind, resourceLabel(d, keys))
+		}
+	}
+}
+
+// resourceLabel identifies a resource by its ID, or by the configured keys while it has none.
+func resourceLabel(d *schema.ResourceData, keys []string) string {
+	if id := d.Id(); id != "" {
+		return id
+	}
+	var parts []string
+	for _, k := range keys {
+		if v, ok := d.Get(k).(string); ok && v != "" {
+			parts = append(parts, v)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// annotateDiagnostics adds "for <kind> <id>" to the summary of every error diagnostic.
+func annotateDiagnostics(diags diag.Diagnostics, kind, id string) diag.Diagnostics {
+	if id == "" {
+		return diags
+	}
+	target := kind + " " + id
+	for i := range diags {
+		if diags[i].Severity != diag.Error {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(diags[i].Summary, "Garage API error"); ok {
+			diags[i].Summary = "Garage API error for " + target + rest
+			continue
+		}
+		diags[i].Summary = fmt.Sprintf("%s (%s)", diags[i].Summary, target)
+	}
+	return diags
+}
@@ -2,10 +2,16 @@ package garage
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 func TestFirstNonEmpty(t *testing.T) {
@@ -46,3 +52,148 @@ func TestCreateDiagnosticsPlainText(t *testing.T) {
 		t.Fatalf("expected raw body to be propagated, got %#v", diags)
 	}
 }
+
+func TestCreateDiagnosticsHTMLBody(t *testing.T) {
+	resp := &http.Response{
+		StatusCode: http.StatusBadGateway,
+		Status:     "502 Bad Gateway",
+		Body:       io.NopCloser(strings.NewReader("<html><head><title>502 Bad Gateway</title></head><body>nginx</body></html>")),
+	}
+
+	diags := createDiagnostics(io.EOF, resp)
+	if len(diags) != 1 || !strings.Contains(diags[0].Detail, "not a Garage API error") {
+		t.Fatalf("expected an HTML body to be explained, got %#v", diags)
+	}
+	if !strings.Contains(diags[0].Detail, "<title>502 Bad Gateway</title>") {
+		t.Fatalf("expected the start of the page in the detail, got %q", diags[0].Detail)
+	}
+}
+
+func TestResourceDiagnosticsNameTheResource(t *testing.T) {
+	p := newTestProvider(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusInternalServerError,
+			Status:     "500 Internal Server Error",
+			Body:       io.NopCloser(strings.NewReader(`{"message":"boom"}`)),
+		}, nil
+	})
+
+	res := resourceBucket()
+	d := schema.TestResourceDataRaw(t, res.Schema, map[string]interface{}{})
+	d.SetId("bucket-123")
+	diags := res.ReadContext(context.Background(), d, p)
+	if len(diags) != 1 || !strings.HasPrefix(diags[0].Summary, "Garage API error for bucket bucket-123 (500") {
+		t.Fatalf("expected bucket ID in summary, got %#v", diags)
+	}
+
+	// no ID yet: fall back to the configured identifiers
+	res = resourceBucketKey()
+	d = schema.TestResourceDataRaw(t, res.Schema, map[string]interface{}{
+		"bucket_id":     "bucket-123",
+		"access_key_id": "GK123",
+		"read":          true,
+	})
+	diags = res.CreateContext(context.Background(), d, p)
+	if !diags.HasError() || !strings.Contains(diags[0].Summary, "bucket key bucket-123/GK123") {
+		t.Fatalf("expected bucket and key IDs in summary, got %#v", diags)
+	}
+}
+
+func TestAnnotateDiagnosticsNonAPIError(t *testing.T) {
+	diags := annotateDiagnostics(diag.Diagnostics{
+		{Severity: diag.Error, Summary: "invalid expiration"},
+		{Severity: diag.Warning, Summary: "heads up"},
+	}, "key", "GK1")
+	if diags[0].Summary != "invalid expiration (key GK1)" {
+		t.Fatalf("unexpected summary %q", diags[0].Summary)
+	}
+	if diags[1].Summary != "heads up" {
+		t.Fatalf("expected warnings to be left alone, got %q", diags[1].Summary)
+	}
+}
+
+func TestCreateDiagnosticsPrefersOpenAPIErrorBody(t *testing.T) {
+	p := newTestProvider(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusForbidden,
+			Status:     "403 Forbidden",
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"code":"Forbidden","message":"token lacks bucket permissions"}`)),
+		}, nil
+	})
+
+	_, httpResp, err := p.client.BucketAPI.ListBuckets(p.withToken(context.Background())).Execute()
+	if err == nil || httpResp == nil {
+		t.Fatalf("expected an API error with a response, got %v / %v", err, httpResp)
+	}
+	// the response body is no longer readable; the SDK error still holds it
+	httpResp.Body = io.NopCloser(strings.NewReader(""))
+
+	diags := createDiagnostics(err, httpResp)
+	if len(diags) != 1 || diags[0].Detail != "token lacks bucket permissions" {
+		t.Fatalf("expected message from the SDK error body, got %#v", diags)
+	}
+}
+
+func TestEmptyAPIResponseIsReportedNotPanicking(t *testing.T) {
+	p := newTestProvider(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Status:     "200 OK",
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader("")),
+		}, nil
+	})
+	ctx := context.Background()
+
+	bucket := schema.TestResourceDataRaw(t, resourceBucket().Schema, map[string]interface{}{"global_alias": "site"})
+	key := schema.TestResourceDataRaw(t, resourceKey().Schema, map[string]interface{}{"name": "app"})
+	existing := schema.TestResourceDataRaw(t, resourceKey().Schema, map[string]interface{}{"name": "app"})
+	existing.SetId("GK1")
+	bucketKey := schema.TestResourceDataRaw(t, resourceBucketKey().Schema, map[string]interface{}{"bucket_id": "bucket", "access_key_id": "GK1"})
+	bucketKey.SetId("bucket:GK1")
+	alias := schema.TestResourceDataRaw(t, resourceBucketAlias().Schema, map[string]interface{}{"bucket_id": "bucket", "global_alias": "site"})
+	alias.SetId("global:site")
+	existingBucket := schema.TestResourceDataRaw(t, resourceBucket().Schema, map[string]interface{}{"global_alias": "site"})
+	existingBucket.SetId("bucket")
+
+	cases := map[string]func() diag.Diagnostics{
+		"bucket create":     func() diag.Diagnostics { return resourceBucketCreate(ctx, bucket, p) },
+		"key create":        func() diag.Diagnostics { return resourceKeyCreate(ctx, key, p) },
+		"key read":          func() diag.Diagnostics { return resourceKeyRead(ctx, existing, p) },
+		"key revoke":        func() diag.Diagnostics { return revokeKeyBucketAccess(ctx, p, "GK1") },
+		"bucket key read":   func() diag.Diagnostics { return resourceBucketKeyRead(ctx, bucketKey, p) },
+		"bucket alias read": func() diag.Diagnostics { return resourceBucketAliasRead(ctx, alias, p) },
+		"bucket read":       func() diag.Diagnostics { return resourceBucketRead(ctx, existingBucket, p) },
+	}
+	for name, call := range cases {
+		diags := call()
+		if !diags.HasError() || diags[0].Summary != "empty response from API" {
+			t.Fatalf("%s: expected empty response diagnostic, got %#v", name, diags)
+		}
+	}
+	if bucket.Id() != "" || key.Id() != "" {
+		t.Fatalf("expected no ID to be recorded from an empty response")
+	}
+	// an empty response is not proof that the bucket is gone
+	if bucketKey.Id() == "" || alias.Id() == "" || existingBucket.Id() == "" {
+		t.Fatalf("expected bucket, bucket key and alias to stay in state after an empty response")
+	}
+}
+
+func TestCreateDiagnosticsDeadlineExceeded(t *testing.T) {
+	diags := createDiagnostics(context.DeadlineExceeded, nil)
+	if len(diags) != 1 || diags[0].Summary != "Garage API request timed out" {
+		t.Fatalf("expected timeout diagnostic, got %#v", diags)
+	}
+	if !strings.Contains(diags[0].Detail, "request_timeout") {
+		t.Fatalf("expected suggestion to raise request_timeout, got %q", diags[0].Detail)
+	}
+
+	// the provider timeout is named when the deadline transport cut the request
+	err := &url.Error{Op: "Get", URL: "https://example.com/v2/GetClusterStatus", Err: &requestTimeoutError{timeout: 15 * time.Second, err: context.DeadlineExceeded}}
+	diags = createDiagnostics(err, nil)
+	if !strings.Contains(diags[0].Detail, "15s") || !strings.Contains(diags[0].Detail, "request_timeout") {
+		t.Fatalf("expected configured timeout in detail, got %q", diags[0].Detail)
+	}
+}
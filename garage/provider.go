@@ -1,14 +1,22 @@
 package garage
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/url"
+	"os"
+	"sort"
 	"strings"
+	"sync"
 	"time"
+	// display_timezone must resolve the same on hosts without a zoneinfo database
+	_ "time/tzdata"
 
 	garage "git.deuxfleurs.fr/garage-sdk/garage-admin-sdk-golang"
 	"github.com/Masterminds/semver/v3"
@@ -27,13 +35,91 @@ type garageProvider struct {
 	client     *garage.APIClient
 	token      string
 	httpClient *http.Client
+	// namePrefix is prepended to created key names and bucket global aliases
+	namePrefix string
+	// defaultIndexDocument fills in website_config_index_document when a bucket
+	// enables website access without one
+	defaultIndexDocument string
+
+	// versionMu guards the detection results below; resources run in parallel
+	// and may read them while a re-detection records new values.
+	versionMu sync.RWMutex
+	// apiVersion ("v1" or "v2") and garageVersion are recorded by version detection
+	apiVersion    string
+	garageVersion string
+
+	// connect holds version detection and the configure-time checks when
+	// lazy_connect defers them. It runs on first use and again on later requests
+	// until it succeeds; connectMu serialises the attempts.
+	connect   func(ctx context.Context) diag.Diagnostics
+	connectMu sync.Mutex
+	connected bool
+
+	// checkScope holds the validate_token_scope check. It runs before mutating
+	// requests until it passes once; scopeMu serialises the attempts.
+	checkScope func(ctx context.Context) diag.Diagnostics
+	scopeMu    sync.Mutex
+	scopeOK    bool
+
+	// apiCalls counts admin API requests per path when collect_api_metrics is set
+	apiCalls *callCountingTransport
+
+	// bucketInfo shares GetBucketInfo responses between resources when
+	// cache_bucket_info is set; nil otherwise.
+	bucketInfo *bucketInfoCache
+
+	// allowUnversionedNodes mirrors allow_unversioned_nodes for the version
+	// checks made after configure, e.g. by garage_version_info.
+	allowUnversionedNodes bool
+
+	// displayLocation is the display_timezone computed timestamps are rendered
+	// in; nil means UTC.
+	displayLocation *time.Location
+}
+
+// setDetectedVersion records the outcome of version detection.
+func (p *garageProvider) setDetectedVersion(apiVersion, garageVersion string) {
+	p.versionMu.Lock()
+	defer p.versionMu.Unlock()
+	p.apiVersion = apiVersion
+	p.garageVersion = garageVersion
+}
+
+// detectedVersion returns the API version and Garage version found by detection.
+func (p *garageProvider) detectedVersion() (apiVersion, garageVersion string) {
+	p.versionMu.RLock()
+	defer p.versionMu.RUnlock()
+	return p.apiVersion, p.garageVersion
 }
 
-// withToken attaches the bearer token to a context
+// withToken attaches the bearer token to a context. It adds no deadline: the
+// provider request timeout is applied by deadlineTransport, and any deadline
+// already on ctx is honoured when it is shorter.
 func (p *garageProvider) withToken(ctx context.Context) context.Context {
 	return context.WithValue(ctx, garage.ContextAccessToken, p.token)
 }
 
+// location returns the time zone computed timestamps are rendered in.
+func (p *garageProvider) location() *time.Location {
+	if p == nil || p.displayLocation == nil {
+		return time.UTC
+	}
+	return p.displayLocation
+}
+
+// formatTimestamp renders t as RFC3339 in the display_timezone.
+func (p *garageProvider) formatTimestamp(t time.Time) string {
+	return t.In(p.location()).Format(time.RFC3339)
+}
+
+// prefixName prepends the configured resource_name_prefix, without doubling it.
+func (p *garageProvider) prefixName(name string) string {
+	if p.namePrefix == "" || strings.HasPrefix(name, p.namePrefix) {
+		return name
+	}
+	return p.namePrefix + name
+}
+
 // Provider defines the Terraform provider schema and resources
 func Provider() *schema.Provider {
 	return &schema.Provider{
@@ -45,9 +131,10 @@ func Provider() *schema.Provider {
 				DefaultFunc: schema.EnvDefaultFunc("GARAGE_HOST", nil),
 			},
 			"scheme": {
-				Type:        schema.TypeString,
-				Optional:    true,
-				DefaultFunc: schema.EnvDefaultFunc("GARAGE_SCHEME", "https"),
+				Type:     schema.TypeString,
+				Optional: true,
+				// Left empty when unset so the scheme can be inferred from the host; falls back to https.
+				DefaultFunc: schema.EnvDefaultFunc("GARAGE_SCHEME", nil),
 				ValidateFunc: func(v interface{}, k string) (ws []string, es []error) {
 					s := v.(string)
 					if s != "http" && s != "https" {
@@ -62,23 +149,256 @@ func Provider() *schema.Provider {
 				Sensitive:   true,
 				DefaultFunc: schema.EnvDefaultFunc("GARAGE_TOKEN", nil),
 			},
+			"url": {
+				Type:      schema.TypeString,
+				Optional:  true,
+				Sensitive: true,
+				// Combined form "https://<token>@garage.example.com:3903"; replaces host, scheme and token.
+				DefaultFunc:   schema.EnvDefaultFunc("GARAGE_URL", nil),
+				ConflictsWith: []string{"host", "scheme", "token"},
+			},
+			"profile": {
+				Type:     schema.TypeString,
+				Optional: true,
+				// Named section of config_file whose host, scheme and token fill in arguments left unset.
+				DefaultFunc: schema.EnvDefaultFunc("GARAGE_PROFILE", nil),
+			},
+			"config_file": {
+				Type:     schema.TypeString,
+				Optional: true,
+				// JSON file mapping profile names to {"host", "scheme", "token"}; required with profile.
+				DefaultFunc: schema.EnvDefaultFunc("GARAGE_CONFIG_FILE", nil),
+			},
+			"client_cert_pem": {
+				Type:     schema.TypeString,
+				Optional: true,
+				// PEM client certificate for mutual TLS, used by the SDK and the v1 probe.
+				DefaultFunc:  schema.EnvDefaultFunc("GARAGE_CLIENT_CERT_PEM", nil),
+				RequiredWith: []string{"client_key_pem"},
+			},
+			"client_key_pem": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Sensitive:    true,
+				DefaultFunc:  schema.EnvDefaultFunc("GARAGE_CLIENT_KEY_PEM", nil),
+				RequiredWith: []string{"client_cert_pem"},
+			},
+			"min_tls_version": {
+				Type:     schema.TypeString,
+				Optional: true,
+				// "1.2" or "1.3"; applies to the SDK client and the v1 probe. Unset keeps the Go default.
+				DefaultFunc: schema.EnvDefaultFunc("GARAGE_MIN_TLS_VERSION", nil),
+				ValidateFunc: func(v interface{}, k string) (ws []string, es []error) {
+					if _, ok := tlsVersions[v.(string)]; !ok {
+						es = append(es, fmt.Errorf("%q must be one of [1.2 1.3], got %q", k, v.(string)))
+					}
+					return
+				},
+			},
+			"prefer_api_version": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "auto",
+				// "v1" skips the v2 probe on clusters that only expose v1; "auto" tries v2 then v1.
+				ValidateFunc: func(v interface{}, k string) (ws []string, es []error) {
+					s := v.(string)
+					if s != "auto" && s != "v1" && s != "v2" {
+						es = append(es, fmt.Errorf("%q must be one of [auto v1 v2], got %q", k, s))
+					}
+					return
+				},
+			},
+			"resource_name_prefix": {
+				Type:     schema.TypeString,
+				Optional: true,
+				// Tags created keys and global aliases so test sweepers can find leftovers.
+				DefaultFunc: schema.EnvDefaultFunc("GARAGE_RESOURCE_NAME_PREFIX", nil),
+			},
+			"cluster_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+				// Guards against cross-wired provider aliases; only warns while the admin API reports no cluster ID.
+				DefaultFunc: schema.EnvDefaultFunc("GARAGE_CLUSTER_ID", nil),
+			},
+			"require_layout": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				// Fails configure while the cluster has no layout with a storage node, since buckets could not hold data.
+			},
+			"display_timezone": {
+				Type:     schema.TypeString,
+				Optional: true,
+				// IANA time zone name (e.g. "Europe/Zurich") for computed timestamps such as created; UTC when unset.
+				ValidateFunc: func(v interface{}, k string) (ws []string, es []error) {
+					if _, err := time.LoadLocation(v.(string)); err != nil {
+						es = append(es, fmt.Errorf("%q: unknown time zone %q", k, v.(string)))
+					}
+					return
+				},
+			},
+			"default_website_index_document": {
+				Type:     schema.TypeString,
+				Optional: true,
+				// Used by garage_bucket when website access is enabled without website_config_index_document.
+			},
+			"allow_unversioned_nodes": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				// Skips the version check for nodes that report no garageVersion (e.g. while bootstrapping a
+				// cluster); nodes that do report one must still be v2+.
+			},
+			"lazy_connect": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				// Defers version detection and configure-time checks to the first API request.
+			},
+			"validate_token_scope": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				// Makes one privileged call (ListBuckets) before the first mutating request to fail early on a
+				// non-admin token; read-only use (e.g. data sources) is not blocked.
+			},
+			"retry_max_elapsed_seconds": {
+				Type:     schema.TypeInt,
+				Optional: true,
+				Default:  int(defaultRetryMaxElapsed / time.Second),
+				// Caps the total time spent retrying a single transient failure; 0 disables the cap.
+				ValidateFunc: validateNonNegativeInt,
+			},
+			"request_timeout": {
+				Type:     schema.TypeInt,
+				Optional: true,
+				Default:  int(defaultRequestTimeout / time.Second),
+				// Seconds allowed for a single admin API request.
+				ValidateFunc: validateNonNegativeInt,
+			},
+			"read_timeout": {
+				Type:     schema.TypeInt,
+				Optional: true,
+				// Seconds for GET requests; 0 falls back to request_timeout.
+				ValidateFunc: validateNonNegativeInt,
+			},
+			"write_timeout": {
+				Type:     schema.TypeInt,
+				Optional: true,
+				// Seconds for mutating requests; 0 falls back to request_timeout.
+				ValidateFunc: validateNonNegativeInt,
+			},
+			"cache_bucket_info": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				// Shares GetBucketInfo responses by bucket ID for bucketInfoCacheTTL; any write clears them.
+			},
+			"collect_api_metrics": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				// Counts admin API requests per path; exposed by garage_provider_info and logged at debug level.
+			},
+			"sdk_debug": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				// Logs every admin API request and response through tflog at debug level, with the
+				// Authorization header and secret keys redacted.
+			},
+			"max_response_size": {
+				Type:     schema.TypeInt,
+				Optional: true,
+				Default:  defaultMaxResponseSize,
+				// Largest admin API response body accepted, in bytes; bigger responses fail instead of being decoded. 0 disables the limit.
+				ValidateFunc: validateNonNegativeInt,
+			},
+			"retry_budget": {
+				Type:     schema.TypeInt,
+				Optional: true,
+				Default:  0,
+				// Total retries allowed across all requests of this provider; 0 leaves retries unbounded.
+				ValidateFunc: validateNonNegativeInt,
+			},
 		},
 		ResourcesMap: map[string]*schema.Resource{
-			"garage_bucket":       resourceBucket(),
-			"garage_bucket_alias": resourceBucketAlias(),
-			"garage_bucket_key":   resourceBucketKey(),
-			"garage_key":          resourceKey(),
+			"garage_bucket":            resourceBucket(),
+			"garage_bucket_alias":      resourceBucketAlias(),
+			"garage_bucket_alias_move": resourceBucketAliasMove(),
+			"garage_bucket_key":        resourceBucketKey(),
+			"garage_bucket_key_revoke": resourceBucketKeyRevoke(),
+			"garage_key":               resourceKey(),
+		},
+		DataSourcesMap: map[string]*schema.Resource{
+			"garage_bucket":                  dataSourceBucket(),
+			"garage_bucket_aliases":          dataSourceBucketAliases(),
+			"garage_bucket_keys":             dataSourceBucketKeys(),
+			"garage_bucket_list":             dataSourceBucketList(),
+			"garage_bucket_permission_audit": dataSourceBucketPermissionAudit(),
+			"garage_cluster_capacity":        dataSourceClusterCapacity(),
+			"garage_key":                     dataSourceKey(),
+			"garage_provider_info":           dataSourceProviderInfo(),
+			"garage_version_info":            dataSourceVersionInfo(),
 		},
-		DataSourcesMap:       map[string]*schema.Resource{},
 		ConfigureContextFunc: providerConfigure,
 	}
 }
 
+// validateNonNegativeInt rejects negative values for count and duration arguments.
+func validateNonNegativeInt(v interface{}, k string) (ws []string, es []error) {
+	if v.(int) < 0 {
+		es = append(es, fmt.Errorf("%q must not be negative, got %d", k, v.(int)))
+	}
+	return
+}
+
+// secondsDuration converts a seconds argument to a time.Duration.
+func secondsDuration(s int) time.Duration {
+	return time.Duration(s) * time.Second
+}
+
 func providerConfigure(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
 	hostRaw := d.Get("host").(string)
 	scheme := d.Get("scheme").(string)
 	token := d.Get("token").(string)
 
+	// a connection URL takes precedence over (and conflicts with) the individual fields
+	if raw, ok := d.Get("url").(string); ok && raw != "" {
+		var err error
+		scheme, hostRaw, token, err = parseConnectionURL(raw)
+		if err != nil {
+			return nil, diag.Diagnostics{{
+				Severity: diag.Error,
+				Summary:  "invalid provider url",
+				Detail:   fmt.Sprintf("%s: %v", redactConnectionURL(raw), err),
+			}}
+		}
+		tflog.Debug(ctx, "using provider connection url", map[string]interface{}{
+			"url": redactConnectionURL(raw),
+		})
+	}
+
+	// profile values only fill in what the arguments (or url) left unset
+	if name := d.Get("profile").(string); name != "" {
+		path := d.Get("config_file").(string)
+		prof, err := loadProfile(path, name)
+		if err != nil {
+			return nil, diag.Diagnostics{{
+				Severity: diag.Error,
+				Summary:  "invalid provider profile",
+				Detail:   err.Error(),
+			}}
+		}
+		hostRaw = firstNonEmpty(hostRaw, prof.Host)
+		scheme = firstNonEmpty(scheme, prof.Scheme)
+		token = firstNonEmpty(token, prof.Token)
+		tflog.Debug(ctx, "using provider profile", map[string]interface{}{
+			"profile":     name,
+			"config_file": path,
+		})
+	}
+
 	if hostRaw == "" || token == "" {
 		return nil, diag.Diagnostics{{
 			Severity: diag.Error,
@@ -91,44 +411,531 @@ func providerConfigure(ctx context.Context, d *schema.ResourceData) (interface{}
 	if err != nil {
 		return nil, diag.FromErr(err)
 	}
-	if inferredScheme != "" {
-		scheme = inferredScheme
+	scheme = resolveScheme(scheme, inferredScheme, host)
+	// schema validation does not cover values from GARAGE_SCHEME, so check the merged result
+	if scheme != "http" && scheme != "https" {
+		return nil, diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "invalid scheme",
+			Detail:   fmt.Sprintf("effective scheme for host %q must be http or https, got %q; set 'scheme' (or GARAGE_SCHEME) or include it in 'host'", host, scheme),
+		}}
 	}
 
 	cfg := garage.NewConfiguration()
 	cfg.Host = host
 	cfg.Scheme = scheme
 	cfg.UserAgent = fmt.Sprintf("terraform-provider-garage/%s", providerVersion)
+	baseTransport, err := buildBaseTransport(d.Get("client_cert_pem").(string), d.Get("client_key_pem").(string), tlsVersions[d.Get("min_tls_version").(string)])
+	if err != nil {
+		return nil, diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "invalid client certificate",
+			Detail:   err.Error(),
+		}}
+	}
 
-	httpClient := &http.Client{Timeout: 10 * time.Second}
+	// one budget for the provider instance, shared by every request it makes
+	var budget *retryBudget
+	if n := d.Get("retry_budget").(int); n > 0 {
+		budget = newRetryBudget(n)
+	}
+
+	// Timeout is enforced per attempt by deadlineTransport rather than
+	// http.Client.Timeout, so a shorter context deadline takes precedence.
+	httpClient := &http.Client{Transport: &retryTransport{
+		base: &deadlineTransport{
+			base:         baseTransport,
+			timeout:      secondsDuration(d.Get("request_timeout").(int)),
+			readTimeout:  secondsDuration(d.Get("read_timeout").(int)),
+			writeTimeout: secondsDuration(d.Get("write_timeout").(int)),
+		},
+		maxAttempts: defaultRetryMaxAttempts,
+		backoff:     defaultRetryBackoff,
+		maxElapsed:  secondsDuration(d.Get("retry_max_elapsed_seconds").(int)),
+		budget:      budget,
+	}}
+	if limit := d.Get("max_response_size").(int); limit > 0 {
+		httpClient.Transport = &responseSizeTransport{base: httpClient.Transport, limit: int64(limit)}
+	}
+	// logged once per call, with credentials redacted; the SDK's own Debug dump is
+	// never enabled as it prints the bearer token and secret keys
+	if d.Get("sdk_debug").(bool) {
+		httpClient.Transport = &debugLoggingTransport{base: httpClient.Transport}
+	}
+	// counted before retries, so each entry is one logical API call
+	var apiCalls *callCountingTransport
+	if d.Get("collect_api_metrics").(bool) {
+		apiCalls = &callCountingTransport{base: httpClient.Transport}
+		httpClient.Transport = apiCalls
+	}
 	cfg.HTTPClient = httpClient
 
-	client := garage.NewAPIClient(cfg)
+	// detection and the configure-time checks always go through this client, so
+	// they never wait on the lazy connect below
+	probeClient := garage.NewAPIClient(cfg)
 
-	// temporary context with token only for detection during configure
-	ctxTok := context.WithValue(ctx, garage.ContextAccessToken, token)
+	gp := &garageProvider{
+		client:     probeClient,
+		token:      token,
+		httpClient: httpClient,
+		namePrefix: d.Get("resource_name_prefix").(string),
+		apiCalls:   apiCalls,
 
-	// detect and enforce minimum supported version
-	ver, src, derr := detectGarageVersion(ctxTok, client, httpClient, scheme, host, token)
-	if derr != nil {
-		return nil, diag.FromErr(derr)
+		defaultIndexDocument: d.Get("default_website_index_document").(string),
 	}
-	if err := enforceV2(ver); err != nil {
-		return nil, diag.FromErr(err)
+	if tz := d.Get("display_timezone").(string); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return nil, diag.Diagnostics{{
+				Severity: diag.Error,
+				Summary:  "invalid display_timezone",
+				Detail:   err.Error(),
+			}}
+		}
+		gp.displayLocation = loc
 	}
 
-	tflog.Debug(ctxTok, "garage version ok", map[string]interface{}{
-		"version": ver.Original(),
-		"source":  src,
-		"host":    host,
-		"scheme":  scheme,
+	preferAPI := d.Get("prefer_api_version").(string)
+	clusterID := d.Get("cluster_id").(string)
+	validateScope := d.Get("validate_token_scope").(bool)
+	allowUnversioned := d.Get("allow_unversioned_nodes").(bool)
+	gp.allowUnversionedNodes = allowUnversioned
+	requireLayout := d.Get("require_layout").(bool)
+	connect := func(ctx context.Context) diag.Diagnostics {
+		// context with token only for detection
+		ctxTok := context.WithValue(ctx, garage.ContextAccessToken, token)
+
+		// detect and enforce minimum supported version
+		ver, src, status, derr := detectPreferredVersion(ctxTok, preferAPI, probeClient, httpClient, scheme, host, token, allowUnversioned)
+		if derr != nil {
+			return diag.FromErr(derr)
+		}
+		if err := enforceV2(ver); err != nil {
+			return diag.FromErr(err)
+		}
+
+		tflog.Debug(ctxTok, "garage version ok", map[string]interface{}{
+			"version": ver.Original(),
+			"source":  src,
+			"host":    host,
+			"scheme":  scheme,
+		})
+
+		var warnings diag.Diagnostics
+		if clusterID != "" {
+			diags := checkClusterID(status, clusterID)
+			if diags.HasError() {
+				return diags
+			}
+			warnings = append(warnings, diags...)
+		}
+
+		if requireLayout {
+			if diags := checkLayout(ctxTok, status, src); len(diags) > 0 {
+				return append(warnings, diags...)
+			}
+		}
+
+		gp.setDetectedVersion(src, ver.String())
+		return warnings
+	}
+
+	clientTransport := httpClient.Transport
+	if validateScope {
+		gp.checkScope = func(ctx context.Context) diag.Diagnostics {
+			return checkTokenScope(context.WithValue(ctx, garage.ContextAccessToken, token), probeClient)
+		}
+		clientTransport = &tokenScopeTransport{base: clientTransport, p: gp}
+	}
+	lazy := d.Get("lazy_connect").(bool)
+	if lazy {
+		// defer detection to the first API request made through the SDK client
+		gp.connect = connect
+		clientTransport = &lazyConnectTransport{base: clientTransport, p: gp}
+	}
+	if d.Get("cache_bucket_info").(bool) {
+		gp.bucketInfo = &bucketInfoCache{ttl: bucketInfoCacheTTL}
+		clientTransport = &bucketInfoInvalidatingTransport{base: clientTransport, cache: gp.bucketInfo}
+	}
+	if clientTransport != httpClient.Transport {
+		clientCfg := *cfg
+		clientCfg.HTTPClient = &http.Client{Transport: clientTransport}
+		gp.client = garage.NewAPIClient(&clientCfg)
+	}
+	if lazy {
+		return gp, nil
+	}
+
+	diags := connect(ctx)
+	if diags.HasError() {
+		return nil, diags
+	}
+	gp.logAPICalls(ctx, "admin API calls during configure")
+	return gp, diags
+}
+
+// logAPICalls writes the per-path API call counts at debug level when
+// collect_api_metrics is enabled.
+func (p *garageProvider) logAPICalls(ctx context.Context, msg string) {
+	if p.apiCalls == nil {
+		return
+	}
+	fields := map[string]interface{}{}
+	for path, n := range p.apiCalls.snapshot() {
+		fields[path] = n
+	}
+	tflog.Debug(ctx, msg, fields)
+}
+
+// bucketInfoCacheTTL is how long a GetBucketInfo response is reused when
+// cache_bucket_info is enabled: long enough to cover the resources of one
+// bucket refreshed or applied together, short enough not to hide outside changes.
+const bucketInfoCacheTTL = 2 * time.Second
+
+// bucketInfoCache dedupes GetBucketInfo calls by bucket ID. Concurrent callers
+// for the same ID wait for a single request and share its result; successful
+// responses are then reused until the TTL expires. Failures are never kept.
+type bucketInfoCache struct {
+	ttl time.Duration
+
+	mu sync.Mutex
+	// gen is bumped by clear, so a request still in flight across a write does
+	// not store a response that may predate it
+	gen     uint64
+	entries map[string]*bucketInfoEntry
+}
+
+type bucketInfoEntry struct {
+	done     chan struct{}
+	info     *garage.GetBucketInfoResponse
+	httpResp *http.Response
+	err      error
+	expires  time.Time
+}
+
+// get returns the cached response for id, waits for a request already in
+// flight, or runs fetch and shares its result.
+func (c *bucketInfoCache) get(id string, fetch func() (*garage.GetBucketInfoResponse, *http.Response, error)) (*garage.GetBucketInfoResponse, *http.Response, error) {
+	c.mu.Lock()
+	if e, ok := c.entries[id]; ok {
+		select {
+		case <-e.done:
+			if time.Now().Before(e.expires) {
+				c.mu.Unlock()
+				return e.info, e.httpResp, nil
+			}
+		default:
+			c.mu.Unlock()
+			<-e.done
+			return e.info, e.httpResp, e.err
+		}
+	}
+	if c.entries == nil {
+		c.entries = map[string]*bucketInfoEntry{}
+	}
+	e := &bucketInfoEntry{done: make(chan struct{})}
+	c.entries[id] = e
+	gen := c.gen
+	c.mu.Unlock()
+
+	e.info, e.httpResp, e.err = fetch()
+
+	c.mu.Lock()
+	e.expires = time.Now().Add(c.ttl)
+	if (e.err != nil || gen != c.gen) && c.entries[id] == e {
+		delete(c.entries, id)
+	}
+	c.mu.Unlock()
+	close(e.done)
+	return e.info, e.httpResp, e.err
+}
+
+// clear drops every cached response.
+func (c *bucketInfoCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	c.entries = nil
+}
+
+// getBucketInfo fetches a bucket by ID, through the shared cache when
+// cache_bucket_info is enabled.
+func (p *garageProvider) getBucketInfo(ctx context.Context, bucketID string) (*garage.GetBucketInfoResponse, *http.Response, error) {
+	if p.bucketInfo == nil {
+		return p.getBucketInfoUncached(ctx, bucketID)
+	}
+	return p.bucketInfo.get(bucketID, func() (*garage.GetBucketInfoResponse, *http.Response, error) {
+		return p.getBucketInfoUncached(ctx, bucketID)
 	})
+}
 
-	return &garageProvider{
-		client:     client,
-		token:      token,
-		httpClient: httpClient,
-	}, nil
+// getBucketInfoUncached always asks the server, for callers polling for a
+// change that a cached response would hide.
+func (p *garageProvider) getBucketInfoUncached(ctx context.Context, bucketID string) (*garage.GetBucketInfoResponse, *http.Response, error) {
+	return p.client.BucketAPI.
+		GetBucketInfo(p.withToken(ctx)).
+		Id(bucketID).
+		Execute()
+}
+
+// ensureConnected runs the deferred connect of a lazy_connect provider until it
+// succeeds. Only success is remembered: a transient failure, or a caller whose
+// context was cancelled, does not fail the requests that follow. It is a no-op
+// for eager providers.
+func (p *garageProvider) ensureConnected(ctx context.Context) error {
+	if p.connect == nil {
+		return nil
+	}
+	p.connectMu.Lock()
+	defer p.connectMu.Unlock()
+	if p.connected {
+		return nil
+	}
+	diags := p.connect(ctx)
+	if diags.HasError() {
+		return diagnosticsError(diags)
+	}
+	// no diagnostics can be returned from a request, so warnings go to the log
+	for _, w := range diags {
+		tflog.Warn(ctx, w.Summary, map[string]interface{}{"detail": w.Detail})
+	}
+	p.connected = true
+	return nil
+}
+
+// diagnosticsError flattens error diagnostics into a single error.
+func diagnosticsError(diags diag.Diagnostics) error {
+	var parts []string
+	for _, d := range diags {
+		if d.Severity != diag.Error {
+			continue
+		}
+		if d.Detail != "" {
+			parts = append(parts, d.Summary+": "+d.Detail)
+		} else {
+			parts = append(parts, d.Summary)
+		}
+	}
+	return errors.New(strings.Join(parts, "; "))
+}
+
+// lazyConnectTransport runs the provider's deferred connect before the first request.
+type lazyConnectTransport struct {
+	base http.RoundTripper
+	p    *garageProvider
+}
+
+func (t *lazyConnectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.p.ensureConnected(req.Context()); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
+
+// ensureTokenScope runs the token scope check until it passes. Only a successful
+// check is remembered, so a transient failure is retried by the next mutating
+// request. It is a no-op unless validate_token_scope is set.
+func (p *garageProvider) ensureTokenScope(ctx context.Context) error {
+	if p.checkScope == nil {
+		return nil
+	}
+	p.scopeMu.Lock()
+	defer p.scopeMu.Unlock()
+	if p.scopeOK {
+		return nil
+	}
+	if diags := p.checkScope(ctx); diags.HasError() {
+		return diagnosticsError(diags)
+	}
+	p.scopeOK = true
+	return nil
+}
+
+// tokenScopeTransport checks the token scope before the first mutating request,
+// so read-only operations keep working with a limited token.
+type tokenScopeTransport struct {
+	base http.RoundTripper
+	p    *garageProvider
+}
+
+func (t *tokenScopeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !retryableMethod(req.Method) {
+		if err := t.p.ensureTokenScope(req.Context()); err != nil {
+			return nil, err
+		}
+	}
+	return t.base.RoundTrip(req)
+}
+
+// checkClusterID compares the configured cluster_id with the one reported by the
+// cluster status read during version detection; status is nil when detection
+// went through the v1 API.
+func checkClusterID(status *garage.GetClusterStatusResponse, want string) diag.Diagnostics {
+	if status == nil {
+		return clusterIDUnsupported(want, "version detection used the v1 API, which returns no cluster status")
+	}
+	return verifyClusterID(status, want)
+}
+
+// checkLayout implements require_layout on the cluster status read during version
+// detection: the cluster must have applied a layout that assigns capacity to at
+// least one node. status is nil when detection went through the v1 API.
+func checkLayout(ctx context.Context, status *garage.GetClusterStatusResponse, apiVersion string) diag.Diagnostics {
+	if status == nil {
+		tflog.Warn(ctx, "require_layout check skipped: cluster status is only read through the v2 API", map[string]interface{}{
+			"api_version": apiVersion,
+		})
+		return nil
+	}
+	return verifyLayout(status)
+}
+
+// verifyLayout reports a cluster without a layout, or whose layout has only gateway nodes.
+func verifyLayout(status *garage.GetClusterStatusResponse) diag.Diagnostics {
+	const setup = "Assign roles with `garage layout assign` and apply them with `garage layout apply` before managing buckets, or unset require_layout."
+	if status.LayoutVersion <= 0 {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "cluster has no layout",
+			Detail:   "The Garage cluster has not applied a layout yet (layout version 0), so it cannot store any data. " + setup,
+		}}
+	}
+	for i := range status.Nodes {
+		if role, ok := status.Nodes[i].GetRoleOk(); ok && role != nil {
+			if c, ok := role.GetCapacityOk(); ok && c != nil && *c > 0 {
+				return nil
+			}
+		}
+	}
+	return diag.Diagnostics{{
+		Severity: diag.Error,
+		Summary:  "cluster layout has no storage node",
+		Detail:   fmt.Sprintf("Layout version %d assigns capacity to no node, so the cluster cannot store any data. %s", status.LayoutVersion, setup),
+	}}
+}
+
+// verifyClusterID looks for a cluster ID on the status through reflection, since the
+// admin API does not expose one in every version.
+func verifyClusterID(status interface{}, want string) diag.Diagnostics {
+	var got string
+	found := false
+	for _, name := range []string{"ClusterId", "ClusterID"} {
+		if got, found = getStringFieldOrGetter(status, name); found {
+			break
+		}
+	}
+	if !found || got == "" {
+		return clusterIDUnsupported(want, "the Garage admin API reports no cluster ID")
+	}
+	if got != want {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "cluster ID mismatch",
+			Detail:   fmt.Sprintf("the provider is configured for cluster %q but the admin API reports cluster %q; check that this provider instance points at the intended cluster", want, got),
+		}}
+	}
+	return nil
+}
+
+// clusterIDUnsupported warns that cluster_id could not be compared, and why.
+func clusterIDUnsupported(want, reason string) diag.Diagnostics {
+	return diag.Diagnostics{{
+		Severity: diag.Warning,
+		Summary:  "cluster_id check unsupported",
+		Detail:   fmt.Sprintf("cluster_id %q was not verified: %s. Remove cluster_id to silence this warning.", want, reason),
+	}}
+}
+
+// checkTokenScope makes a minimal privileged call so a token without admin scope is
+// reported with a clear message instead of the raw error of the operation.
+func checkTokenScope(ctx context.Context, client *garage.APIClient) diag.Diagnostics {
+	_, httpResp, err := client.BucketAPI.ListBuckets(ctx).Execute()
+	if err == nil {
+		return nil
+	}
+	if httpResp != nil && (httpResp.StatusCode == http.StatusForbidden || httpResp.StatusCode == http.StatusUnauthorized) {
+		httpResp.Body.Close()
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "token lacks admin scope",
+			Detail:   fmt.Sprintf("ListBuckets was rejected with %s; the configured token must be an admin token allowed to manage buckets and keys", httpResp.Status),
+		}}
+	}
+	return createDiagnostics(err, httpResp)
+}
+
+// parseConnectionURL splits "scheme://token@host[:port]" into its parts.
+func parseConnectionURL(raw string) (scheme, host, token string, err error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", "", "", fmt.Errorf("cannot parse url")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", "", "", fmt.Errorf("scheme must be http or https, got %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return "", "", "", fmt.Errorf("missing host")
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return "", "", "", fmt.Errorf("missing token in userinfo (expected %s://<token>@%s)", u.Scheme, u.Host)
+	}
+	return u.Scheme, u.Host, u.User.Username(), nil
+}
+
+// providerProfile is one named section of the provider config file.
+type providerProfile struct {
+	Host   string `json:"host"`
+	Scheme string `json:"scheme"`
+	Token  string `json:"token"`
+}
+
+// loadProfile reads the named profile from a JSON config file of the form
+//
+//	{"prod": {"host": "garage.example.com:3903", "scheme": "https", "token": "..."}}
+//
+// Unknown fields are rejected so that typos do not silently drop a setting.
+func loadProfile(path, name string) (providerProfile, error) {
+	if path == "" {
+		return providerProfile{}, fmt.Errorf("profile %q is set but no config file is given; set config_file or GARAGE_CONFIG_FILE", name)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return providerProfile{}, fmt.Errorf("reading config file: %w", err)
+	}
+
+	var profiles map[string]providerProfile
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&profiles); err != nil {
+		return providerProfile{}, fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+
+	prof, ok := profiles[name]
+	if !ok {
+		names := make([]string, 0, len(profiles))
+		for n := range profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return providerProfile{}, fmt.Errorf("profile %q not found in %s; available profiles: %v", name, path, names)
+	}
+	if prof.Scheme != "" && prof.Scheme != "http" && prof.Scheme != "https" {
+		return providerProfile{}, fmt.Errorf("profile %q: scheme must be http or https, got %q", name, prof.Scheme)
+	}
+	return prof, nil
+}
+
+// redactConnectionURL hides the token of a connection URL for logs and diagnostics.
+func redactConnectionURL(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.User == nil {
+		if i := strings.LastIndex(raw, "@"); i >= 0 {
+			return "REDACTED" + raw[i:]
+		}
+		return raw
+	}
+	u.User = url.User("REDACTED")
+	return u.String()
 }
 
 // sanitizeHost accepts either "host:port" or a full URL and returns "host[:port]" and scheme
@@ -162,22 +969,118 @@ func sanitizeHost(raw string) (host string, scheme string, err error) {
 	return raw, "", nil
 }
 
+// resolveScheme picks the effective scheme: a scheme from a full host URL wins, then
+// the configured scheme, then a hint from a well-known port, and finally https
+func resolveScheme(configured, inferred, host string) string {
+	if inferred != "" {
+		return inferred
+	}
+	if configured != "" {
+		return configured
+	}
+	if s := inferSchemeFromPort(host); s != "" {
+		return s
+	}
+	return "https"
+}
+
+// inferSchemeFromPort maps well-known ports to their scheme; other ports (e.g. 3903) are ambiguous
+func inferSchemeFromPort(host string) string {
+	_, port, err := net.SplitHostPort(host)
+	if err != nil {
+		return ""
+	}
+	switch port {
+	case "443":
+		return "https"
+	case "80":
+		return "http"
+	default:
+		return ""
+	}
+}
+
+// detectPreferredVersion runs version detection according to prefer_api_version:
+// "v1" probes only the v1 endpoint, "v2" only the v2 one, anything else falls
+// back to detectGarageVersion (v2 first, then v1). The cluster status read by
+// v2 detection is returned for the configure-time checks; it is nil for v1.
+func detectPreferredVersion(
+	ctx context.Context,
+	prefer string,
+	client *garage.APIClient,
+	httpClient *http.Client,
+	scheme, host, token string,
+	allowUnversioned bool,
+) (*semver.Version, string, *garage.GetClusterStatusResponse, error) {
+	switch prefer {
+	case "v1":
+		v, err := detectV1Version(ctx, httpClient, scheme, host, token)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("failed to determine garage version via v1: %w", err)
+		}
+		return v, "v1", nil, nil
+	case "v2":
+		status, resp, err := client.ClusterAPI.GetClusterStatus(ctx).Execute()
+		if err != nil || status == nil || len(status.Nodes) == 0 {
+			if err == nil {
+				return nil, "", nil, fmt.Errorf("v2 cluster status reported no nodes")
+			}
+			return nil, "", nil, enrichV2HTTP(err, resp)
+		}
+		warnOnClockSkew(ctx, resp, time.Now())
+		v, serr := minClusterSemverFromV2(status, allowUnversioned)
+		if serr != nil {
+			return nil, "", nil, fmt.Errorf("v2 payload invalid: %w", serr)
+		}
+		return v, "v2", status, nil
+	default:
+		return detectGarageVersion(ctx, client, httpClient, scheme, host, token, allowUnversioned)
+	}
+}
+
+// v1VersionError reports a v1 status endpoint that answered with an unparsable version.
+type v1VersionError struct{ err error }
+
+func (e *v1VersionError) Error() string {
+	return fmt.Sprintf("v1 status returned bad version: %v", e.err)
+}
+
+func (e *v1VersionError) Unwrap() error {
+	return e.err
+}
+
+// detectV1Version probes the v1 status endpoint and parses the reported version.
+func detectV1Version(ctx context.Context, httpClient *http.Client, scheme, host, token string) (*semver.Version, error) {
+	v1Str, err := probeV1Version(ctx, httpClient, scheme, host, token)
+	if err != nil {
+		return nil, err
+	}
+	norm, nerr := normalizeVersion(v1Str)
+	if nerr != nil {
+		return nil, &v1VersionError{err: nerr}
+	}
+	v, _ := semver.NewVersion(norm)
+	return v, nil
+}
+
 // detectGarageVersion tries v2 (SDK) first, then v1 (/v1/status via raw HTTP)
-// returns detected version and source ("v2" | "v1")
+// returns detected version, source ("v2" | "v1") and the v2 cluster status
 func detectGarageVersion(
 	ctx context.Context,
 	client *garage.APIClient,
 	httpClient *http.Client,
 	scheme, host, token string,
-) (*semver.Version, string, error) {
+	allowUnversioned bool,
+) (*semver.Version, string, *garage.GetClusterStatusResponse, error) {
 	// v2 via SDK
 	status, resp, err := client.ClusterAPI.GetClusterStatus(ctx).Execute()
 	if err == nil && status != nil && len(status.Nodes) > 0 {
-		v, serr := minClusterSemverFromV2(status)
+		warnOnClockSkew(ctx, resp, time.Now())
+		v, serr := minClusterSemverFromV2(status, allowUnversioned)
 		if serr == nil {
-			return v, "v2", nil
+			return v, "v2", status, nil
 		}
-		return nil, "", fmt.Errorf("v2 payload invalid: %w", serr)
+		return nil, "", nil, fmt.Errorf("v2 payload invalid: %w", serr)
 	}
 	v2Err := enrichV2HTTP(err, resp)
 	fallbackAllowed := false
@@ -186,31 +1089,30 @@ func detectGarageVersion(
 		switch resp.StatusCode {
 		case http.StatusUnauthorized, http.StatusForbidden:
 			// Auth failures indicate bad credentials; do not mask them with a v1 fallback.
-			return nil, "", v2Err
+			return nil, "", nil, v2Err
 		default:
 			if ok, reason := shouldFallbackToV1(resp, err); ok {
 				fallbackAllowed = true
 				fallbackReason = reason
 			} else {
 				// Any other HTTP response means v2 exists but failed; surface the enriched error.
-				return nil, "", v2Err
+				return nil, "", nil, v2Err
 			}
 		}
 	}
 
 	// v1 via raw HTTP
-	v1Str, v1Err := probeV1Version(ctx, httpClient, scheme, host, token)
+	v, v1Err := detectV1Version(ctx, httpClient, scheme, host, token)
 	if v1Err == nil {
-		norm, nerr := normalizeVersion(v1Str)
-		if nerr != nil {
-			return nil, "", fmt.Errorf("v1 status returned bad version: %w", nerr)
-		}
-		v, _ := semver.NewVersion(norm)
-		return v, "v1", nil
+		return v, "v1", nil, nil
+	}
+	var badVersion *v1VersionError
+	if errors.As(v1Err, &badVersion) {
+		return nil, "", nil, v1Err
 	}
 
 	if fallbackAllowed {
-		return nil, "", fmt.Errorf(
+		return nil, "", nil, fmt.Errorf(
 			"this provider requires Garage 2.0.0 or newer; %s (%v)",
 			fallbackReason,
 			v2Err,
@@ -218,7 +1120,7 @@ func detectGarageVersion(
 	}
 
 	// both failed
-	return nil, "", fmt.Errorf("failed to determine garage version; v2: %v; v1: %v", v2Err, v1Err)
+	return nil, "", nil, fmt.Errorf("failed to determine garage version; v2: %v; v1: %v", v2Err, v1Err)
 }
 
 // enforceV2 ensures detected version >= 2.0.0
@@ -230,33 +1132,97 @@ func enforceV2(v *semver.Version) error {
 	return nil
 }
 
-// minClusterSemverFromV2 parses the cluster status and returns the minimum node version as semver
-func minClusterSemverFromV2(status *garage.GetClusterStatusResponse) (*semver.Version, error) {
+// minClusterSemverFromV2 parses the cluster status and returns the minimum node version as semver.
+// With allowUnversioned, nodes reporting no version are skipped but at least one node must report one.
+func minClusterSemverFromV2(status *garage.GetClusterStatusResponse, allowUnversioned bool) (*semver.Version, error) {
+	minSeen, _, err := clusterSemverRangeFromV2(status, allowUnversioned)
+	if err == nil && minSeen == nil {
+		return nil, fmt.Errorf("no node reports a garageVersion")
+	}
+	return minSeen, err
+}
+
+// clusterSemverRangeFromV2 returns the lowest and highest node versions reported in the cluster status.
+// Every node must report a parsable v2+ version, unless allowUnversioned skips those reporting none.
+func clusterSemverRangeFromV2(status *garage.GetClusterStatusResponse, allowUnversioned bool) (*semver.Version, *semver.Version, error) {
 	c, _ := semver.NewConstraint(">= 2.0.0")
-	var minSeen *semver.Version
+	var minSeen, maxSeen *semver.Version
 
 	for _, n := range status.Nodes {
 		if !n.GarageVersion.IsSet() || n.GarageVersion.Get() == nil {
-			return nil, fmt.Errorf("node %s reports no garageVersion", n.Id)
+			if allowUnversioned {
+				continue
+			}
+			return nil, nil, fmt.Errorf("node %s reports no garageVersion", n.Id)
 		}
 		norm, err := normalizeVersion(*n.GarageVersion.Get())
 		if err != nil {
-			return nil, fmt.Errorf("node %s has invalid version: %w", n.Id, err)
+			return nil, nil, fmt.Errorf("node %s has invalid version: %w", n.Id, err)
 		}
 		v, _ := semver.NewVersion(norm)
 		if !c.Check(v) {
-			return nil, fmt.Errorf("node %s is on %s this provider supports only v2+ please upgrade", n.Id, v.Original())
+			return nil, nil, fmt.Errorf("node %s is on %s this provider supports only v2+ please upgrade", n.Id, v.Original())
 		}
 		if minSeen == nil || v.LessThan(minSeen) {
 			minSeen = v
 		}
+		if maxSeen == nil || v.GreaterThan(maxSeen) {
+			maxSeen = v
+		}
 	}
-	return minSeen, nil
+	return minSeen, maxSeen, nil
 }
 
-// probeV1Version calls /v1/status and extracts the GarageVersion
+// clockSkewWarnThreshold is the difference between server and local clocks above
+// which configure warns; key expirations are evaluated on the server clock.
+const clockSkewWarnThreshold = time.Minute
+
+// warnOnClockSkew compares the response Date header with now and logs a warning
+// when they differ by more than clockSkewWarnThreshold. Missing or unparsable
+// headers are ignored.
+func warnOnClockSkew(ctx context.Context, resp *http.Response, now time.Time) {
+	if resp == nil {
+		return
+	}
+	serverTime, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return
+	}
+	skew := serverTime.Sub(now)
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew <= clockSkewWarnThreshold {
+		return
+	}
+	tflog.Warn(ctx, "clock skew detected between Terraform and the Garage server; key expirations may not behave as expected", map[string]interface{}{
+		"server_time": serverTime.UTC().Format(time.RFC3339),
+		"local_time":  now.UTC().Format(time.RFC3339),
+		"skew":        skew.Round(time.Second).String(),
+	})
+}
+
+// joinAPIURL builds "scheme://host[/prefix]/path" with exactly one slash between
+// parts, whatever leading or trailing slashes host, prefix and path carry.
+func joinAPIURL(scheme, host, prefix, path string) string {
+	var b strings.Builder
+	b.WriteString(scheme)
+	b.WriteString("://")
+	b.WriteString(strings.TrimRight(host, "/"))
+	for _, part := range []string{prefix, path} {
+		if part = strings.Trim(part, "/"); part != "" {
+			b.WriteString("/")
+			b.WriteString(part)
+		}
+	}
+	return b.String()
+}
+
+// probeV1Version makes a single /v1/status request. A node that has just
+// started may answer 503 for a moment; the provider's retryTransport already
+// retries such GETs within retry_budget and retry_max_elapsed_seconds.
 func probeV1Version(ctx context.Context, httpClient *http.Client, scheme, host, token string) (string, error) {
-	urlStr := fmt.Sprintf("%s://%s/v1/status", scheme, host)
+	urlStr := joinAPIURL(scheme, host, "", "/v1/status")
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, urlStr, nil)
 	if err != nil {
@@ -273,6 +1239,7 @@ func probeV1Version(ctx context.Context, httpClient *http.Client, scheme, host,
 	if res.StatusCode < 200 || res.StatusCode >= 300 {
 		return "", fmt.Errorf("GET %s -> %s", urlStr, res.Status)
 	}
+	warnOnClockSkew(ctx, res, time.Now())
 
 	var payload struct {
 		GarageVersion string `json:"garageVersion"`
@@ -313,6 +1280,10 @@ func enrichV2HTTP(err error, resp *http.Response) error {
 	respStatus := strings.TrimSpace(resp.Status)
 
 	errBody := apiErrorBody(err)
+	if errBody == "" {
+		// not an SDK error carrying the body: fall back to the raw response
+		errBody = responseBodySnippet(resp)
+	}
 	cleanedErr := strings.TrimSpace(err.Error())
 	if cleanedErr == respStatus {
 		cleanedErr = ""
@@ -376,6 +1347,33 @@ func apiErrorBody(err error) string {
 	return ""
 }
 
+// maxErrorBodySnippet bounds how much of a response body is quoted in errors.
+const maxErrorBodySnippet = 600
+
+// responseBodySnippet reads a truncated copy of resp.Body and puts the bytes back
+// so later readers still see them. A body that can no longer be read (already
+// consumed and closed by the SDK) is reported as such.
+func responseBodySnippet(resp *http.Response) string {
+	if resp == nil || resp.Body == nil || resp.Body == http.NoBody {
+		return ""
+	}
+
+	buf, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySnippet+1))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(buf), resp.Body), resp.Body}
+	if err != nil && len(buf) == 0 {
+		return "(response body already consumed)"
+	}
+
+	body := strings.TrimSpace(string(buf))
+	if len(buf) > maxErrorBodySnippet {
+		body = strings.TrimSpace(string(buf[:maxErrorBodySnippet])) + "…"
+	}
+	return body
+}
+
 type garageAPIErrorDetails struct {
 	Code    string `json:"code"`
 	Message string `json:"message"`
@@ -1,18 +1,26 @@
 package garage
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	garageapi "git.deuxfleurs.fr/garage-sdk/garage-admin-sdk-golang"
 	"github.com/Masterminds/semver/v3"
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
 func newAPIClientForServer(server *httptest.Server) *garageapi.APIClient {
@@ -96,6 +104,697 @@ func TestProviderConfigureSuccess(t *testing.T) {
 	}
 }
 
+func TestParseConnectionURL(t *testing.T) {
+	scheme, host, token, err := parseConnectionURL("https://s3cr3t@garage.example.com:3903")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if scheme != "https" || host != "garage.example.com:3903" || token != "s3cr3t" {
+		t.Fatalf("unexpected parse result scheme=%q host=%q token=%q", scheme, host, token)
+	}
+
+	for _, raw := range []string{
+		"https://garage.example.com:3903",
+		"ftp://tok@garage.example.com",
+		"https://tok@",
+	} {
+		if _, _, _, err := parseConnectionURL(raw); err == nil {
+			t.Fatalf("expected error for %q", raw)
+		}
+	}
+}
+
+func TestRedactConnectionURL(t *testing.T) {
+	got := redactConnectionURL("https://s3cr3t@garage.example.com:3903")
+	if strings.Contains(got, "s3cr3t") {
+		t.Fatalf("expected token to be redacted, got %q", got)
+	}
+	if !strings.Contains(got, "garage.example.com:3903") {
+		t.Fatalf("expected host to be kept, got %q", got)
+	}
+}
+
+func TestProviderValidateTokenScopeOnlyBlocksMutations(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		switch r.URL.Path {
+		case "/v2/GetClusterStatus":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"layoutVersion":1,"nodes":[{"draining":false,"id":"node-1","isUp":true,"garageVersion":"2.2.0"}]}`)
+		case "/v2/ListBuckets":
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"code":"Forbidden","message":"Forbidden"}`)
+		default:
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	p := Provider()
+	data := schema.TestResourceDataRaw(t, p.Schema, map[string]interface{}{
+		"host":                 server.URL,
+		"token":                "read-only",
+		"validate_token_scope": true,
+	})
+
+	cfg, diags := providerConfigure(context.Background(), data)
+	if len(diags) != 0 {
+		t.Fatalf("expected configure to succeed with a limited token, got %#v", diags)
+	}
+	gp := cfg.(*garageProvider)
+
+	// read-only data source works without admin scope
+	d := schema.TestResourceDataRaw(t, dataSourceVersionInfo().Schema, map[string]interface{}{})
+	if diags := dataSourceVersionInfoRead(context.Background(), d, gp); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics for read-only data source %#v", diags)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("expected detection and the data source read only, got %v", paths)
+	}
+
+	// the first mutating call runs the scope check and is never sent
+	kd := schema.TestResourceDataRaw(t, resourceKey().Schema, map[string]interface{}{"name": "app"})
+	diags = resourceKeyCreate(context.Background(), kd, gp)
+	if !diags.HasError() || !strings.Contains(diags[0].Summary+diags[0].Detail, "token lacks admin scope") {
+		t.Fatalf("expected scope error on mutation, got %#v", diags)
+	}
+	if paths[len(paths)-1] != "/v2/ListBuckets" {
+		t.Fatalf("expected the scope check instead of CreateKey, got %v", paths)
+	}
+}
+
+func TestProviderValidateTokenScopeRetriesAfterFailure(t *testing.T) {
+	checks := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/GetClusterStatus":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"layoutVersion":1,"nodes":[{"draining":false,"id":"node-1","isUp":true,"garageVersion":"2.2.0"}]}`)
+		case "/v2/ListBuckets":
+			checks++
+			// a transient failure on the first scope check
+			if checks == 1 {
+				w.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprint(w, `{"code":"InternalError","message":"try again"}`)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `[]`)
+		default:
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	data := schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{
+		"host":                 server.URL,
+		"token":                "token",
+		"validate_token_scope": true,
+	})
+	cfg, diags := providerConfigure(context.Background(), data)
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	gp := cfg.(*garageProvider)
+
+	if err := gp.ensureTokenScope(context.Background()); err == nil {
+		t.Fatalf("expected the first scope check to fail")
+	}
+	for i := 0; i < 2; i++ {
+		if err := gp.ensureTokenScope(context.Background()); err != nil {
+			t.Fatalf("expected the scope check to be retried, got %v", err)
+		}
+	}
+	if checks != 2 {
+		t.Fatalf("expected one failed and one successful check, got %d", checks)
+	}
+}
+
+func TestVerifyClusterID(t *testing.T) {
+	type statusWithID struct {
+		ClusterId string
+	}
+
+	diags := verifyClusterID(&statusWithID{ClusterId: "cluster-b"}, "cluster-a")
+	if !diags.HasError() || diags[0].Summary != "cluster ID mismatch" {
+		t.Fatalf("expected cluster ID mismatch, got %#v", diags)
+	}
+	if diags := verifyClusterID(&statusWithID{ClusterId: "cluster-a"}, "cluster-a"); len(diags) != 0 {
+		t.Fatalf("expected matching cluster ID to pass, got %#v", diags)
+	}
+	// the status type carries no cluster ID: nothing to compare against
+	for _, diags := range []diag.Diagnostics{
+		verifyClusterID(&garageapi.GetClusterStatusResponse{}, "cluster-a"),
+		checkClusterID(nil, "cluster-a"),
+	} {
+		if len(diags) != 1 || diags[0].Severity != diag.Warning || diags[0].Summary != "cluster_id check unsupported" {
+			t.Fatalf("expected an unsupported check warning, got %#v", diags)
+		}
+	}
+}
+
+func TestProviderConfigureAllowUnversionedNodes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/GetClusterStatus" {
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"layoutVersion":0,"nodes":[{"draining":false,"id":"node-1","isUp":true,"garageVersion":"2.1.0"},{"draining":false,"id":"node-2","isUp":true}]}`)
+	}))
+	defer server.Close()
+
+	p := Provider()
+	strict := schema.TestResourceDataRaw(t, p.Schema, map[string]interface{}{
+		"host":  server.URL,
+		"token": "token",
+	})
+	if _, diags := providerConfigure(context.Background(), strict); !diags.HasError() {
+		t.Fatalf("expected unversioned node to fail configuration without the flag")
+	}
+
+	data := schema.TestResourceDataRaw(t, p.Schema, map[string]interface{}{
+		"host":                    server.URL,
+		"token":                   "token",
+		"allow_unversioned_nodes": true,
+	})
+	cfg, diags := providerConfigure(context.Background(), data)
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	if v := cfg.(*garageProvider).garageVersion; v != "2.1.0" {
+		t.Fatalf("expected version of the versioned node, got %q", v)
+	}
+}
+
+func TestProviderConfigureProfile(t *testing.T) {
+	var gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"layoutVersion":1,"nodes":[{"draining":false,"id":"node-1","isUp":true,"garageVersion":"2.2.0"}]}`)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "garage.json")
+	content := fmt.Sprintf(`{"dev": {"host": %q, "token": "dev-token"}, "prod": {"host": "prod.example.com:3903", "scheme": "https", "token": "prod-token"}}`, server.URL)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write config file: %v", err)
+	}
+
+	p := Provider()
+	data := schema.TestResourceDataRaw(t, p.Schema, map[string]interface{}{
+		"profile":     "dev",
+		"config_file": path,
+	})
+	if _, diags := providerConfigure(context.Background(), data); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	if gotAuth != "Bearer dev-token" {
+		t.Fatalf("expected the profile token, got %q", gotAuth)
+	}
+
+	// explicit arguments win over the profile
+	data = schema.TestResourceDataRaw(t, p.Schema, map[string]interface{}{
+		"profile":     "dev",
+		"config_file": path,
+		"token":       "explicit-token",
+	})
+	if _, diags := providerConfigure(context.Background(), data); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	if gotAuth != "Bearer explicit-token" {
+		t.Fatalf("expected the explicit token to override the profile, got %q", gotAuth)
+	}
+
+	prof, err := loadProfile(path, "prod")
+	if err != nil || prof.Host != "prod.example.com:3903" || prof.Scheme != "https" || prof.Token != "prod-token" {
+		t.Fatalf("unexpected profile %+v (err %v)", prof, err)
+	}
+	if _, err := loadProfile(path, "staging"); err == nil || !strings.Contains(err.Error(), "[dev prod]") {
+		t.Fatalf("expected missing profile error listing the available ones, got %v", err)
+	}
+	if _, err := loadProfile("", "dev"); err == nil {
+		t.Fatalf("expected error without a config file")
+	}
+
+	invalid := filepath.Join(t.TempDir(), "invalid.json")
+	if err := os.WriteFile(invalid, []byte(`{"dev": {"hots": "typo.example.com"}}`), 0o600); err != nil {
+		t.Fatalf("write config file: %v", err)
+	}
+	if _, err := loadProfile(invalid, "dev"); err == nil {
+		t.Fatalf("expected unknown fields to be rejected")
+	}
+}
+
+func TestProviderConfigureClusterIDWarnsWithoutReportedID(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/GetClusterStatus" {
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"layoutVersion":1,"nodes":[{"draining":false,"id":"node-1","isUp":true,"garageVersion":"2.2.0"}]}`)
+	}))
+	defer server.Close()
+
+	p := Provider()
+	data := schema.TestResourceDataRaw(t, p.Schema, map[string]interface{}{
+		"host":       server.URL,
+		"token":      "token",
+		"cluster_id": "cluster-a",
+	})
+	_, diags := providerConfigure(context.Background(), data)
+	if len(diags) != 1 || diags[0].Severity != diag.Warning || diags[0].Summary != "cluster_id check unsupported" {
+		t.Fatalf("expected an unsupported check warning, got %#v", diags)
+	}
+	if calls != 1 {
+		t.Fatalf("expected the check to reuse the detection status, got %d calls", calls)
+	}
+}
+
+func TestProviderConfigureRequireLayout(t *testing.T) {
+	for name, tc := range map[string]struct {
+		status  string
+		require bool
+		want    string
+	}{
+		"zero layout version": {
+			status:  `{"layoutVersion":0,"nodes":[{"draining":false,"id":"node-1","isUp":true,"garageVersion":"2.2.0"}]}`,
+			require: true,
+			want:    "cluster has no layout",
+		},
+		"gateway nodes only": {
+			status:  `{"layoutVersion":2,"nodes":[{"draining":false,"id":"node-1","isUp":true,"garageVersion":"2.2.0","role":{"zone":"a","tags":[],"capacity":null}}]}`,
+			require: true,
+			want:    "cluster layout has no storage node",
+		},
+		"storage node": {
+			status:  `{"layoutVersion":2,"nodes":[{"draining":false,"id":"node-1","isUp":true,"garageVersion":"2.2.0","role":{"zone":"a","tags":[],"capacity":1000}}]}`,
+			require: true,
+		},
+		"flag unset": {
+			status: `{"layoutVersion":0,"nodes":[{"draining":false,"id":"node-1","isUp":true,"garageVersion":"2.2.0"}]}`,
+		},
+	} {
+		calls := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/v2/GetClusterStatus" {
+				t.Fatalf("unexpected path %s", r.URL.Path)
+			}
+			calls++
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, tc.status)
+		}))
+
+		data := schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{
+			"host":           server.URL,
+			"token":          "token",
+			"require_layout": tc.require,
+		})
+		_, diags := providerConfigure(context.Background(), data)
+		server.Close()
+
+		// the layout is checked on the status read by version detection
+		if calls != 1 {
+			t.Fatalf("%s: expected a single cluster status request, got %d", name, calls)
+		}
+
+		if tc.want == "" {
+			if len(diags) != 0 {
+				t.Fatalf("%s: unexpected diagnostics %#v", name, diags)
+			}
+			continue
+		}
+		if len(diags) != 1 || diags[0].Summary != tc.want {
+			t.Fatalf("%s: expected %q, got %#v", name, tc.want, diags)
+		}
+		if !strings.Contains(diags[0].Detail, "garage layout assign") {
+			t.Fatalf("%s: expected setup guidance, got %q", name, diags[0].Detail)
+		}
+	}
+}
+
+func TestProviderConfigureSDKDebug(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		p := Provider()
+		data := schema.TestResourceDataRaw(t, p.Schema, map[string]interface{}{
+			"host":         "127.0.0.1:3903",
+			"token":        "token",
+			"lazy_connect": true,
+			"sdk_debug":    enabled,
+		})
+
+		cfg, diags := providerConfigure(context.Background(), data)
+		if len(diags) != 0 {
+			t.Fatalf("unexpected diagnostics %#v", diags)
+		}
+		// the SDK dump would print the token, so it stays off either way
+		if cfg.(*garageProvider).client.GetConfig().Debug {
+			t.Fatalf("sdk_debug=%v: expected the SDK's Debug flag to stay off", enabled)
+		}
+		if _, ok := cfg.(*garageProvider).httpClient.Transport.(*debugLoggingTransport); ok != enabled {
+			t.Fatalf("sdk_debug=%v: expected debug logging transport %v, got %T", enabled, enabled, cfg.(*garageProvider).httpClient.Transport)
+		}
+	}
+}
+
+func TestProviderConfigureLazyConnect(t *testing.T) {
+	probes := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/GetClusterStatus":
+			probes++
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"layoutVersion":1,"nodes":[{"draining":false,"id":"node-1","isUp":true,"garageVersion":"2.2.0"}]}`)
+		case "/v2/ListBuckets":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `[]`)
+		default:
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	p := Provider()
+	data := schema.TestResourceDataRaw(t, p.Schema, map[string]interface{}{
+		"host":         server.URL,
+		"token":        "token",
+		"lazy_connect": true,
+	})
+
+	cfg, diags := providerConfigure(context.Background(), data)
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	if probes != 0 {
+		t.Fatalf("expected no probe during configure, got %d", probes)
+	}
+
+	gp := cfg.(*garageProvider)
+	ctx := context.WithValue(context.Background(), garageapi.ContextAccessToken, gp.token)
+	for i := 0; i < 2; i++ {
+		if _, _, err := gp.client.BucketAPI.ListBuckets(ctx).Execute(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if probes != 1 {
+		t.Fatalf("expected a single probe on first use, got %d", probes)
+	}
+	if api, ver := gp.detectedVersion(); api != "v2" || ver != "2.2.0" {
+		t.Fatalf("expected detected version after first use, got %q %q", api, ver)
+	}
+}
+
+func TestProviderLazyConnectRetriesAfterFailure(t *testing.T) {
+	probes := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/GetClusterStatus":
+			probes++
+			// a cluster that is not reachable yet on the first use
+			if probes == 1 {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"code":"BadRequest","message":"not ready"}`)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"layoutVersion":1,"nodes":[{"draining":false,"id":"node-1","isUp":true,"garageVersion":"2.2.0"}]}`)
+		case "/v2/ListBuckets":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `[]`)
+		default:
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	data := schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{
+		"host":         server.URL,
+		"token":        "token",
+		"lazy_connect": true,
+	})
+	cfg, diags := providerConfigure(context.Background(), data)
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	gp := cfg.(*garageProvider)
+
+	// a cancelled first caller fails without poisoning later requests
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := gp.ensureConnected(cancelled); err == nil {
+		t.Fatalf("expected the cancelled connect to fail")
+	}
+
+	ctx := context.WithValue(context.Background(), garageapi.ContextAccessToken, gp.token)
+	if _, _, err := gp.client.BucketAPI.ListBuckets(ctx).Execute(); err == nil {
+		t.Fatalf("expected the first use to fail while the cluster is not ready")
+	}
+	for i := 0; i < 2; i++ {
+		if _, _, err := gp.client.BucketAPI.ListBuckets(ctx).Execute(); err != nil {
+			t.Fatalf("expected connect to be retried, got %v", err)
+		}
+	}
+	if probes != 2 {
+		t.Fatalf("expected one failed and one successful probe, got %d", probes)
+	}
+}
+
+func TestProviderConfigureWarnsOnClockSkew(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", time.Now().Add(-2*time.Hour).UTC().Format(http.TimeFormat))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"layoutVersion":1,"nodes":[{"draining":false,"id":"node-1","isUp":true,"garageVersion":"2.2.0"}]}`)
+	}))
+	defer server.Close()
+
+	var logs bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &logs)
+
+	p := Provider()
+	data := schema.TestResourceDataRaw(t, p.Schema, map[string]interface{}{
+		"host":  server.URL,
+		"token": "token",
+	})
+	if _, diags := providerConfigure(ctx, data); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+
+	entries, err := tflogtest.MultilineJSONDecode(&logs)
+	if err != nil {
+		t.Fatalf("decoding logs: %v", err)
+	}
+	for _, e := range entries {
+		if e["@level"] == "warn" && strings.Contains(fmt.Sprint(e["@message"]), "clock skew") {
+			return
+		}
+	}
+	t.Fatalf("expected a clock skew warning, got %v", entries)
+}
+
+func TestWarnOnClockSkewWithinThreshold(t *testing.T) {
+	var logs bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &logs)
+
+	now := time.Now()
+	for _, date := range []string{now.Add(30 * time.Second).UTC().Format(http.TimeFormat), "", "garbage"} {
+		warnOnClockSkew(ctx, &http.Response{Header: http.Header{"Date": []string{date}}}, now)
+	}
+	warnOnClockSkew(ctx, nil, now)
+	if logs.Len() != 0 {
+		t.Fatalf("expected no warning, got %s", logs.String())
+	}
+}
+
+func TestProviderConfigureWithURL(t *testing.T) {
+	token := "token-123"
+	var gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"layoutVersion":1,"nodes":[{"draining":false,"id":"node-1","isUp":true,"garageVersion":"2.2.0"}]}`)
+	}))
+	defer server.Close()
+
+	hostPort := strings.TrimPrefix(server.URL, "http://")
+	p := Provider()
+	data := schema.TestResourceDataRaw(t, p.Schema, map[string]interface{}{
+		"url": "http://" + token + "@" + hostPort,
+	})
+
+	cfg, diags := providerConfigure(context.Background(), data)
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	provider := cfg.(*garageProvider)
+	if provider.token != token || gotAuth != "Bearer "+token {
+		t.Fatalf("expected token from url, got %q (auth %q)", provider.token, gotAuth)
+	}
+	if provider.client.GetConfig().Host != hostPort || provider.client.GetConfig().Scheme != "http" {
+		t.Fatalf("unexpected client config %q %q", provider.client.GetConfig().Scheme, provider.client.GetConfig().Host)
+	}
+}
+
+func TestProviderConfigureClientCert(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"layoutVersion":1,"nodes":[{"draining":false,"id":"node-1","isUp":true,"garageVersion":"2.2.0"}]}`)
+	}))
+	defer server.Close()
+
+	certPEM, keyPEM := testClientCertPEM(t)
+	p := Provider()
+	data := schema.TestResourceDataRaw(t, p.Schema, map[string]interface{}{
+		"host":            server.URL,
+		"token":           "token",
+		"client_cert_pem": certPEM,
+		"client_key_pem":  keyPEM,
+	})
+
+	cfg, diags := providerConfigure(context.Background(), data)
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	rt, ok := providerRetryTransport(cfg.(*garageProvider)).(*retryTransport)
+	if !ok {
+		t.Fatalf("expected retry transport, got %T", providerRetryTransport(cfg.(*garageProvider)))
+	}
+	dt, ok := rt.base.(*deadlineTransport)
+	if !ok {
+		t.Fatalf("expected deadline transport, got %T", rt.base)
+	}
+	tr, ok := dt.base.(*http.Transport)
+	if !ok || tr.TLSClientConfig == nil || len(tr.TLSClientConfig.Certificates) != 1 {
+		t.Fatalf("expected client certificate installed on base transport, got %#v", dt.base)
+	}
+}
+
+func TestProviderConfigureMinTLSVersion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"layoutVersion":1,"nodes":[{"draining":false,"id":"node-1","isUp":true,"garageVersion":"2.2.0"}]}`)
+	}))
+	defer server.Close()
+
+	p := Provider()
+	data := schema.TestResourceDataRaw(t, p.Schema, map[string]interface{}{
+		"host":            server.URL,
+		"token":           "token",
+		"min_tls_version": "1.3",
+	})
+
+	cfg, diags := providerConfigure(context.Background(), data)
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	rt := providerRetryTransport(cfg.(*garageProvider)).(*retryTransport)
+	tr := rt.base.(*deadlineTransport).base.(*http.Transport)
+	if tr.TLSClientConfig == nil || tr.TLSClientConfig.MinVersion != tls.VersionTLS13 {
+		t.Fatalf("expected MinVersion TLS 1.3 on base transport, got %#v", tr.TLSClientConfig)
+	}
+
+	invalid := p.Validate(terraform.NewResourceConfigRaw(map[string]interface{}{
+		"host":            "garage.example.com:3903",
+		"token":           "token",
+		"min_tls_version": "1.1",
+	}))
+	if !invalid.HasError() {
+		t.Fatalf("expected min_tls_version 1.1 to be rejected")
+	}
+}
+
+func TestProviderConfigureDisplayTimezone(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"layoutVersion":1,"nodes":[{"draining":false,"id":"node-1","isUp":true,"garageVersion":"2.2.0"}]}`)
+	}))
+	defer server.Close()
+
+	p := Provider()
+	data := schema.TestResourceDataRaw(t, p.Schema, map[string]interface{}{
+		"host":             server.URL,
+		"token":            "token",
+		"display_timezone": "Europe/Zurich",
+	})
+	cfg, diags := providerConfigure(context.Background(), data)
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	provider := cfg.(*garageProvider)
+	created := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+	if got := provider.formatTimestamp(created); got != "2025-07-01T12:00:00+02:00" {
+		t.Fatalf("expected timestamp in Europe/Zurich, got %q", got)
+	}
+	if got := (&garageProvider{}).formatTimestamp(created); got != "2025-07-01T10:00:00Z" {
+		t.Fatalf("expected UTC by default, got %q", got)
+	}
+
+	invalid := p.Validate(terraform.NewResourceConfigRaw(map[string]interface{}{
+		"host":             "garage.example.com:3903",
+		"token":            "token",
+		"display_timezone": "Mars/Olympus_Mons",
+	}))
+	if !invalid.HasError() {
+		t.Fatalf("expected unknown display_timezone to be rejected")
+	}
+}
+
+func TestProviderConfigureReadWriteTimeouts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"layoutVersion":1,"nodes":[{"draining":false,"id":"node-1","isUp":true,"garageVersion":"2.2.0"}]}`)
+	}))
+	defer server.Close()
+
+	p := Provider()
+	data := schema.TestResourceDataRaw(t, p.Schema, map[string]interface{}{
+		"host":          server.URL,
+		"token":         "token",
+		"read_timeout":  5,
+		"write_timeout": 60,
+	})
+
+	cfg, diags := providerConfigure(context.Background(), data)
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	dt := providerRetryTransport(cfg.(*garageProvider)).(*retryTransport).base.(*deadlineTransport)
+	if dt.timeoutFor(http.MethodGet) != 5*time.Second || dt.timeoutFor(http.MethodPost) != time.Minute {
+		t.Fatalf("expected read/write timeouts on the deadline transport, got %#v", dt)
+	}
+	if dt.timeout != defaultRequestTimeout {
+		t.Fatalf("expected request_timeout to default to %s, got %s", defaultRequestTimeout, dt.timeout)
+	}
+}
+
+func TestProviderConfigureInvalidClientCert(t *testing.T) {
+	p := Provider()
+	data := schema.TestResourceDataRaw(t, p.Schema, map[string]interface{}{
+		"host":            "garage.example.com:3903",
+		"token":           "token",
+		"client_cert_pem": "bogus",
+		"client_key_pem":  "bogus",
+	})
+
+	cfg, diags := providerConfigure(context.Background(), data)
+	if cfg != nil || !diags.HasError() {
+		t.Fatalf("expected invalid client certificate to fail configure")
+	}
+}
+
+func TestProviderURLConflictsWithFields(t *testing.T) {
+	p := Provider()
+	diags := p.Validate(terraform.NewResourceConfigRaw(map[string]interface{}{
+		"url":  "https://tok@garage.example.com",
+		"host": "garage.example.com",
+	}))
+	if !diags.HasError() {
+		t.Fatalf("expected url to conflict with host")
+	}
+}
+
 func TestProviderConfigureRequiresHostAndToken(t *testing.T) {
 	p := Provider()
 	data := schema.TestResourceDataRaw(t, p.Schema, map[string]interface{}{})
@@ -127,7 +826,7 @@ func TestDetectGarageVersionV2Success(t *testing.T) {
 	host := strings.TrimPrefix(server.URL, "http://")
 	host = strings.TrimPrefix(host, "https://")
 
-	ver, src, err := detectGarageVersion(context.Background(), client, httpClient, "http", host, "token")
+	ver, src, _, err := detectGarageVersion(context.Background(), client, httpClient, "http", host, "token", false)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -154,7 +853,7 @@ func TestDetectGarageVersionV2Invalid(t *testing.T) {
 	host := strings.TrimPrefix(server.URL, "http://")
 	host = strings.TrimPrefix(host, "https://")
 
-	ver, src, err := detectGarageVersion(context.Background(), client, httpClient, "http", host, "token")
+	ver, src, _, err := detectGarageVersion(context.Background(), client, httpClient, "http", host, "token", false)
 	if err == nil {
 		t.Fatalf("expected error for invalid v2 payload")
 	}
@@ -190,7 +889,7 @@ func TestDetectGarageVersionFallbackToV1(t *testing.T) {
 	host = strings.TrimPrefix(host, "https://")
 	token := "token-xyz"
 
-	ver, src, err := detectGarageVersion(context.Background(), client, httpClient, "http", host, token)
+	ver, src, _, err := detectGarageVersion(context.Background(), client, httpClient, "http", host, token, false)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -225,7 +924,7 @@ func TestDetectGarageVersionMissingV2UnauthorizedV1(t *testing.T) {
 	host := strings.TrimPrefix(server.URL, "http://")
 	host = strings.TrimPrefix(host, "https://")
 
-	ver, src, err := detectGarageVersion(context.Background(), client, httpClient, "http", host, "token")
+	ver, src, _, err := detectGarageVersion(context.Background(), client, httpClient, "http", host, "token", false)
 	if err == nil {
 		t.Fatalf("expected error when v2 missing and v1 unauthorized")
 	}
@@ -254,7 +953,7 @@ func TestDetectGarageVersionAuthFailureStopsFallback(t *testing.T) {
 	host := strings.TrimPrefix(server.URL, "http://")
 	host = strings.TrimPrefix(host, "https://")
 
-	ver, src, err := detectGarageVersion(context.Background(), client, httpClient, "http", host, "token")
+	ver, src, _, err := detectGarageVersion(context.Background(), client, httpClient, "http", host, "token", false)
 	if err == nil {
 		t.Fatalf("expected error on auth failure")
 	}
@@ -283,7 +982,7 @@ func TestDetectGarageVersionBadRequestStopsFallback(t *testing.T) {
 	host := strings.TrimPrefix(server.URL, "http://")
 	host = strings.TrimPrefix(host, "https://")
 
-	ver, src, err := detectGarageVersion(context.Background(), client, httpClient, "http", host, "token")
+	ver, src, _, err := detectGarageVersion(context.Background(), client, httpClient, "http", host, "token", false)
 	if err == nil {
 		t.Fatalf("expected error on v2 bad request")
 	}
@@ -309,7 +1008,7 @@ func TestDetectGarageVersionServerErrorStopsFallback(t *testing.T) {
 	host := strings.TrimPrefix(server.URL, "http://")
 	host = strings.TrimPrefix(host, "https://")
 
-	ver, src, err := detectGarageVersion(context.Background(), client, httpClient, "http", host, "token")
+	ver, src, _, err := detectGarageVersion(context.Background(), client, httpClient, "http", host, "token", false)
 	if err == nil {
 		t.Fatalf("expected error on server failure")
 	}
@@ -339,7 +1038,7 @@ func TestDetectGarageVersionBothFail(t *testing.T) {
 	host := strings.TrimPrefix(server.URL, "http://")
 	host = strings.TrimPrefix(host, "https://")
 
-	ver, src, err := detectGarageVersion(context.Background(), client, httpClient, "http", host, "token")
+	ver, src, _, err := detectGarageVersion(context.Background(), client, httpClient, "http", host, "token", false)
 	if err == nil {
 		t.Fatalf("expected error when both version probes fail")
 	}
@@ -369,6 +1068,61 @@ func TestSanitizeHost(t *testing.T) {
 	}
 }
 
+func TestPrefixName(t *testing.T) {
+	p := &garageProvider{namePrefix: "tf-acc-"}
+	if got := p.prefixName("key"); got != "tf-acc-key" {
+		t.Fatalf("expected prefixed name, got %q", got)
+	}
+	if got := p.prefixName("tf-acc-key"); got != "tf-acc-key" {
+		t.Fatalf("expected prefix not to be doubled, got %q", got)
+	}
+	if got := (&garageProvider{}).prefixName("key"); got != "key" {
+		t.Fatalf("expected name unchanged without prefix, got %q", got)
+	}
+}
+
+func TestResolveScheme(t *testing.T) {
+	cases := []struct {
+		name       string
+		configured string
+		inferred   string
+		host       string
+		want       string
+	}{
+		{"port 443 implies https", "", "", "garage.example.com:443", "https"},
+		{"port 80 implies http", "", "", "garage.example.com:80", "http"},
+		{"ambiguous port defaults to https", "", "", "garage.example.com:3903", "https"},
+		{"no port defaults to https", "", "", "garage.example.com", "https"},
+		{"explicit scheme overrides port", "https", "", "garage.example.com:80", "https"},
+		{"url scheme overrides everything", "https", "http", "garage.example.com:443", "http"},
+	}
+	for _, tc := range cases {
+		if got := resolveScheme(tc.configured, tc.inferred, tc.host); got != tc.want {
+			t.Fatalf("%s: expected %q, got %q", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestProviderConfigureRejectsInvalidEffectiveScheme(t *testing.T) {
+	p := Provider()
+	for _, scheme := range []string{"ftp", " "} {
+		// GARAGE_SCHEME is not checked by the schema's ValidateFunc
+		t.Setenv("GARAGE_SCHEME", scheme)
+		data := schema.TestResourceDataRaw(t, p.Schema, map[string]interface{}{
+			"host":  "garage.example.com:3903",
+			"token": "token",
+		})
+
+		cfg, diags := providerConfigure(context.Background(), data)
+		if cfg != nil || !diags.HasError() || diags[0].Summary != "invalid scheme" {
+			t.Fatalf("expected invalid scheme %q to be rejected, got %#v", scheme, diags)
+		}
+		if !strings.Contains(diags[0].Detail, "garage.example.com:3903") {
+			t.Fatalf("expected host in detail, got %q", diags[0].Detail)
+		}
+	}
+}
+
 func TestNormalizeVersion(t *testing.T) {
 	v, err := normalizeVersion("v2.1.0")
 	if err != nil || v != "2.1.0" {
@@ -406,7 +1160,7 @@ func TestMinClusterSemverFromV2(t *testing.T) {
 	verStr := "2.2.0"
 	resp.Nodes[0].GarageVersion.Set(&verStr)
 
-	v, err := minClusterSemverFromV2(resp)
+	v, err := minClusterSemverFromV2(resp, false)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -415,9 +1169,28 @@ func TestMinClusterSemverFromV2(t *testing.T) {
 	}
 
 	resp.Nodes[0].GarageVersion = garageapi.NullableString{}
-	if _, err := minClusterSemverFromV2(resp); err == nil {
+	if _, err := minClusterSemverFromV2(resp, false); err == nil {
 		t.Fatalf("expected error when node lacks version")
 	}
+	if _, err := minClusterSemverFromV2(resp, true); err == nil {
+		t.Fatalf("expected error when no node reports a version")
+	}
+
+	old := "1.3.0"
+	resp.Nodes = append(resp.Nodes, garageapi.NodeResp{Id: "node-2"})
+	resp.Nodes[1].GarageVersion.Set(&old)
+	if _, err := minClusterSemverFromV2(resp, true); err == nil {
+		t.Fatalf("expected versioned v1 node to be rejected even when unversioned nodes are allowed")
+	}
+}
+
+// providerRetryTransport returns the transport below the response size guard,
+// which is the retry transport in a default configuration.
+func providerRetryTransport(p *garageProvider) http.RoundTripper {
+	if st, ok := p.httpClient.Transport.(*responseSizeTransport); ok {
+		return st.base
+	}
+	return p.httpClient.Transport
 }
 
 type roundTripperFunc func(*http.Request) (*http.Response, error)
@@ -426,6 +1199,55 @@ func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
 	return f(r)
 }
 
+func TestProviderConfigurePreferV1SkipsV2Probe(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if strings.HasPrefix(r.URL.Path, "/v2/") {
+			t.Fatalf("unexpected v2 request %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"garageVersion":"2.1.0"}`)
+	}))
+	defer server.Close()
+
+	p := Provider()
+	data := schema.TestResourceDataRaw(t, p.Schema, map[string]interface{}{
+		"host":               server.URL,
+		"token":              "token",
+		"prefer_api_version": "v1",
+	})
+
+	cfg, diags := providerConfigure(context.Background(), data)
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	if got, _ := cfg.(*garageProvider).detectedVersion(); got != "v1" {
+		t.Fatalf("expected v1 detection, got %q", got)
+	}
+	if len(paths) != 1 {
+		t.Fatalf("expected a single v1 probe, got %v", paths)
+	}
+}
+
+func TestDetectPreferredVersionV2NoFallback(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := newAPIClientForServer(server)
+	host := strings.TrimPrefix(server.URL, "http://")
+	if _, _, _, err := detectPreferredVersion(context.Background(), "v2", client, server.Client(), "http", host, "token", false); err == nil {
+		t.Fatalf("expected v2-only detection to fail")
+	}
+	if len(paths) != 1 || paths[0] != "/v2/GetClusterStatus" {
+		t.Fatalf("expected only the v2 probe, got %v", paths)
+	}
+}
+
 func TestProbeV1Version(t *testing.T) {
 	var gotAuth string
 	client := &http.Client{
@@ -451,6 +1273,114 @@ func TestProbeV1Version(t *testing.T) {
 	}
 }
 
+func TestProbeV1VersionRetriesTransientError(t *testing.T) {
+	calls := 0
+	// the provider's client: a 503 from a starting node is retried by the transport
+	client := &http.Client{Transport: &retryTransport{
+		base: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			calls++
+			if calls == 1 {
+				return &http.Response{StatusCode: http.StatusServiceUnavailable, Status: "503 Service Unavailable", Body: io.NopCloser(strings.NewReader("starting"))}, nil
+			}
+			return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Body: io.NopCloser(strings.NewReader(`{"garageVersion":"1.1.0"}`))}, nil
+		}),
+		maxAttempts: defaultRetryMaxAttempts,
+		backoff:     time.Millisecond,
+	}}
+
+	version, err := probeV1Version(context.Background(), client, "http", "localhost:3903", "token")
+	if err != nil {
+		t.Fatalf("probeV1Version failed: %v", err)
+	}
+	if version != "1.1.0" || calls != 2 {
+		t.Fatalf("expected version 1.1.0 after one retry, got %q in %d calls", version, calls)
+	}
+
+	// the probe adds no retries of its own on top of the transport
+	calls = 0
+	client = &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		calls++
+		return &http.Response{StatusCode: http.StatusServiceUnavailable, Status: "503 Service Unavailable", Body: io.NopCloser(strings.NewReader(""))}, nil
+	})}
+	if _, err := probeV1Version(context.Background(), client, "http", "localhost:3903", "token"); err == nil || calls != 1 {
+		t.Fatalf("expected a single failed probe, got err=%v calls=%d", err, calls)
+	}
+}
+
+func TestEnrichV2HTTPGenericErrorIncludesBody(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "https://example.com/v2/GetClusterStatus", nil)
+	resp := &http.Response{
+		Status:     "502 Bad Gateway",
+		StatusCode: http.StatusBadGateway,
+		Request:    req,
+		Body:       io.NopCloser(strings.NewReader("<html>upstream unavailable</html>")),
+	}
+
+	err := enrichV2HTTP(fmt.Errorf("unexpected content type"), resp)
+	msg := err.Error()
+	if !strings.Contains(msg, "upstream unavailable") || !strings.Contains(msg, "unexpected content type") {
+		t.Fatalf("expected body and error in message, got %q", msg)
+	}
+
+	// body is still readable afterwards
+	rest, _ := io.ReadAll(resp.Body)
+	if string(rest) != "<html>upstream unavailable</html>" {
+		t.Fatalf("expected body to be preserved, got %q", rest)
+	}
+}
+
+func TestEnrichV2HTTPConsumedBody(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "https://example.com/v2/GetClusterStatus", nil)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		fmt.Fprint(w, "gone")
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Request = req
+
+	msg := enrichV2HTTP(fmt.Errorf("boom"), resp).Error()
+	if !strings.Contains(msg, "already consumed") {
+		t.Fatalf("expected consumed-body note, got %q", msg)
+	}
+}
+
+func TestResponseBodySnippetTruncates(t *testing.T) {
+	resp := &http.Response{Body: io.NopCloser(strings.NewReader(strings.Repeat("x", 2*maxErrorBodySnippet)))}
+	got := responseBodySnippet(resp)
+	if !strings.HasSuffix(got, "…") || len(got) > maxErrorBodySnippet+len("…") {
+		t.Fatalf("expected truncated snippet, got %d bytes", len(got))
+	}
+}
+
+func TestJoinAPIURL(t *testing.T) {
+	cases := []struct {
+		host, prefix, path string
+		want               string
+	}{
+		{"garage:3903", "", "/v1/status", "http://garage:3903/v1/status"},
+		{"garage:3903", "", "v1/status", "http://garage:3903/v1/status"},
+		{"garage:3903/", "", "/v1/status", "http://garage:3903/v1/status"},
+		{"garage:3903", "admin", "/v1/status", "http://garage:3903/admin/v1/status"},
+		{"garage:3903", "/admin/", "v1/status", "http://garage:3903/admin/v1/status"},
+		{"garage:3903", "/admin", "/v1/status", "http://garage:3903/admin/v1/status"},
+		{"garage:3903", "admin/", "/v1/status", "http://garage:3903/admin/v1/status"},
+		{"garage:3903", "/", "/v1/status", "http://garage:3903/v1/status"},
+		{"garage:3903", "/proxy/admin/", "//v1/status", "http://garage:3903/proxy/admin/v1/status"},
+	}
+	for _, tc := range cases {
+		if got := joinAPIURL("http", tc.host, tc.prefix, tc.path); got != tc.want {
+			t.Fatalf("joinAPIURL(%q, %q, %q) = %q, want %q", tc.host, tc.prefix, tc.path, got, tc.want)
+		}
+	}
+}
+
 func TestEnrichV2HTTP(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "https://example.com/v2/GetClusterStatus", nil)
 	resp := &http.Response{
@@ -468,3 +1398,144 @@ func TestEnrichV2HTTP(t *testing.T) {
 		t.Fatalf("unexpected error message %q", msg)
 	}
 }
+
+func TestDetectedVersionConcurrentAccess(t *testing.T) {
+	p := &garageProvider{}
+	p.setDetectedVersion("v2", "2.0.0")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				if i%2 == 0 {
+					p.setDetectedVersion("v2", "2.0.0")
+				} else {
+					p.setDetectedVersion("v1", "1.0.0")
+				}
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				api, ver := p.detectedVersion()
+				if (api == "v2" && ver != "2.0.0") || (api == "v1" && ver != "1.0.0") {
+					t.Errorf("torn read: %q / %q", api, ver)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestBucketInfoCacheDedupesConcurrentReads(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	p := newTestProvider(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path != "/v2/GetBucketInfo" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		// keep the request in flight long enough for every reader to join it
+		time.Sleep(50 * time.Millisecond)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(bucketInfoJSON("bucket-1", nil, 0))),
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+		}, nil
+	})
+	p.bucketInfo = &bucketInfoCache{ttl: time.Minute}
+
+	const readers = 20
+	var wg sync.WaitGroup
+	errs := make(chan string, readers)
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			info, diags := fetchBucketInfo(context.Background(), p, "bucket-1")
+			if len(diags) > 0 {
+				errs <- fmt.Sprintf("unexpected diagnostics %#v", diags)
+				return
+			}
+			if info == nil || info.Id != "bucket-1" {
+				errs <- fmt.Sprintf("unexpected bucket info %#v", info)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for msg := range errs {
+		t.Error(msg)
+	}
+
+	// a later read within the TTL is served from the cache too
+	if _, diags := fetchBucketInfo(context.Background(), p, "bucket-1"); len(diags) > 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	if calls != 1 {
+		t.Fatalf("expected a single GetBucketInfo call, got %d", calls)
+	}
+}
+
+func TestBucketInfoCacheSharedWithDataSources(t *testing.T) {
+	calls := 0
+	p := newTestProvider(func(r *http.Request) (*http.Response, error) {
+		calls++
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(bucketInfoJSON("bucket-1", []string{"media"}, 0))),
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+		}, nil
+	})
+	p.bucketInfo = &bucketInfoCache{ttl: time.Minute}
+
+	if _, diags := fetchBucketInfo(context.Background(), p, "bucket-1"); len(diags) > 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	d := schema.TestResourceDataRaw(t, dataSourceBucketAliases().Schema, map[string]interface{}{"bucket_id": "bucket-1"})
+	if diags := dataSourceBucketAliasesRead(context.Background(), d, p); len(diags) > 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	if got := d.Get("global_aliases").([]interface{}); len(got) != 1 || got[0] != "media" {
+		t.Fatalf("unexpected global_aliases %#v", got)
+	}
+	if calls != 1 {
+		t.Fatalf("expected the data source to reuse the cached GetBucketInfo response, got %d calls", calls)
+	}
+}
+
+func TestBucketInfoCacheDoesNotKeepFailures(t *testing.T) {
+	calls := 0
+	p := newTestProvider(func(r *http.Request) (*http.Response, error) {
+		calls++
+		if calls == 1 {
+			return &http.Response{
+				StatusCode: http.StatusInternalServerError,
+				Body:       io.NopCloser(strings.NewReader(`{"code":"InternalError","message":"boom"}`)),
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+			}, nil
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(bucketInfoJSON("bucket-1", nil, 0))),
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+		}, nil
+	})
+	p.bucketInfo = &bucketInfoCache{ttl: time.Minute}
+
+	if _, diags := fetchBucketInfo(context.Background(), p, "bucket-1"); len(diags) == 0 {
+		t.Fatalf("expected the first read to fail")
+	}
+	info, diags := fetchBucketInfo(context.Background(), p, "bucket-1")
+	if len(diags) > 0 || info == nil {
+		t.Fatalf("expected the second read to refetch, got %#v %#v", info, diags)
+	}
+	if calls != 2 {
+		t.Fatalf("expected 2 calls, got %d", calls)
+	}
+}
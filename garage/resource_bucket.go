@@ -3,9 +3,14 @@ package garage
 import (
 	"context"
 	"fmt"
+	"math/big"
 	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
 
 	garage "git.deuxfleurs.fr/garage-sdk/garage-admin-sdk-golang"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
@@ -19,21 +24,93 @@ func getOkString(d *schema.ResourceData, key string) (string, bool) {
 	return s, s != ""
 }
 
+// getBool reads a bool attribute, treating a missing or non-bool value as false.
+func getBool(d *schema.ResourceData, key string) bool {
+	return asBool(d.Get(key))
+}
+
+func asBool(v interface{}) bool {
+	b, _ := v.(bool)
+	return b
+}
+
+// indirectString extracts a string from a plain string, a *string, or a nullable
+// wrapper exposing Get() *string, so state always stores a TypeString.
+func indirectString(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return ""
+	}
+	if rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return ""
+	}
+	if m := rv.MethodByName("Get"); m.IsValid() && m.Type().NumIn() == 0 && m.Type().NumOut() == 1 {
+		return indirectString(m.Call(nil)[0].Interface())
+	}
+	if rv.Kind() == reflect.Pointer {
+		rv = rv.Elem()
+	}
+	if rv.Kind() == reflect.String {
+		return rv.String()
+	}
+	return ""
+}
+
 func resourceBucket() *schema.Resource {
+	annotate := withResourceDiagnostics("bucket", "global_alias")
 	return &schema.Resource{
-		Description:   "This resource manages Garage buckets (global alias optional; create-time local alias optional).",
+		Description:   "This resource manages Garage buckets (global alias optional; create-time local alias optional). The `website_*` arguments can be left unset when website hosting is managed outside this resource: they then follow the values reported by Garage and are never sent on update.",
 		Schema:        schemaBucket(),
-		CreateContext: resourceBucketCreate,
-		ReadContext:   resourceBucketRead,
-		UpdateContext: resourceBucketUpdate,
-		DeleteContext: resourceBucketDelete,
+		CreateContext: annotate(resourceBucketCreate),
+		ReadContext:   annotate(resourceBucketRead),
+		UpdateContext: annotate(resourceBucketUpdate),
+		DeleteContext: annotate(resourceBucketDelete),
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
 		CustomizeDiff: func(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+			// Garage has no object ACLs; anonymous reads only go through website hosting
+			if d.Get("public_read").(bool) {
+				return fmt.Errorf("public_read is not supported by Garage: buckets have no ACLs; use website_access_enabled to serve objects anonymously through the website endpoint")
+			}
+			// the admin API has no lifecycle settings; Garage only takes them through the S3 API
+			if n, _ := d.Get("lifecycle_rule.#").(int); n > 0 {
+				return fmt.Errorf("lifecycle_rule is not supported by the Garage admin API: set expiration rules with PutBucketLifecycleConfiguration on the S3 endpoint instead, e.g. with an S3 client or another provider's lifecycle resource")
+			}
+			// key grants are only issued by Create; an edit would be stored in state
+			// without ever reaching the cluster
+			if d.Id() != "" && d.HasChange("key_grant") {
+				return fmt.Errorf("key_grant cannot be changed on an existing bucket: it is only applied when the bucket is created; manage the permissions of an existing bucket with garage_bucket_key instead")
+			}
+			// the admin API has no website redirect setting
+			if websiteRedirectConfigured(d) {
+				return fmt.Errorf("website_redirect_all_requests_to is not supported by the Garage admin API: it has no website redirect setting, so the bucket would be served with neither index document nor redirect; use website_config_index_document instead")
+			}
 			if d.Get("website_access_enabled").(bool) {
+				// the provider default_website_index_document stands in for a missing value
+				if p, _ := m.(*garageProvider); p != nil && p.defaultIndexDocument != "" {
+					return nil
+				}
 				if v, ok := d.GetOk("website_config_index_document"); !ok || v.(string) == "" {
-					return fmt.Errorf("website_config_index_document is required when website_access_enabled is true")
+					return fmt.Errorf("website_config_index_document is required when website_access_enabled is true, unless the provider sets default_website_index_document")
+				}
+			}
+
+			// surface the concrete alias operations an update will perform
+			if d.Id() != "" && d.HasChange("global_alias") {
+				oldRaw, newRaw := d.GetChange("global_alias")
+				p, _ := m.(*garageProvider)
+				ops := planAliasChanges(prefixAlias(p, oldRaw.(string)), prefixAlias(p, newRaw.(string)))
+				plan := make([]interface{}, 0, len(ops))
+				for _, op := range ops {
+					plan = append(plan, op.String())
+				}
+				tflog.Info(ctx, "planned global alias changes", map[string]interface{}{
+					"bucket_id":  d.Id(),
+					"operations": plan,
+				})
+				if err := d.SetNew("alias_change_plan", plan); err != nil {
+					return err
 				}
 			}
 			return nil
@@ -48,14 +125,14 @@ func schemaBucket() map[string]*schema.Schema {
 		"global_alias": {
 			Type:        schema.TypeString,
 			Optional:    true,
-			Description: "Creates a global alias for the bucket. A global alias is unique cluster-wide (e.g. `my-bucket`). You can add or remove additional aliases later using the `garage_bucket_alias` resource.",
+			Description: "Creates a global alias for the bucket. A global alias is unique cluster-wide (e.g. `my-bucket`). Can be combined with `local_alias`; both are applied in the same create call. You can add or remove additional aliases later using the `garage_bucket_alias` resource. Changing it to an empty string removes the alias, leaving the bucket reachable only by ID.",
 		},
 
 		"local_alias": {
 			Type:        schema.TypeList,
 			Optional:    true,
 			MaxItems:    1,
-			Description: "Creates a local alias bound to a specific access key at bucket creation time. Only one block is allowed here.",
+			Description: "Creates a local alias bound to a specific access key at bucket creation time. Only one block is allowed here. May be set together with `global_alias`: the bucket is then reachable by the global name for every key and by the local name for this key only.",
 			Elem: &schema.Resource{
 				Schema: map[string]*schema.Schema{
 					"alias": {
@@ -74,17 +151,63 @@ func schemaBucket() map[string]*schema.Schema {
 			},
 		},
 
-		"website_access_enabled": {
+		"key_grant": {
+			Type:        schema.TypeList,
+			Optional:    true,
+			Description: "Grants access keys permissions on the bucket right after it is created, in the same apply. Only applied at creation time and not to an adopted bucket; changing it on an existing bucket is rejected at plan time, so use `garage_bucket_key` to manage permissions afterwards.",
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"access_key_id": {
+						Type:        schema.TypeString,
+						Required:    true,
+						Description: "The access key ID to grant permissions to.",
+					},
+					"read": {
+						Type:        schema.TypeBool,
+						Optional:    true,
+						Description: "Allow the key to read from the bucket.",
+					},
+					"write": {
+						Type:        schema.TypeBool,
+						Optional:    true,
+						Description: "Allow the key to write to the bucket.",
+					},
+					"owner": {
+						Type:        schema.TypeBool,
+						Optional:    true,
+						Description: "Make the key an owner of the bucket.",
+					},
+				},
+			},
+		},
+
+		"adopt_existing": {
 			Type:        schema.TypeBool,
 			Optional:    true,
 			Default:     false,
-			Description: "Enable static website hosting for the bucket. Defaults to `false`. When enabled, `website_config_index_document` is required.",
+			Description: "If a bucket with `global_alias` already exists, adopt it instead of failing. Terraform then manages (and on destroy deletes) that pre-existing bucket. `local_alias` is not applied to an adopted bucket.",
+		},
+
+		"public_read": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "Reserved. Garage has no per-bucket ACLs, so a public-read toggle cannot be applied and `true` is rejected at plan time. Anonymous access is only possible through website hosting (`website_access_enabled`), which serves objects over the separate web endpoint, not the S3 API.",
+		},
+
+		"website_access_enabled": {
+			Type:     schema.TypeBool,
+			Optional: true,
+			// computed so an unset value follows the server (e.g. after import)
+			// instead of planning a change to false
+			Computed:    true,
+			Description: "Enable static website hosting for the bucket. When not set, the value reported by Garage is kept, so hosting enabled outside Terraform or on an imported bucket is not turned off; set it to `false` explicitly to disable hosting. When enabled, `website_config_index_document` is required unless the provider sets `default_website_index_document`.",
 		},
 		"website_config_index_document": {
 			Type:        schema.TypeString,
 			Optional:    true,
 			Computed:    true,
-			Description: "Name of the index document (e.g. `index.html`). Required if `website_access_enabled` is `true`.",
+			Description: "Name of the index document (e.g. `index.html`). Required if `website_access_enabled` is `true`, unless the provider sets `default_website_index_document`.",
 		},
 		"website_config_error_document": {
 			Type:        schema.TypeString,
@@ -92,6 +215,37 @@ func schemaBucket() map[string]*schema.Schema {
 			Computed:    true,
 			Description: "Name of the error document (e.g. `404.html`). Optional, used when website hosting is enabled.",
 		},
+		"website_redirect_all_requests_to": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "Reserved. The Garage admin API has no website redirect setting, so a non-empty value is rejected at plan time.",
+		},
+
+		"lifecycle_rule": {
+			Type:        schema.TypeList,
+			Optional:    true,
+			Description: "Reserved. The Garage admin API has no object lifecycle settings, so any rule is rejected at plan time. Garage applies expiration rules set through the S3 API (`PutBucketLifecycleConfiguration`) instead.",
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"prefix": {
+						Type:        schema.TypeString,
+						Optional:    true,
+						Description: "Key prefix the rule applies to. Empty matches every object.",
+					},
+					"expiration_days": {
+						Type:     schema.TypeInt,
+						Required: true,
+						ValidateFunc: func(v interface{}, k string) (ws []string, es []error) {
+							if v.(int) < 1 {
+								es = append(es, fmt.Errorf("%q must be at least 1, got %d", k, v.(int)))
+							}
+							return
+						},
+						Description: "Number of days after creation at which matching objects expire.",
+					},
+				},
+			},
+		},
 
 		"quotas": {
 			Type:        schema.TypeList,
@@ -101,9 +255,22 @@ func schemaBucket() map[string]*schema.Schema {
 			Elem: &schema.Resource{
 				Schema: map[string]*schema.Schema{
 					"max_size": {
-						Type:        schema.TypeInt,
-						Optional:    true,
-						Description: "Maximum total size in bytes allowed for this bucket. `0` means unlimited.",
+						Type:             schema.TypeInt,
+						Optional:         true,
+						DiffSuppressFunc: suppressQuotaSizeFromHuman,
+						Description:      "Maximum total size in bytes allowed for this bucket. `0` means unlimited.",
+					},
+					"max_size_human": {
+						Type:          schema.TypeString,
+						Optional:      true,
+						ConflictsWith: []string{"quotas.0.max_size"},
+						ValidateFunc: func(v interface{}, k string) (ws []string, es []error) {
+							if _, err := parseByteSize(v.(string)); err != nil {
+								es = append(es, fmt.Errorf("%q: %v", k, err))
+							}
+							return
+						},
+						Description: "Maximum total size as a human-readable string, e.g. `10GiB` or `500MB`. Binary (`KiB`…`PiB`) and decimal (`KB`…`PB`) units are accepted. Conflicts with `max_size`, which is still reported in bytes.",
 					},
 					"max_objects": {
 						Type:        schema.TypeInt,
@@ -114,6 +281,19 @@ func schemaBucket() map[string]*schema.Schema {
 			},
 		},
 
+		"warn_at_size_percent": {
+			Type:         schema.TypeInt,
+			Optional:     true,
+			ValidateFunc: validatePercent,
+			Description:  "Emit a warning on refresh when `quota_size_used_percent` reaches this percentage of `quotas.max_size`. Advisory only; nothing is enforced. Ignored without a size quota.",
+		},
+		"warn_at_objects_percent": {
+			Type:         schema.TypeInt,
+			Optional:     true,
+			ValidateFunc: validatePercent,
+			Description:  "Emit a warning on refresh when `quota_objects_used_percent` reaches this percentage of `quotas.max_objects`. Advisory only; nothing is enforced. Ignored without an object quota.",
+		},
+
 		/* ------------------------------ Outputs ----------------------------- */
 
 		"global_aliases": {
@@ -122,6 +302,12 @@ func schemaBucket() map[string]*schema.Schema {
 			Computed:    true,
 			Description: "List of all global aliases currently bound to the bucket.",
 		},
+		"alias_change_plan": {
+			Type:        schema.TypeList,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Computed:    true,
+			Description: "Alias operations planned for the latest `global_alias` change, in order (e.g. `add:new-name`, `remove:old-name`). Kept after the apply as a record of what was performed, until `global_alias` changes again.",
+		},
 		"objects": {
 			Type:        schema.TypeInt,
 			Computed:    true,
@@ -137,6 +323,27 @@ func schemaBucket() map[string]*schema.Schema {
 			Computed:    true,
 			Description: "Number of unfinished uploads currently tracked for the bucket.",
 		},
+		"quota_size_used_percent": {
+			Type:        schema.TypeFloat,
+			Computed:    true,
+			Description: "Percentage of `quotas.max_size` used by `bytes`. `0` when no size quota is set.",
+		},
+		"quota_objects_used_percent": {
+			Type:        schema.TypeFloat,
+			Computed:    true,
+			Description: "Percentage of `quotas.max_objects` used by `objects`. `0` when no object quota is set.",
+		},
+		"quotas_enabled": {
+			Type:        schema.TypeBool,
+			Computed:    true,
+			Description: "True when the bucket has a positive `max_size` or `max_objects` quota.",
+		},
+		"keys_without_permissions": {
+			Type:        schema.TypeList,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Computed:    true,
+			Description: "Access key IDs listed on the bucket without read, write or owner permission, e.g. leftover local alias bindings. Candidates for cleanup.",
+		},
 	}
 }
 
@@ -147,12 +354,21 @@ func flattenBucketInfo(bucket *garage.GetBucketInfoResponse) map[string]interfac
 		"objects":                bucket.Objects,
 		"bytes":                  bucket.Bytes,
 		"unfinished_uploads":     bucket.UnfinishedUploads,
+
+		"quota_size_used_percent":    0.0,
+		"quota_objects_used_percent": 0.0,
+		"quotas_enabled":             false,
+
+		"keys_without_permissions": keysWithoutPermissions(bucket),
 	}
 
-	// Website config
+	// Website config. Without one (website disabled) the documents are cleared
+	// so stale values do not linger in these computed attributes.
+	b["website_config_index_document"] = ""
+	b["website_config_error_document"] = nil
 	if bucket.WebsiteConfig.IsSet() && bucket.WebsiteConfig.Get() != nil {
 		wc := bucket.WebsiteConfig.Get()
-		b["website_config_index_document"] = wc.IndexDocument
+		b["website_config_index_document"] = indirectString(wc.IndexDocument)
 
 		if wc.ErrorDocument.IsSet() {
 			if v := wc.ErrorDocument.Get(); v != nil {
@@ -173,6 +389,7 @@ func flattenBucketInfo(bucket *garage.GetBucketInfoResponse) map[string]interfac
 		if bucket.Quotas.MaxSize.IsSet() {
 			if v := bucket.Quotas.MaxSize.Get(); v != nil && *v > 0 {
 				q["max_size"] = int(*v)
+				b["quota_size_used_percent"] = usedPercent(bucket.Bytes, *v)
 				hasAny = true
 			}
 		}
@@ -180,24 +397,54 @@ func flattenBucketInfo(bucket *garage.GetBucketInfoResponse) map[string]interfac
 		if bucket.Quotas.MaxObjects.IsSet() {
 			if v := bucket.Quotas.MaxObjects.Get(); v != nil && *v > 0 {
 				q["max_objects"] = int(*v)
+				b["quota_objects_used_percent"] = usedPercent(bucket.Objects, *v)
 				hasAny = true
 			}
 		}
 
 		if hasAny {
 			b["quotas"] = []interface{}{q}
+			b["quotas_enabled"] = true
 		}
 	}
 
 	return b
 }
 
+// keysWithoutPermissions returns the IDs of the bucket's keys that hold none of
+// read, write and owner.
+func keysWithoutPermissions(bucket *garage.GetBucketInfoResponse) []string {
+	ids := []string{}
+	for _, key := range bucket.Keys {
+		perms := key.GetPermissions()
+		if !perms.GetRead() && !perms.GetWrite() && !perms.GetOwner() {
+			ids = append(ids, key.GetAccessKeyId())
+		}
+	}
+	return ids
+}
+
+// usedPercent returns used/limit as a percentage, or 0 when there is no limit.
+func usedPercent(used, limit int64) float64 {
+	if limit <= 0 {
+		return 0
+	}
+	return float64(used) / float64(limit) * 100
+}
+
+// resourceBucketCreate creates the bucket with its optional global alias and
+// optional local alias in a single CreateBucket call; both may be set at once.
 func resourceBucketCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	p := m.(*garageProvider)
 
+	grants, diags := keyGrants(d)
+	if len(diags) > 0 {
+		return diags
+	}
+
 	reqBody := garage.CreateBucketRequest{}
 	if alias, ok := getOkString(d, "global_alias"); ok {
-		reqBody.SetGlobalAlias(alias)
+		reqBody.SetGlobalAlias(p.prefixName(alias))
 	}
 
 	// optional local_alias at create time
@@ -218,8 +465,22 @@ func resourceBucketCreate(ctx context.Context, d *schema.ResourceData, m interfa
 		CreateBucketRequest(reqBody).
 		Execute()
 	if err != nil {
+		if httpResp != nil && httpResp.StatusCode == http.StatusConflict && d.Get("adopt_existing").(bool) {
+			if alias, ok := getOkString(d, "global_alias"); ok {
+				return adoptExistingBucket(ctx, d, m, p.prefixName(alias))
+			}
+		}
+		if isAlreadyExists(err, httpResp) {
+			// the conflicting bucket's ID would need another lookup, so it is left as a placeholder
+			diags := alreadyExistsDiagnostics("garage_bucket", "<bucket_id>", err, httpResp)
+			diags[0].Detail += "\n\nAlternatively, set adopt_existing = true to manage the bucket that already holds this global alias."
+			return diags
+		}
 		return createDiagnostics(err, httpResp)
 	}
+	if resp == nil {
+		return emptyResponseDiagnostics("CreateBucket")
+	}
 
 	d.SetId(resp.Id)
 
@@ -228,16 +489,77 @@ func resourceBucketCreate(ctx context.Context, d *schema.ResourceData, m interfa
 		_ = d.Set("local_alias", v)
 	}
 
+	// the bucket is new, so every grant is a plain AllowBucketKey
+	for _, g := range grants {
+		if diags := applyBucketKeyChanges(ctx, p, resp.Id, g.keyID, bucketKeyPermissions{}, g.perms); len(diags) > 0 {
+			return diags
+		}
+	}
+
 	return resourceBucketRead(ctx, d, m)
 }
 
-func resourceBucketRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+type keyGrant struct {
+	keyID string
+	perms bucketKeyPermissions
+}
+
+// keyGrants reads the key_grant blocks, rejecting entries that grant nothing
+// before the bucket is created.
+func keyGrants(d *schema.ResourceData) ([]keyGrant, diag.Diagnostics) {
+	var grants []keyGrant
+	for _, raw := range d.Get("key_grant").([]interface{}) {
+		gm, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		g := keyGrant{
+			keyID: gm["access_key_id"].(string),
+			perms: bucketKeyPermissions{
+				Read:  gm["read"] == true,
+				Write: gm["write"] == true,
+				Owner: gm["owner"] == true,
+			},
+		}
+		if !g.perms.any() {
+			return nil, diag.Diagnostics{{
+				Severity: diag.Error,
+				Summary:  "invalid key_grant",
+				Detail:   fmt.Sprintf("key_grant for %q must set at least one of read, write, or owner to true", g.keyID),
+			}}
+		}
+		grants = append(grants, g)
+	}
+	return grants, nil
+}
+
+// adoptExistingBucket takes over the bucket already owning the global alias.
+func adoptExistingBucket(ctx context.Context, d *schema.ResourceData, m interface{}, alias string) diag.Diagnostics {
 	p := m.(*garageProvider)
 
 	bucket, httpResp, err := p.client.BucketAPI.
 		GetBucketInfo(p.withToken(ctx)).
-		Id(d.Id()).
+		GlobalAlias(alias).
 		Execute()
+	if err != nil {
+		return createDiagnostics(err, httpResp)
+	}
+	if bucket == nil || bucket.Id == "" {
+		return diag.Errorf("bucket with global alias %q reported as existing but could not be found", alias)
+	}
+
+	tflog.Info(ctx, "adopting existing bucket", map[string]interface{}{
+		"bucket_id":    bucket.Id,
+		"global_alias": alias,
+	})
+	d.SetId(bucket.Id)
+	return resourceBucketRead(ctx, d, m)
+}
+
+func resourceBucketRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	p := m.(*garageProvider)
+
+	bucket, httpResp, err := p.getBucketInfo(ctx, d.Id())
 	if err != nil {
 		if httpResp != nil && httpResp.StatusCode == http.StatusNotFound {
 			d.SetId("")
@@ -246,45 +568,154 @@ func resourceBucketRead(ctx context.Context, d *schema.ResourceData, m interface
 		return createDiagnostics(err, httpResp)
 	}
 	if bucket == nil {
-		d.SetId("")
-		return nil
+		return emptyResponseDiagnostics("GetBucketInfo")
 	}
 
-	for k, v := range flattenBucketInfo(bucket) {
+	flat := flattenBucketInfo(bucket)
+	preserveQuotaSizeHuman(d, flat)
+	for k, v := range flat {
 		if err := d.Set(k, v); err != nil {
 			return diag.FromErr(err)
 		}
 	}
 
-	return nil
+	return quotaUsageWarnings(d, flat)
+}
+
+// validatePercent accepts a whole percentage between 1 and 100.
+func validatePercent(v interface{}, k string) (ws []string, es []error) {
+	if n := v.(int); n < 1 || n > 100 {
+		es = append(es, fmt.Errorf("%q must be between 1 and 100, got %d", k, n))
+	}
+	return
+}
+
+// quotaUsageWarnings returns a warning for each warn_at_*_percent threshold
+// reached by the usage in flat. Quota percentages are 0 without a quota, so
+// unset quotas never warn.
+func quotaUsageWarnings(d *schema.ResourceData, flat map[string]interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for _, c := range []struct {
+		threshold, used, quota string
+	}{
+		{"warn_at_size_percent", "quota_size_used_percent", "max_size"},
+		{"warn_at_objects_percent", "quota_objects_used_percent", "max_objects"},
+	} {
+		threshold, _ := d.Get(c.threshold).(int)
+		used, _ := flat[c.used].(float64)
+		if threshold <= 0 || used < float64(threshold) {
+			continue
+		}
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "bucket quota usage above threshold",
+			Detail:   fmt.Sprintf("bucket %q uses %.1f%% of its %s quota, at or above %s = %d", d.Id(), used, c.quota, c.threshold, threshold),
+		})
+	}
+	return diags
+}
+
+// preserveQuotaSizeHuman keeps the configured max_size_human in state as long as it
+// still matches the quota reported by the API; Garage itself only stores bytes.
+func preserveQuotaSizeHuman(d *schema.ResourceData, flat map[string]interface{}) {
+	human, _ := d.Get("quotas.0.max_size_human").(string)
+	if human == "" {
+		return
+	}
+	quotas, ok := flat["quotas"].([]interface{})
+	if !ok || len(quotas) == 0 {
+		return
+	}
+	q := quotas[0].(map[string]interface{})
+	size, _ := q["max_size"].(int)
+	if n, err := parseByteSize(human); err == nil && n == int64(size) {
+		q["max_size_human"] = human
+	}
 }
 
-func buildWebsiteAccess(d *schema.ResourceData) (*garage.UpdateBucketWebsiteAccess, diag.Diagnostics) {
+// defaultIndex (the provider default_website_index_document) is used when the
+// bucket enables website access without an index document of its own.
+func buildWebsiteAccess(d *schema.ResourceData, defaultIndex string) (*garage.UpdateBucketWebsiteAccess, diag.Diagnostics) {
 	if v, ok := d.GetOk("website_access_enabled"); ok {
 		if v.(bool) {
 			indexDoc, _ := getOkString(d, "website_config_index_document")
+			if indexDoc == "" {
+				indexDoc = defaultIndex
+			}
 			if indexDoc == "" {
 				return nil, diag.Diagnostics{{
 					Severity: diag.Error,
 					Summary:  "website access enabled but index document missing",
-					Detail:   "website_config_index_document is required when website_access_enabled is true",
+					Detail:   "website_config_index_document is required when website_access_enabled is true, unless the provider sets default_website_index_document",
 				}}
 			}
 			var errDocPtr *string
 			if s, ok := getOkString(d, "website_config_error_document"); ok {
 				errDocPtr = &s
 			}
-			return &garage.UpdateBucketWebsiteAccess{
+			wa := &garage.UpdateBucketWebsiteAccess{
 				Enabled:       true,
 				IndexDocument: *garage.NewNullableString(&indexDoc),
 				ErrorDocument: *garage.NewNullableString(errDocPtr),
-			}, nil
+			}
+			return wa, nil
 		}
-		return &garage.UpdateBucketWebsiteAccess{Enabled: false}, nil
+	}
+	// GetOk reports false as unset, so a switch from enabled to disabled is
+	// detected through the change itself.
+	if d.HasChange("website_access_enabled") && !d.Get("website_access_enabled").(bool) {
+		// Garage drops the whole website config when disabling; the documents are
+		// sent as explicit nulls so nothing is carried over to a later re-enable.
+		return &garage.UpdateBucketWebsiteAccess{
+			Enabled:       false,
+			IndexDocument: *garage.NewNullableString(nil),
+			ErrorDocument: *garage.NewNullableString(nil),
+		}, nil
 	}
 	return nil, nil
 }
 
+// websiteFields are the attributes that make up the bucket's website access update.
+var websiteFields = []string{
+	"website_access_enabled",
+	"website_config_index_document",
+	"website_config_error_document",
+	"website_redirect_all_requests_to",
+}
+
+// websiteRedirectConfigured reports whether the configuration sets a non-empty
+// website_redirect_all_requests_to, or one only known at apply.
+func websiteRedirectConfigured(d *schema.ResourceDiff) bool {
+	if !d.NewValueKnown("website_redirect_all_requests_to") {
+		return true
+	}
+	v, _ := d.Get("website_redirect_all_requests_to").(string)
+	return v != ""
+}
+
+// websiteErrorDocumentOnlyChange reports whether the error document is the only website setting being changed.
+func websiteErrorDocumentOnlyChange(d *schema.ResourceData) bool {
+	return d.HasChange("website_config_error_document") &&
+		!d.HasChanges("website_access_enabled", "website_config_index_document", "website_redirect_all_requests_to")
+}
+
+// mergeCurrentIndexDocument fills the index document from the bucket's current website
+// config, so changing only the error document leaves the index document as it is on
+// the server rather than relying on the value last seen in state.
+func mergeCurrentIndexDocument(ctx context.Context, p *garageProvider, bucketID string, wa *garage.UpdateBucketWebsiteAccess) diag.Diagnostics {
+	bucket, httpResp, err := p.getBucketInfo(ctx, bucketID)
+	if err != nil {
+		return createDiagnostics(err, httpResp)
+	}
+	if bucket == nil || !bucket.WebsiteConfig.IsSet() || bucket.WebsiteConfig.Get() == nil {
+		return nil
+	}
+	if index := indirectString(bucket.WebsiteConfig.Get().IndexDocument); index != "" {
+		wa.IndexDocument = *garage.NewNullableString(&index)
+	}
+	return nil
+}
+
 func buildQuotas(d *schema.ResourceData) (*garage.ApiBucketQuotas, diag.Diagnostics) {
 	raw := d.Get("quotas").([]interface{})
 	if len(raw) == 0 {
@@ -300,6 +731,17 @@ func buildQuotas(d *schema.ResourceData) (*garage.ApiBucketQuotas, diag.Diagnost
 	}
 	if sizeSet && objsSet {
 		maxSize := int64(sizeRaw.(int))
+		if human, _ := qm["max_size_human"].(string); human != "" {
+			n, err := parseByteSize(human)
+			if err != nil {
+				return nil, diag.Diagnostics{{
+					Severity: diag.Error,
+					Summary:  "invalid quotas configuration",
+					Detail:   fmt.Sprintf("max_size_human: %v", err),
+				}}
+			}
+			maxSize = n
+		}
 		maxObjects := int64(objsRaw.(int))
 		return &garage.ApiBucketQuotas{
 			MaxSize:    *garage.NewNullableInt64(&maxSize),
@@ -314,49 +756,119 @@ func buildQuotas(d *schema.ResourceData) (*garage.ApiBucketQuotas, diag.Diagnost
 	}}
 }
 
+// byteSizeUnits maps accepted size suffixes to their multiplier in bytes.
+var byteSizeUnits = map[string]int64{
+	"":    1,
+	"B":   1,
+	"KB":  1000,
+	"MB":  1000 * 1000,
+	"GB":  1000 * 1000 * 1000,
+	"TB":  1000 * 1000 * 1000 * 1000,
+	"PB":  1000 * 1000 * 1000 * 1000 * 1000,
+	"KIB": 1 << 10,
+	"MIB": 1 << 20,
+	"GIB": 1 << 30,
+	"TIB": 1 << 40,
+	"PIB": 1 << 50,
+}
+
+// parseByteSize converts strings like "10GiB", "500MB" or "1.5 TiB" to bytes.
+// Units are case-insensitive; the result must be a whole number of bytes.
+func parseByteSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(s)
+	}
+	num, unit := s[:i], strings.ToUpper(strings.TrimSpace(s[i:]))
+	if num == "" {
+		return 0, fmt.Errorf("invalid size %q: missing number", s)
+	}
+	mult, ok := byteSizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q: unknown unit %q", s, s[i:])
+	}
+
+	r, ok := new(big.Rat).SetString(num)
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q: bad number %q", s, num)
+	}
+	r.Mul(r, new(big.Rat).SetInt64(mult))
+	if !r.IsInt() || !r.Num().IsInt64() {
+		return 0, fmt.Errorf("invalid size %q: not a whole number of bytes that fits in 64 bits", s)
+	}
+	return r.Num().Int64(), nil
+}
+
+// suppressQuotaSizeFromHuman hides the max_size diff when the size is configured through
+// max_size_human and already matches the stored byte count.
+func suppressQuotaSizeFromHuman(_, oldValue, newValue string, d *schema.ResourceData) bool {
+	if newValue != "" && newValue != "0" {
+		return false
+	}
+	human, _ := d.Get("quotas.0.max_size_human").(string)
+	if human == "" {
+		return false
+	}
+	n, err := parseByteSize(human)
+	return err == nil && strconv.FormatInt(n, 10) == oldValue
+}
+
 func resourceBucketUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	p := m.(*garageProvider)
 
 	// rename semantics for global_alias
 	if d.HasChange("global_alias") {
 		oldRaw, newRaw := d.GetChange("global_alias")
-		oldAlias := oldRaw.(string)
-		newAlias := newRaw.(string)
-
-		// add new first
-		if newAlias != "" {
-			_, httpResp, err := p.client.BucketAliasAPI.
-				AddBucketAlias(p.withToken(ctx)).
-				AddBucketAliasRequest(*garage.NewAddBucketAliasRequest(
-					newAlias, "", "", d.Id(),
-				)).
-				Execute()
-			if err != nil {
-				return createDiagnostics(err, httpResp)
+		ops := planAliasChanges(prefixAlias(p, oldRaw.(string)), prefixAlias(p, newRaw.(string)))
+		existing, diags := existingGlobalAliases(ctx, p, d.Id(), ops)
+		if len(diags) > 0 {
+			return diags
+		}
+		for _, op := range ops {
+			// a previous partial apply may already have added the new alias
+			if op.Add && existing[op.Alias] {
+				tflog.Debug(ctx, "global alias already present, skipping add", map[string]interface{}{
+					"bucket_id": d.Id(),
+					"alias":     op.Alias,
+				})
+				continue
+			}
+			if diags := applyAliasChange(ctx, p, d.Id(), op); len(diags) > 0 {
+				return diags
 			}
 		}
-
-		// then remove old (if different)
-		if oldAlias != "" && oldAlias != newAlias {
-			_, httpResp, err := p.client.BucketAliasAPI.
-				RemoveBucketAlias(p.withToken(ctx)).
-				RemoveBucketAliasRequest(*garage.NewRemoveBucketAliasRequest(
-					oldAlias, "", "", d.Id(),
-				)).
-				Execute()
-			if err != nil {
-				return createDiagnostics(err, httpResp)
+		if newRaw.(string) == "" && oldRaw.(string) != "" {
+			if diags := confirmGlobalAliasRemoved(ctx, p, d.Id(), prefixAlias(p, oldRaw.(string))); len(diags) > 0 {
+				return diags
 			}
 		}
 	}
 
-	websiteAccess, diags := buildWebsiteAccess(d)
-	if len(diags) > 0 {
-		return diags
+	// unchanged website and quota settings are left out of the request so they
+	// do not overwrite changes made outside Terraform
+	var websiteAccess *garage.UpdateBucketWebsiteAccess
+	if d.HasChanges(websiteFields...) {
+		wa, diags := buildWebsiteAccess(d, p.defaultIndexDocument)
+		if len(diags) > 0 {
+			return diags
+		}
+		if wa != nil && wa.Enabled && websiteErrorDocumentOnlyChange(d) {
+			if diags := mergeCurrentIndexDocument(ctx, p, d.Id(), wa); len(diags) > 0 {
+				return diags
+			}
+		}
+		websiteAccess = wa
 	}
-	quotas, diags := buildQuotas(d)
-	if len(diags) > 0 {
-		return diags
+	var quotas *garage.ApiBucketQuotas
+	if d.HasChange("quotas") {
+		q, diags := buildQuotas(d)
+		if len(diags) > 0 {
+			return diags
+		}
+		quotas = q
 	}
 
 	// nothing else to update
@@ -384,6 +896,120 @@ func resourceBucketUpdate(ctx context.Context, d *schema.ResourceData, m interfa
 	return resourceBucketRead(ctx, d, m)
 }
 
+// aliasChange is a single global alias operation performed on update.
+type aliasChange struct {
+	Add   bool
+	Alias string
+}
+
+func (c aliasChange) String() string {
+	if c.Add {
+		return "add:" + c.Alias
+	}
+	return "remove:" + c.Alias
+}
+
+// prefixAlias applies the provider resource_name_prefix to a non-empty global alias.
+func prefixAlias(p *garageProvider, alias string) string {
+	if p == nil || alias == "" {
+		return alias
+	}
+	return p.prefixName(alias)
+}
+
+// planAliasChanges returns the operations needed to move from oldAlias to newAlias:
+// the new alias is added first so the bucket is never left without one, then the old is removed.
+func planAliasChanges(oldAlias, newAlias string) []aliasChange {
+	var ops []aliasChange
+	if newAlias != "" {
+		ops = append(ops, aliasChange{Add: true, Alias: newAlias})
+	}
+	if oldAlias != "" && oldAlias != newAlias {
+		ops = append(ops, aliasChange{Add: false, Alias: oldAlias})
+	}
+	return ops
+}
+
+// existingGlobalAliases returns the bucket's current global aliases when ops adds
+// one, so the add can be skipped if it is already in place. No request is made otherwise.
+func existingGlobalAliases(ctx context.Context, p *garageProvider, bucketID string, ops []aliasChange) (map[string]bool, diag.Diagnostics) {
+	adds := false
+	for _, op := range ops {
+		adds = adds || op.Add
+	}
+	if !adds {
+		return nil, nil
+	}
+
+	bucket, httpResp, err := p.getBucketInfo(ctx, bucketID)
+	if err != nil {
+		return nil, createDiagnostics(err, httpResp)
+	}
+	if bucket == nil {
+		return nil, emptyResponseDiagnostics("GetBucketInfo")
+	}
+	existing := make(map[string]bool, len(bucket.GlobalAliases))
+	for _, alias := range bucket.GlobalAliases {
+		existing[alias] = true
+	}
+	return existing, nil
+}
+
+// confirmGlobalAliasRemoved re-reads the bucket after global_alias was cleared,
+// failing if alias is still bound and warning that the bucket is now only
+// reachable by ID when it has no global alias left.
+func confirmGlobalAliasRemoved(ctx context.Context, p *garageProvider, bucketID, alias string) diag.Diagnostics {
+	bucket, httpResp, err := p.getBucketInfo(ctx, bucketID)
+	if err != nil {
+		return createDiagnostics(err, httpResp)
+	}
+	if bucket == nil {
+		return emptyResponseDiagnostics("GetBucketInfo")
+	}
+	for _, a := range bucket.GlobalAliases {
+		if a == alias {
+			return diag.Diagnostics{{
+				Severity: diag.Error,
+				Summary:  "global alias not removed",
+				Detail:   fmt.Sprintf("global alias %q is still bound to bucket %q after removing it", alias, bucketID),
+			}}
+		}
+	}
+	if len(bucket.GlobalAliases) == 0 {
+		tflog.Warn(ctx, "bucket has no global alias anymore; it can only be referenced by ID", map[string]interface{}{
+			"bucket_id":     bucketID,
+			"removed_alias": alias,
+		})
+	}
+	return nil
+}
+
+func applyAliasChange(ctx context.Context, p *garageProvider, bucketID string, op aliasChange) diag.Diagnostics {
+	if op.Add {
+		_, httpResp, err := p.client.BucketAliasAPI.
+			AddBucketAlias(p.withToken(ctx)).
+			AddBucketAliasRequest(*garage.NewAddBucketAliasRequest(
+				op.Alias, "", "", bucketID,
+			)).
+			Execute()
+		if err != nil {
+			return createDiagnostics(err, httpResp)
+		}
+		return nil
+	}
+
+	_, httpResp, err := p.client.BucketAliasAPI.
+		RemoveBucketAlias(p.withToken(ctx)).
+		RemoveBucketAliasRequest(*garage.NewRemoveBucketAliasRequest(
+			op.Alias, "", "", bucketID,
+		)).
+		Execute()
+	if err != nil {
+		return createDiagnostics(err, httpResp)
+	}
+	return nil
+}
+
 func resourceBucketDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	p := m.(*garageProvider)
 
@@ -395,7 +1021,33 @@ func resourceBucketDelete(ctx context.Context, d *schema.ResourceData, m interfa
 		if httpResp != nil && httpResp.StatusCode == http.StatusNotFound {
 			return nil
 		}
-		return createDiagnostics(err, httpResp)
+		diags := createDiagnostics(err, httpResp)
+		if httpResp != nil {
+			enrichBucketDeleteDiagnostics(ctx, p, d.Id(), diags)
+		}
+		return diags
 	}
 	return nil
 }
+
+// enrichBucketDeleteDiagnostics appends what is still stored in the bucket to a failed
+// delete (typically Garage's 400 "bucket not empty"). Lookup failures are ignored so
+// the original error is always reported.
+func enrichBucketDeleteDiagnostics(ctx context.Context, p *garageProvider, bucketID string, diags diag.Diagnostics) {
+	if len(diags) == 0 {
+		return
+	}
+	bucket, _, err := p.getBucketInfo(ctx, bucketID)
+	if err != nil || bucket == nil {
+		return
+	}
+
+	usage := fmt.Sprintf("bucket still holds %d objects (%d bytes) and %d unfinished uploads",
+		bucket.Objects, bucket.Bytes, bucket.UnfinishedUploads)
+	hint := "empty the bucket before destroying it; force_destroy is not supported by this provider"
+	if diags[0].Detail != "" {
+		diags[0].Detail = fmt.Sprintf("%s\n\n%s; %s", diags[0].Detail, usage, hint)
+	} else {
+		diags[0].Detail = fmt.Sprintf("%s; %s", usage, hint)
+	}
+}
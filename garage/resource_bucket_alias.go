@@ -4,10 +4,14 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/url"
 	"reflect"
+	"sort"
 	"strings"
+	"time"
 
 	garage "git.deuxfleurs.fr/garage-sdk/garage-admin-sdk-golang"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
@@ -24,12 +28,15 @@ APIs used:
   - Remove: BucketAliasAPI.RemoveBucketAlias(ctx).RemoveBucketAliasRequest(NewRemoveBucketAliasRequest(...)).Execute()
   - Read:   BucketAPI.GetBucketInfo(ctx).Id(bucket_id).Execute()
 
-ID format:
+ID format (segments are URL path-escaped, so ':' in an alias becomes %3A):
   - global:<global_alias>
   - local:<access_key_id>:<local_alias>
+
+IDs written before escaping was introduced are still accepted.
 */
 
 func resourceBucketAlias() *schema.Resource {
+	annotate := withResourceDiagnostics("bucket alias", "bucket_id", "global_alias", "local_alias")
 	return &schema.Resource{
 		Description: "Manages a Garage bucket alias. An alias is an alternate name for a bucket, either global (cluster-wide) or local (scoped to an access key).",
 
@@ -68,6 +75,13 @@ func resourceBucketAlias() *schema.Resource {
 				Description:   "Access key ID to which the local alias is bound. Required when `local_alias` is specified.",
 			},
 
+			"validate_key_exists": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Look up `access_key_id` with `GetKeyInfo` before binding a local alias, failing with a clear error when the key does not exist. Adds one API call on create.",
+			},
+
 			"kind": {
 				Type:        schema.TypeString,
 				Computed:    true, // "global" or "local"
@@ -75,9 +89,11 @@ func resourceBucketAlias() *schema.Resource {
 			},
 		},
 
-		CreateContext: resourceBucketAliasCreate,
-		ReadContext:   resourceBucketAliasRead,
-		DeleteContext: resourceBucketAliasDelete,
+		CreateContext: annotate(resourceBucketAliasCreate),
+		ReadContext:   annotate(resourceBucketAliasRead),
+		// only validate_key_exists can change in place; it has no remote counterpart
+		UpdateContext: annotate(resourceBucketAliasRead),
+		DeleteContext: annotate(resourceBucketAliasDelete),
 
 		Importer: &schema.ResourceImporter{
 			// Accept import IDs in the form:
@@ -125,13 +141,21 @@ func resourceBucketAliasCreate(ctx context.Context, d *schema.ResourceData, m in
 			))
 		_, httpResp, err := req.Execute()
 		if err != nil {
+			if isAlreadyExists(err, httpResp) {
+				return alreadyExistsDiagnostics("garage_bucket_alias", globalAliasID(global), err, httpResp)
+			}
 			return createDiagnostics(err, httpResp)
 		}
-		d.SetId(fmt.Sprintf("global:%s", global))
+		d.SetId(globalAliasID(global))
 		_ = d.Set("kind", "global")
 
 	case local != "" && keyID != "":
 		// LOCAL alias
+		if d.Get("validate_key_exists").(bool) {
+			if diags := checkAccessKeyExists(ctx, p, keyID); len(diags) > 0 {
+				return diags
+			}
+		}
 		req := p.client.BucketAliasAPI.
 			AddBucketAlias(p.withToken(ctx)).
 			AddBucketAliasRequest(*garage.NewAddBucketAliasRequest(
@@ -142,9 +166,12 @@ func resourceBucketAliasCreate(ctx context.Context, d *schema.ResourceData, m in
 			))
 		_, httpResp, err := req.Execute()
 		if err != nil {
+			if isAlreadyExists(err, httpResp) {
+				return alreadyExistsDiagnostics("garage_bucket_alias", localAliasID(keyID, local), err, httpResp)
+			}
 			return createDiagnostics(err, httpResp)
 		}
-		d.SetId(fmt.Sprintf("local:%s:%s", keyID, local))
+		d.SetId(localAliasID(keyID, local))
 		_ = d.Set("kind", "local")
 
 	default:
@@ -158,6 +185,26 @@ func resourceBucketAliasCreate(ctx context.Context, d *schema.ResourceData, m in
 	return resourceBucketAliasRead(ctx, d, m)
 }
 
+// checkAccessKeyExists fails with "access key not found" when GetKeyInfo reports
+// the key as missing, instead of the opaque error AddBucketAlias would return.
+func checkAccessKeyExists(ctx context.Context, p *garageProvider, keyID string) diag.Diagnostics {
+	_, httpResp, err := p.client.AccessKeyAPI.
+		GetKeyInfo(p.withToken(ctx)).
+		Id(keyID).
+		Execute()
+	if err == nil {
+		return nil
+	}
+	if httpResp != nil && httpResp.StatusCode == http.StatusNotFound {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "access key not found",
+			Detail:   fmt.Sprintf("access_key_id %q does not exist; create the key before binding a local alias to it", keyID),
+		}}
+	}
+	return createDiagnostics(err, httpResp)
+}
+
 /* ---------------------------------- Read --------------------------------- */
 
 func resourceBucketAliasRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
@@ -169,10 +216,7 @@ func resourceBucketAliasRead(ctx context.Context, d *schema.ResourceData, m inte
 	kind, alias, keyID := parseAliasID(id, d)
 
 	// Fetch bucket info (use per-op context with token)
-	info, httpResp, err := p.client.BucketAPI.
-		GetBucketInfo(p.withToken(ctx)).
-		Id(bucketID).
-		Execute()
+	info, httpResp, err := p.getBucketInfo(ctx, bucketID)
 	if err != nil {
 		if httpResp != nil && httpResp.StatusCode == http.StatusNotFound {
 			d.SetId("")
@@ -181,8 +225,7 @@ func resourceBucketAliasRead(ctx context.Context, d *schema.ResourceData, m inte
 		return createDiagnostics(err, httpResp)
 	}
 	if info == nil {
-		d.SetId("")
-		return nil
+		return emptyResponseDiagnostics("GetBucketInfo")
 	}
 
 	switch kind {
@@ -203,7 +246,30 @@ func resourceBucketAliasRead(ctx context.Context, d *schema.ResourceData, m inte
 
 	case "local":
 		if keyID == "" || alias == "" {
-			d.SetId("")
+			// neither the ID nor state pins the alias down (e.g. an import with
+			// a bare alias name): look it up before giving up
+			name := alias
+			if name == "" {
+				name = aliasNameFromID(id)
+			}
+			rkind, rkey, ok := findAliasInBucketInfo(info, name)
+			if !ok {
+				d.SetId("")
+				return nil
+			}
+			tflog.Debug(ctx, "recovered bucket alias kind from bucket info", map[string]interface{}{
+				"id":   id,
+				"kind": rkind,
+			})
+			_ = d.Set("kind", rkind)
+			if rkind == "global" {
+				_ = d.Set("global_alias", name)
+				d.SetId(globalAliasID(name))
+			} else {
+				_ = d.Set("local_alias", name)
+				_ = d.Set("access_key_id", rkey)
+				d.SetId(localAliasID(rkey, name))
+			}
 			return nil
 		}
 		found := false
@@ -234,6 +300,13 @@ func resourceBucketAliasRead(ctx context.Context, d *schema.ResourceData, m inte
 
 /* -------------------------------- Delete --------------------------------- */
 
+// aliasRemoveAttempts and aliasRemoveBackoff bound the RemoveBucketAlias retries
+// on transient conflicts, e.g. while a cluster layout change is being applied.
+var (
+	aliasRemoveAttempts = 4
+	aliasRemoveBackoff  = 500 * time.Millisecond
+)
+
 func resourceBucketAliasDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	p := m.(*garageProvider)
 
@@ -242,21 +315,12 @@ func resourceBucketAliasDelete(ctx context.Context, d *schema.ResourceData, m in
 
 	switch kind {
 	case "global":
-		_, httpResp, err := p.client.BucketAliasAPI.
-			RemoveBucketAlias(p.withToken(ctx)).
-			RemoveBucketAliasRequest(*garage.NewRemoveBucketAliasRequest(
-				alias, // globalAlias
-				"",    // accessKeyId (unused)
-				"",    // localAlias (unused)
-				bucketID,
-			)).
-			Execute()
-		if err != nil {
-			if httpResp != nil && httpResp.StatusCode == http.StatusNotFound {
-				return nil
-			}
-			return createDiagnostics(err, httpResp)
-		}
+		return removeBucketAlias(ctx, p, *garage.NewRemoveBucketAliasRequest(
+			alias, // globalAlias
+			"",    // accessKeyId (unused)
+			"",    // localAlias (unused)
+			bucketID,
+		))
 
 	case "local":
 		// optional: guard against malformed ID
@@ -264,43 +328,84 @@ func resourceBucketAliasDelete(ctx context.Context, d *schema.ResourceData, m in
 			d.SetId("")
 			return nil
 		}
-		_, httpResp, err := p.client.BucketAliasAPI.
-			RemoveBucketAlias(p.withToken(ctx)).
-			RemoveBucketAliasRequest(*garage.NewRemoveBucketAliasRequest(
-				"",    // globalAlias (unused)
-				keyID, // accessKeyId
-				alias, // localAlias
-				bucketID,
-			)).
-			Execute()
-		if err != nil {
-			if httpResp != nil && httpResp.StatusCode == http.StatusNotFound {
-				return nil
-			}
-			return createDiagnostics(err, httpResp)
-		}
+		return removeBucketAlias(ctx, p, *garage.NewRemoveBucketAliasRequest(
+			"",    // globalAlias (unused)
+			keyID, // accessKeyId
+			alias, // localAlias
+			bucketID,
+		))
 
 	default:
 		// unknown kind -> treat as already gone
 		d.SetId("")
 		return nil
 	}
+}
 
-	return nil
+// removeBucketAlias removes an alias, treating 404 as already gone and retrying
+// 409 and 503 with backoff. Other errors are returned straight away.
+func removeBucketAlias(ctx context.Context, p *garageProvider, req garage.RemoveBucketAliasRequest) diag.Diagnostics {
+	backoff := aliasRemoveBackoff
+	for attempt := 1; ; attempt++ {
+		_, httpResp, err := p.client.BucketAliasAPI.
+			RemoveBucketAlias(p.withToken(ctx)).
+			RemoveBucketAliasRequest(req).
+			Execute()
+		if err == nil {
+			return nil
+		}
+		if httpResp != nil && httpResp.StatusCode == http.StatusNotFound {
+			return nil
+		}
+		transient := httpResp != nil &&
+			(httpResp.StatusCode == http.StatusConflict || httpResp.StatusCode == http.StatusServiceUnavailable)
+		if !transient || attempt >= aliasRemoveAttempts {
+			return createDiagnostics(err, httpResp)
+		}
+		tflog.Debug(ctx, "retrying bucket alias removal", map[string]interface{}{
+			"attempt": attempt,
+			"status":  httpResp.StatusCode,
+		})
+
+		select {
+		case <-ctx.Done():
+			return diag.FromErr(ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
 }
 
 /* ------------------------------- helpers --------------------------------- */
 
+func globalAliasID(alias string) string {
+	return "global:" + escapeAliasIDSegment(alias)
+}
+
+func localAliasID(keyID, alias string) string {
+	return "local:" + escapeAliasIDSegment(keyID) + ":" + escapeAliasIDSegment(alias)
+}
+
+// escapeAliasIDSegment path-escapes a segment; PathEscape keeps ':' so it is escaped explicitly.
+func escapeAliasIDSegment(s string) string {
+	return strings.ReplaceAll(url.PathEscape(s), ":", "%3A")
+}
+
 // parseAliasID extracts kind/alias/keyID from the Terraform ID, with state fallback.
+// Access key IDs never contain ':', so for local IDs everything after the second
+// separator is the alias; this keeps unescaped IDs with colons in the alias working.
 func parseAliasID(id string, d *schema.ResourceData) (kind, alias, keyID string) {
 	if strings.HasPrefix(id, "global:") {
-		return "global", strings.TrimPrefix(id, "global:"), ""
+		raw := strings.TrimPrefix(id, "global:")
+		return "global", decodeAliasIDSegment(raw, stateString(d, "global_alias")), ""
 	}
 	if strings.HasPrefix(id, "local:") {
 		rest := strings.TrimPrefix(id, "local:")
 		parts := strings.SplitN(rest, ":", 2)
 		if len(parts) == 2 {
-			return "local", parts[1], parts[0]
+			keyID = decodeAliasIDSegment(parts[0], stateString(d, "access_key_id"))
+			alias = decodeAliasIDSegment(parts[1], stateString(d, "local_alias"))
+			return "local", alias, keyID
 		}
 	}
 	// Fallback: infer from state
@@ -310,6 +415,68 @@ func parseAliasID(id string, d *schema.ResourceData) (kind, alias, keyID string)
 	return "local", d.Get("local_alias").(string), d.Get("access_key_id").(string)
 }
 
+// aliasNameFromID returns the alias name carried by an ID that parseAliasID
+// could not resolve: a bare alias, or the last segment after a known prefix.
+func aliasNameFromID(id string) string {
+	for _, prefix := range []string{"global:", "local:"} {
+		if strings.HasPrefix(id, prefix) {
+			rest := strings.TrimPrefix(id, prefix)
+			id = rest[strings.LastIndex(rest, ":")+1:]
+			break
+		}
+	}
+	return decodeAliasIDSegment(id, "")
+}
+
+// findAliasInBucketInfo looks name up as a global alias, then as a local alias
+// of any key on the bucket. A local alias held by several keys is ambiguous and
+// reported as not found.
+func findAliasInBucketInfo(info *garage.GetBucketInfoResponse, name string) (kind, keyID string, ok bool) {
+	if name == "" {
+		return "", "", false
+	}
+	for _, ga := range info.GetGlobalAliases() {
+		if ga == name {
+			return "global", "", true
+		}
+	}
+	for _, k := range info.GetKeys() {
+		if !keyHasLocalAlias(k, name) {
+			continue
+		}
+		if keyID != "" {
+			return "", "", false
+		}
+		keyID = k.GetAccessKeyId()
+	}
+	if keyID == "" {
+		return "", "", false
+	}
+	return "local", keyID, true
+}
+
+// decodeAliasIDSegment unescapes an ID segment. Old-format IDs stored the raw value,
+// so a segment that fails to unescape, or that matches the value in state verbatim,
+// is returned as-is.
+func decodeAliasIDSegment(raw, stateVal string) string {
+	if raw == stateVal {
+		return raw
+	}
+	decoded, err := url.PathUnescape(raw)
+	if err != nil {
+		return raw
+	}
+	return decoded
+}
+
+func stateString(d *schema.ResourceData, key string) string {
+	if d == nil {
+		return ""
+	}
+	s, _ := d.Get(key).(string)
+	return s
+}
+
 func validateBucketAliasInputs(global, local, keyID string) error {
 	hasGlobal := global != ""
 	hasLocal := local != "" || keyID != ""
@@ -358,30 +525,41 @@ func keyMatchesAccessKeyID(key interface{}, want string) bool {
 }
 
 // keyHasLocalAlias returns true if the GetBucketInfoKey contains the given local alias.
-// Supports common shapes: []string, map[string]bool/map[string]string, or []struct{ Alias string }.
 func keyHasLocalAlias(key interface{}, alias string) bool {
+	for _, a := range keyLocalAliases(key) {
+		if a == alias {
+			return true
+		}
+	}
+	return false
+}
+
+// keyLocalAliases returns the local aliases held by a GetBucketInfoKey.
+// Supports common shapes: []string, map[string]bool/map[string]string, or []struct{ Alias string }.
+func keyLocalAliases(key interface{}) []string {
 	rv := reflect.ValueOf(key)
 	if rv.Kind() == reflect.Pointer {
 		rv = rv.Elem()
 	}
 	if rv.Kind() != reflect.Struct {
-		return false
+		return nil
 	}
 
 	// Try likely field names holding local aliases
 	fields := []string{
-		"LocalAliases", "Aliases", "LocalAlias", "LocalAliasesList", "LocalAliasesMap",
+		"BucketLocalAliases", "LocalAliases", "Aliases", "LocalAlias", "LocalAliasesList", "LocalAliasesMap",
 	}
 
+	var out []string
 	for _, name := range fields {
 		f := rv.FieldByName(name)
 		if !f.IsValid() {
 			// Try Get<name>() getter
 			getter := rv.MethodByName("Get" + name)
 			if getter.IsValid() && getter.Type().NumIn() == 0 && getter.Type().NumOut() == 1 {
-				out := getter.Call(nil)
-				if len(out) == 1 {
-					f = out[0]
+				res := getter.Call(nil)
+				if len(res) == 1 {
+					f = res[0]
 				}
 			}
 		}
@@ -395,20 +573,23 @@ func keyHasLocalAlias(key interface{}, alias string) bool {
 				elem := f.Index(i)
 				switch elem.Kind() {
 				case reflect.String:
-					if elem.String() == alias {
-						return true
-					}
+					out = append(out, elem.String())
 				case reflect.Struct:
+					found := false
 					for _, an := range []string{"Alias", "Name"} {
 						af := elem.FieldByName(an)
-						if af.IsValid() && af.Kind() == reflect.String && af.String() == alias {
-							return true
+						if af.IsValid() && af.Kind() == reflect.String {
+							out = append(out, af.String())
+							found = true
+							break
 						}
 					}
 					// Try getters on element
-					if m := elem.Addr().MethodByName("GetAlias"); m.IsValid() && m.Type().NumIn() == 0 && m.Type().NumOut() == 1 {
-						if out := m.Call(nil); len(out) == 1 && out[0].Kind() == reflect.String && out[0].String() == alias {
-							return true
+					if !found && elem.CanAddr() {
+						if m := elem.Addr().MethodByName("GetAlias"); m.IsValid() && m.Type().NumIn() == 0 && m.Type().NumOut() == 1 {
+							if res := m.Call(nil); len(res) == 1 && res[0].Kind() == reflect.String {
+								out = append(out, res[0].String())
+							}
 						}
 					}
 				}
@@ -416,12 +597,16 @@ func keyHasLocalAlias(key interface{}, alias string) bool {
 
 		case reflect.Map:
 			for _, mk := range f.MapKeys() {
-				if mk.Kind() == reflect.String && mk.String() == alias {
-					return true
+				if mk.Kind() == reflect.String {
+					out = append(out, mk.String())
 				}
 			}
+			sort.Strings(out)
+		}
+		if len(out) > 0 {
+			return out
 		}
 	}
 
-	return false
+	return out
 }
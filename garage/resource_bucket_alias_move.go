@@ -0,0 +1,189 @@
+package garage
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	garage "git.deuxfleurs.fr/garage-sdk/garage-admin-sdk-golang"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+/*
+Resource: garage_bucket_alias_move
+
+Moves a global alias from one bucket to another in a single resource:
+  - the alias is first added to to_bucket_id, then removed from from_bucket_id;
+  - Garage keeps global aliases unique and refuses that dual binding, so in
+    practice the alias is removed from from_bucket_id and then added to
+    to_bucket_id, and put back on from_bucket_id if that add fails. The move is
+    therefore not atomic: the alias is briefly unbound.
+
+APIs used:
+  - Lookup: BucketAPI.GetBucketInfo(ctx).GlobalAlias(alias).Execute()
+  - Add:    BucketAliasAPI.AddBucketAlias(ctx)
+  - Remove: BucketAliasAPI.RemoveBucketAlias(ctx)
+
+Destroying the resource leaves the alias where it is.
+
+ID format: global:<alias> (URL path-escaped, as for garage_bucket_alias)
+*/
+
+func resourceBucketAliasMove() *schema.Resource {
+	annotate := withResourceDiagnostics("bucket alias move", "alias")
+	return &schema.Resource{
+		Description: "Moves a global alias from one bucket to another. Garage refuses to bind a global alias to a second bucket, so the move removes the alias from `from_bucket_id` and then adds it to `to_bucket_id`. It is not atomic: the alias resolves to no bucket in between, and is put back on `from_bucket_id` if the add fails. Destroying the resource leaves the alias on `to_bucket_id`.",
+
+		Schema: map[string]*schema.Schema{
+			"alias": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Global alias to move.",
+			},
+			"from_bucket_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "ID of the bucket (UUID) the alias is currently bound to.",
+			},
+			"to_bucket_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "ID of the bucket (UUID) the alias should point to.",
+			},
+		},
+
+		CreateContext: annotate(resourceBucketAliasMoveCreate),
+		ReadContext:   annotate(resourceBucketAliasMoveRead),
+		DeleteContext: annotate(resourceBucketAliasMoveDelete),
+	}
+}
+
+func resourceBucketAliasMoveCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	p := m.(*garageProvider)
+
+	alias := d.Get("alias").(string)
+	from := d.Get("from_bucket_id").(string)
+	to := d.Get("to_bucket_id").(string)
+
+	current, diags := globalAliasBucketID(ctx, p, alias)
+	if len(diags) > 0 {
+		return diags
+	}
+
+	switch current {
+	case to:
+		// already moved, e.g. a re-create after the state was lost
+	case "":
+		if diags := addGlobalAlias(ctx, p, alias, to); len(diags) > 0 {
+			return diags
+		}
+	case from:
+		if diags := moveGlobalAlias(ctx, p, alias, from, to); len(diags) > 0 {
+			return diags
+		}
+	default:
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "alias bound to another bucket",
+			Detail:   fmt.Sprintf("global alias %q points to bucket %q, not from_bucket_id %q", alias, current, from),
+		}}
+	}
+
+	d.SetId(globalAliasID(alias))
+	return resourceBucketAliasMoveRead(ctx, d, m)
+}
+
+// moveGlobalAlias binds alias to the bucket to, then unbinds it from the bucket
+// from. Garage keeps global aliases unique, so when the add is refused the alias
+// is removed first and restored on from should the add still fail.
+func moveGlobalAlias(ctx context.Context, p *garageProvider, alias, from, to string) diag.Diagnostics {
+	_, httpResp, err := p.client.BucketAliasAPI.
+		AddBucketAlias(p.withToken(ctx)).
+		AddBucketAliasRequest(*garage.NewAddBucketAliasRequest(alias, "", "", to)).
+		Execute()
+	if err == nil {
+		return removeBucketAlias(ctx, p, *garage.NewRemoveBucketAliasRequest(alias, "", "", from))
+	}
+	if !isAlreadyExists(err, httpResp) {
+		return createDiagnostics(err, httpResp)
+	}
+
+	tflog.Debug(ctx, "dual binding refused, moving alias by remove then add", map[string]interface{}{
+		"alias": alias,
+		"from":  from,
+		"to":    to,
+	})
+	if diags := removeBucketAlias(ctx, p, *garage.NewRemoveBucketAliasRequest(alias, "", "", from)); len(diags) > 0 {
+		return diags
+	}
+	diags := addGlobalAlias(ctx, p, alias, to)
+	if len(diags) == 0 {
+		return nil
+	}
+	if restore := addGlobalAlias(ctx, p, alias, from); len(restore) > 0 {
+		return append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "alias left unbound",
+			Detail:   fmt.Sprintf("global alias %q was removed from bucket %q but could not be added to %q nor restored; add it back manually", alias, from, to),
+		})
+	}
+	return diags
+}
+
+func addGlobalAlias(ctx context.Context, p *garageProvider, alias, bucketID string) diag.Diagnostics {
+	_, httpResp, err := p.client.BucketAliasAPI.
+		AddBucketAlias(p.withToken(ctx)).
+		AddBucketAliasRequest(*garage.NewAddBucketAliasRequest(alias, "", "", bucketID)).
+		Execute()
+	if err != nil {
+		return createDiagnostics(err, httpResp)
+	}
+	return nil
+}
+
+// globalAliasBucketID returns the ID of the bucket a global alias points to,
+// or "" when the alias is not bound.
+func globalAliasBucketID(ctx context.Context, p *garageProvider, alias string) (string, diag.Diagnostics) {
+	info, httpResp, err := p.client.BucketAPI.
+		GetBucketInfo(p.withToken(ctx)).
+		GlobalAlias(alias).
+		Execute()
+	if err != nil {
+		if httpResp != nil && httpResp.StatusCode == http.StatusNotFound {
+			return "", nil
+		}
+		return "", createDiagnostics(err, httpResp)
+	}
+	if info == nil {
+		return "", nil
+	}
+	return info.Id, nil
+}
+
+func resourceBucketAliasMoveRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	p := m.(*garageProvider)
+
+	current, diags := globalAliasBucketID(ctx, p, d.Get("alias").(string))
+	if len(diags) > 0 {
+		return diags
+	}
+	if current == "" {
+		d.SetId("")
+		return nil
+	}
+	// an alias moved elsewhere outside Terraform shows up as a diff on to_bucket_id
+	_ = d.Set("to_bucket_id", current)
+	return nil
+}
+
+// resourceBucketAliasMoveDelete only drops the resource from state; the alias
+// stays on to_bucket_id.
+func resourceBucketAliasMoveDelete(_ context.Context, d *schema.ResourceData, _ interface{}) diag.Diagnostics {
+	d.SetId("")
+	return nil
+}
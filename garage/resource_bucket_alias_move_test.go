@@ -0,0 +1,126 @@
+package garage
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// aliasMoveRoundTripper simulates a global alias "site" bound to *owner.
+// With refuseDual, adding the alias to a second bucket fails with a conflict
+// as long as it is still bound elsewhere. Every request is recorded as
+// "<path>" or "<path>:<bucketId>".
+func aliasMoveRoundTripper(t *testing.T, calls *[]string, owner *string, refuseDual bool) keyRoundTripper {
+	t.Helper()
+	return func(r *http.Request) (*http.Response, error) {
+		var body struct {
+			BucketID string `json:"bucketId"`
+		}
+		if r.Body != nil {
+			raw, _ := io.ReadAll(r.Body)
+			r.Body.Close()
+			_ = json.Unmarshal(raw, &body)
+		}
+		call := r.URL.Path
+		if body.BucketID != "" {
+			call += ":" + body.BucketID
+		}
+		*calls = append(*calls, call)
+
+		status := http.StatusOK
+		var payload string
+		switch r.URL.Path {
+		case "/v2/GetBucketInfo":
+			if r.URL.Query().Get("globalAlias") != "site" {
+				t.Fatalf("expected lookup by global alias, got %s", r.URL.RawQuery)
+			}
+			payload = bucketInfoJSON(*owner, []string{"site"}, 0)
+		case "/v2/AddBucketAlias":
+			if refuseDual && *owner != "" && *owner != body.BucketID {
+				status = http.StatusConflict
+				payload = `{"message":"Bucket alias site already exists"}`
+			} else {
+				*owner = body.BucketID
+				payload = bucketInfoJSON(body.BucketID, []string{"site"}, 0)
+			}
+		case "/v2/RemoveBucketAlias":
+			if *owner == body.BucketID {
+				*owner = ""
+			}
+			payload = bucketInfoJSON(body.BucketID, []string{}, 0)
+		default:
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+		return &http.Response{
+			StatusCode: status,
+			Status:     http.StatusText(status),
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(payload)),
+		}, nil
+	}
+}
+
+func newAliasMoveData(t *testing.T) *schema.ResourceData {
+	return schema.TestResourceDataRaw(t, resourceBucketAliasMove().Schema, map[string]interface{}{
+		"alias":          "site",
+		"from_bucket_id": "bucket-a",
+		"to_bucket_id":   "bucket-b",
+	})
+}
+
+func TestResourceBucketAliasMoveCreateAddsBeforeRemoving(t *testing.T) {
+	var calls []string
+	owner := "bucket-a"
+	p := newTestProvider(aliasMoveRoundTripper(t, &calls, &owner, false))
+
+	d := newAliasMoveData(t)
+	if diags := resourceBucketAliasMoveCreate(context.Background(), d, p); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+
+	want := "/v2/GetBucketInfo,/v2/AddBucketAlias:bucket-b,/v2/RemoveBucketAlias:bucket-a,/v2/GetBucketInfo"
+	if got := strings.Join(calls, ","); got != want {
+		t.Fatalf("unexpected call sequence\n got: %s\nwant: %s", got, want)
+	}
+	if d.Id() != "global:site" || d.Get("to_bucket_id").(string) != "bucket-b" {
+		t.Fatalf("unexpected state id=%q to_bucket_id=%q", d.Id(), d.Get("to_bucket_id"))
+	}
+}
+
+func TestResourceBucketAliasMoveCreateFallsBackWhenDualBindingRefused(t *testing.T) {
+	var calls []string
+	owner := "bucket-a"
+	p := newTestProvider(aliasMoveRoundTripper(t, &calls, &owner, true))
+
+	d := newAliasMoveData(t)
+	if diags := resourceBucketAliasMoveCreate(context.Background(), d, p); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+
+	want := "/v2/GetBucketInfo,/v2/AddBucketAlias:bucket-b,/v2/RemoveBucketAlias:bucket-a,/v2/AddBucketAlias:bucket-b,/v2/GetBucketInfo"
+	if got := strings.Join(calls, ","); got != want {
+		t.Fatalf("unexpected call sequence\n got: %s\nwant: %s", got, want)
+	}
+	if owner != "bucket-b" {
+		t.Fatalf("expected alias on bucket-b, got %q", owner)
+	}
+}
+
+func TestResourceBucketAliasMoveCreateRejectsUnexpectedOwner(t *testing.T) {
+	var calls []string
+	owner := "bucket-c"
+	p := newTestProvider(aliasMoveRoundTripper(t, &calls, &owner, true))
+
+	diags := resourceBucketAliasMoveCreate(context.Background(), newAliasMoveData(t), p)
+	if !diags.HasError() || !strings.Contains(diags[0].Detail, "bucket-c") {
+		t.Fatalf("expected error naming the current bucket, got %#v", diags)
+	}
+	if len(calls) != 1 {
+		t.Fatalf("expected no change requests, got %v", calls)
+	}
+}
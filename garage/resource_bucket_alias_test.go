@@ -51,6 +51,49 @@ func TestParseAliasID(t *testing.T) {
 	}
 }
 
+func TestAliasIDRoundTripSpecialCharacters(t *testing.T) {
+	res := resourceBucketAlias()
+	data := schema.TestResourceDataRaw(t, res.Schema, map[string]interface{}{})
+
+	for _, alias := range []string{"a:b", "with space", "pct%41", "slash/and?query", "ünïcode", "trailing:"} {
+		id := globalAliasID(alias)
+		if kind, got, key := parseAliasID(id, data); kind != "global" || got != alias || key != "" {
+			t.Fatalf("global %q: id %q parsed to %#v %#v %#v", alias, id, kind, got, key)
+		}
+
+		id = localAliasID("GK123", alias)
+		if strings.Count(id, ":") != 2 {
+			t.Fatalf("expected escaped local id to contain exactly two separators, got %q", id)
+		}
+		if kind, got, key := parseAliasID(id, data); kind != "local" || got != alias || key != "GK123" {
+			t.Fatalf("local %q: id %q parsed to %#v %#v %#v", alias, id, kind, got, key)
+		}
+	}
+}
+
+func TestParseAliasIDOldFormat(t *testing.T) {
+	res := resourceBucketAlias()
+	data := schema.TestResourceDataRaw(t, res.Schema, map[string]interface{}{})
+
+	// unescaped colon in the alias of a pre-escaping local ID
+	if kind, alias, key := parseAliasID("local:GK123:team:logs", data); kind != "local" || alias != "team:logs" || key != "GK123" {
+		t.Fatalf("unexpected parse result %#v %#v %#v", kind, alias, key)
+	}
+
+	// malformed escape sequence is kept verbatim
+	if _, alias, _ := parseAliasID("global:100%", data); alias != "100%" {
+		t.Fatalf("expected raw alias for invalid escape, got %#v", alias)
+	}
+
+	// old ID whose alias happens to look escaped is matched against state
+	old := schema.TestResourceDataRaw(t, res.Schema, map[string]interface{}{
+		"global_alias": "literal%41",
+	})
+	if _, alias, _ := parseAliasID("global:literal%41", old); alias != "literal%41" {
+		t.Fatalf("expected old-format alias to match state, got %#v", alias)
+	}
+}
+
 func TestResourceBucketAliasCustomizeDiffValid(t *testing.T) {
 	resource := resourceBucketAlias()
 
@@ -267,6 +310,33 @@ func TestResourceBucketAliasCreateLocal(t *testing.T) {
 	}
 }
 
+func TestResourceBucketAliasCreateLocalMissingKey(t *testing.T) {
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path != "/v2/GetKeyInfo" {
+			t.Fatalf("unexpected request %s", r.URL.Path)
+		}
+		return &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found", Body: io.NopCloser(strings.NewReader(`{"code":"NoSuchAccessKey","message":"no such key"}`)), Header: http.Header{"Content-Type": []string{"application/json"}}}, nil
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourceBucketAlias().Schema, map[string]interface{}{
+		"bucket_id":           "bucket",
+		"local_alias":         "local",
+		"access_key_id":       "GKmissing",
+		"validate_key_exists": true,
+	})
+
+	diags := resourceBucketAliasCreate(context.Background(), d, p)
+	if len(diags) != 1 || diags[0].Summary != "access key not found" {
+		t.Fatalf("expected access key not found diagnostic, got %#v", diags)
+	}
+	if !strings.Contains(diags[0].Detail, "GKmissing") {
+		t.Fatalf("expected key id in detail, got %q", diags[0].Detail)
+	}
+	if d.Id() != "" {
+		t.Fatalf("expected no ID to be set, got %q", d.Id())
+	}
+}
+
 func TestResourceBucketAliasCreateError(t *testing.T) {
 	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
 		return &http.Response{StatusCode: http.StatusInternalServerError, Status: "500 Internal Server Error", Body: io.NopCloser(strings.NewReader("boom")), Header: make(http.Header)}, nil
@@ -283,6 +353,31 @@ func TestResourceBucketAliasCreateError(t *testing.T) {
 	}
 }
 
+func TestResourceBucketAliasCreateConflictSuggestsImport(t *testing.T) {
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path != "/v2/AddBucketAlias" {
+			t.Fatalf("unexpected request %s", r.URL.Path)
+		}
+		return &http.Response{StatusCode: http.StatusConflict, Status: "409 Conflict", Body: io.NopCloser(strings.NewReader(`{"code":"BucketAlreadyExists","message":"alias already exists"}`)), Header: http.Header{"Content-Type": []string{"application/json"}}}, nil
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourceBucketAlias().Schema, map[string]interface{}{
+		"bucket_id":    "bucket",
+		"global_alias": "site",
+	})
+
+	diags := resourceBucketAliasCreate(context.Background(), d, p)
+	if len(diags) != 1 || diags[0].Summary != "garage_bucket_alias already exists" {
+		t.Fatalf("expected already exists diagnostic, got %#v", diags)
+	}
+	if !strings.Contains(diags[0].Detail, "terraform import garage_bucket_alias.<name> global:site") {
+		t.Fatalf("expected import command in detail, got %q", diags[0].Detail)
+	}
+	if !strings.Contains(diags[0].Detail, "alias already exists") {
+		t.Fatalf("expected API message in detail, got %q", diags[0].Detail)
+	}
+}
+
 func TestResourceBucketAliasReadGlobal(t *testing.T) {
 	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
 		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(aliasBucketInfoPayload("bucket", []string{"alias"}, "", "", nil)))}, nil
@@ -374,6 +469,56 @@ func TestResourceBucketAliasReadLocalMalformedID(t *testing.T) {
 	}
 }
 
+func TestResourceBucketAliasReadRecoversAmbiguousGlobal(t *testing.T) {
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(aliasBucketInfoPayload("bucket", []string{"site"}, "GK1", "key", []string{"other"})))}, nil
+	}))
+
+	// bare alias name, as left behind by an import without a kind prefix
+	d := schema.TestResourceDataRaw(t, resourceBucketAlias().Schema, map[string]interface{}{
+		"bucket_id": "bucket",
+	})
+	d.SetId("site")
+
+	diags := resourceBucketAliasRead(context.Background(), d, p)
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	if d.Id() != "global:site" {
+		t.Fatalf("expected recovered global id, got %q", d.Id())
+	}
+	if d.Get("kind").(string) != "global" || d.Get("global_alias").(string) != "site" {
+		t.Fatalf("unexpected recovered state kind=%q global_alias=%q", d.Get("kind"), d.Get("global_alias"))
+	}
+}
+
+func TestFindAliasInBucketInfo(t *testing.T) {
+	info := &garageapi.GetBucketInfoResponse{
+		GlobalAliases: []string{"site"},
+		Keys: []garageapi.GetBucketInfoKey{
+			{AccessKeyId: "GK1", BucketLocalAliases: []string{"mine", "shared"}},
+			{AccessKeyId: "GK2", BucketLocalAliases: []string{"shared"}},
+		},
+	}
+
+	cases := []struct {
+		name, kind, keyID string
+		ok                bool
+	}{
+		{name: "site", kind: "global", ok: true},
+		{name: "mine", kind: "local", keyID: "GK1", ok: true},
+		{name: "shared"},
+		{name: "absent"},
+		{name: ""},
+	}
+	for _, tc := range cases {
+		kind, keyID, ok := findAliasInBucketInfo(info, tc.name)
+		if kind != tc.kind || keyID != tc.keyID || ok != tc.ok {
+			t.Fatalf("%q: got %q %q %v", tc.name, kind, keyID, ok)
+		}
+	}
+}
+
 func TestResourceBucketAliasReadBucketNotFound(t *testing.T) {
 	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
 		return &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found", Body: io.NopCloser(strings.NewReader("")), Header: make(http.Header)}, nil
@@ -419,6 +564,66 @@ func TestResourceBucketAliasDeleteGlobal(t *testing.T) {
 	}
 }
 
+func TestResourceBucketAliasDeleteRetriesTransientError(t *testing.T) {
+	origBackoff := aliasRemoveBackoff
+	aliasRemoveBackoff = time.Millisecond
+	t.Cleanup(func() { aliasRemoveBackoff = origBackoff })
+
+	calls := 0
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path != "/v2/RemoveBucketAlias" {
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+		calls++
+		if calls == 1 {
+			return &http.Response{StatusCode: http.StatusServiceUnavailable, Status: "503 Service Unavailable", Header: make(http.Header), Body: io.NopCloser(strings.NewReader("layout change in progress"))}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(aliasBucketInfoPayload("bucket", []string{}, "", "", nil)))}, nil
+	}))
+
+	res := resourceBucketAlias()
+	state := &terraform.InstanceState{
+		ID: "global:alias",
+		Attributes: map[string]string{
+			"id":           "global:alias",
+			"bucket_id":    "bucket",
+			"global_alias": "alias",
+			"kind":         "global",
+		},
+	}
+	newState, diags := res.Apply(context.Background(), state, &terraform.InstanceDiff{Destroy: true}, p)
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	if newState != nil && newState.ID != "" {
+		t.Fatalf("expected resource to be removed from state, got %q", newState.ID)
+	}
+	if calls != 2 {
+		t.Fatalf("expected one retry, got %d calls", calls)
+	}
+}
+
+func TestResourceBucketAliasDeletePermanentErrorNotRetried(t *testing.T) {
+	calls := 0
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		calls++
+		return &http.Response{StatusCode: http.StatusBadRequest, Status: "400 Bad Request", Header: make(http.Header), Body: io.NopCloser(strings.NewReader("bad request"))}, nil
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourceBucketAlias().Schema, map[string]interface{}{
+		"bucket_id":    "bucket",
+		"global_alias": "alias",
+	})
+	d.SetId("global:alias")
+
+	if diags := resourceBucketAliasDelete(context.Background(), d, p); len(diags) == 0 {
+		t.Fatalf("expected diagnostics for permanent error")
+	}
+	if calls != 1 {
+		t.Fatalf("expected no retry for a permanent error, got %d calls", calls)
+	}
+}
+
 func TestResourceBucketAliasDeleteLocal(t *testing.T) {
 	removed := false
 	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
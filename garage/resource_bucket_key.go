@@ -4,8 +4,11 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	garage "git.deuxfleurs.fr/garage-sdk/garage-admin-sdk-golang"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
@@ -20,14 +23,57 @@ func (p bucketKeyPermissions) any() bool {
 	return p.Owner || p.Read || p.Write
 }
 
+// sources maps each permission to "explicit", "implied" or "none". Owner gives
+// full control of the bucket, so read and write not granted themselves are
+// implied by it.
+func (p bucketKeyPermissions) sources() map[string]string {
+	source := func(granted bool) string {
+		switch {
+		case granted:
+			return "explicit"
+		case p.Owner:
+			return "implied"
+		}
+		return "none"
+	}
+	owner := "none"
+	if p.Owner {
+		owner = "explicit"
+	}
+	return map[string]string{
+		"read":  source(p.Read),
+		"write": source(p.Write),
+		"owner": owner,
+	}
+}
+
+// String lists the granted permissions, e.g. "read, owner", or "none".
+func (p bucketKeyPermissions) String() string {
+	var names []string
+	if p.Read {
+		names = append(names, "read")
+	}
+	if p.Write {
+		names = append(names, "write")
+	}
+	if p.Owner {
+		names = append(names, "owner")
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
+
 // resourceBucketKey manages permissions granted to an access key on a bucket.
 func resourceBucketKey() *schema.Resource {
+	annotate := withResourceDiagnostics("bucket key", "bucket_id", "access_key_id")
 	return &schema.Resource{
 		Description:   "Manage permissions granted to an access key on a Garage bucket.",
-		CreateContext: resourceBucketKeyCreate,
-		ReadContext:   resourceBucketKeyRead,
-		UpdateContext: resourceBucketKeyUpdate,
-		DeleteContext: resourceBucketKeyDelete,
+		CreateContext: annotate(resourceBucketKeyCreate),
+		ReadContext:   annotate(resourceBucketKeyRead),
+		UpdateContext: annotate(resourceBucketKeyUpdate),
+		DeleteContext: annotate(resourceBucketKeyDelete),
 		Schema: map[string]*schema.Schema{
 			"bucket_id": {
 				Type:        schema.TypeString,
@@ -59,24 +105,68 @@ func resourceBucketKey() *schema.Resource {
 				Default:     false,
 				Description: "Grant owner permissions on the bucket (full administrative control).",
 			},
+			"assume_no_existing_permissions": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Skip reading current permissions on create and grant the desired ones directly. Only safe when the key has no existing permissions on the bucket; `key_name` is filled on the next refresh.",
+			},
+			"prevent_orphan_owner": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Fail instead of warn when revoking `owner` (on update or destroy) would leave the bucket without any owning key.",
+			},
+			"reapply_trigger": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Arbitrary value; changing it re-reads the key's permissions on the bucket and re-grants or revokes them to match `read`, `write` and `owner`, even when those are unchanged. Use it to repair drift made outside Terraform.",
+			},
+			"verify_timeout_seconds": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validateNonNegativeInt,
+				Description:  "After granting or revoking permissions, re-read them until they match `read`, `write` and `owner`, failing after this many seconds. `0` (the default) disables the check.",
+			},
 			"key_name": {
 				Type:        schema.TypeString,
 				Computed:    true,
 				Description: "Human-friendly name of the access key, if available.",
 			},
+			"permissions_source": {
+				Type:        schema.TypeMap,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Computed:    true,
+				Description: "Where each of `read`, `write` and `owner` comes from: `explicit` when the key is granted it on the bucket, `implied` for `read` and `write` not granted themselves but covered by `owner`, and `none` otherwise.",
+			},
 		},
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			// Accept import IDs in the form <bucket_id>:<access_key_id>
+			StateContext: resourceBucketKeyImport,
 		},
 		CustomizeDiff: func(ctx context.Context, d *schema.ResourceDiff, _ interface{}) error {
 			perms := bucketKeyPermissions{
-				Read:  d.Get("read").(bool),
-				Write: d.Get("write").(bool),
-				Owner: d.Get("owner").(bool),
+				Read:  asBool(d.Get("read")),
+				Write: asBool(d.Get("write")),
+				Owner: asBool(d.Get("owner")),
 			}
 			if !perms.any() {
 				return fmt.Errorf("at least one of read, write, or owner must be true")
 			}
+			// bucket IDs are hex, so a GK prefix can only be an access key ID
+			if bucketID := d.Get("bucket_id").(string); strings.HasPrefix(bucketID, accessKeyIDPrefix) {
+				return fmt.Errorf("bucket_id %q looks like an access key ID; bucket_id and access_key_id may be swapped", bucketID)
+			}
+			if perms.Owner {
+				if denied := explicitlyDisabled(d, "read", "write"); len(denied) > 0 {
+					tflog.Warn(ctx, "owner grants full access to the bucket; read/write set to false have no effect", map[string]interface{}{
+						"bucket_id":     d.Get("bucket_id"),
+						"access_key_id": d.Get("access_key_id"),
+						"disabled":      denied,
+					})
+				}
+			}
 			return nil
 		},
 	}
@@ -96,25 +186,79 @@ func resourceBucketKeyCreate(ctx context.Context, d *schema.ResourceData, m inte
 	bucketID := d.Get("bucket_id").(string)
 	keyID := d.Get("access_key_id").(string)
 
+	// direct-grant path: no pre-fetch and no trailing read, only AllowBucketKey
+	if d.Get("assume_no_existing_permissions").(bool) {
+		if diags := applyBucketKeyChanges(ctx, p, bucketID, keyID, bucketKeyPermissions{}, desired); len(diags) > 0 {
+			return diags
+		}
+		d.SetId(fmt.Sprintf("%s:%s", bucketID, keyID))
+		return verifyBucketKeyPermissions(ctx, d, p, desired)
+	}
+
 	if diags := ensureBucketKeyPermissions(ctx, p, bucketID, keyID, desired); len(diags) > 0 {
 		return diags
 	}
 
 	d.SetId(fmt.Sprintf("%s:%s", bucketID, keyID))
+	if diags := verifyBucketKeyPermissions(ctx, d, p, desired); len(diags) > 0 {
+		return diags
+	}
 	return resourceBucketKeyRead(ctx, d, m)
 }
 
+// accessKeyIDPrefix starts every Garage access key ID.
+const accessKeyIDPrefix = "GK"
+
+// bucketNotFoundDiagnostics reports a missing bucket_id, pointing out a likely
+// swap with access_key_id when the two values resolve the other way round.
+func bucketNotFoundDiagnostics(ctx context.Context, p *garageProvider, bucketID, keyID string) diag.Diagnostics {
+	if hint := bucketKeySwapHint(ctx, p, bucketID, keyID); hint != "" {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "bucket_id and access_key_id look swapped",
+			Detail:   fmt.Sprintf("no bucket has ID %q, but %s. Set bucket_id to the bucket ID and access_key_id to the access key ID.", bucketID, hint),
+		}}
+	}
+	return diag.Diagnostics{{
+		Severity: diag.Error,
+		Summary:  "bucket not found",
+		Detail:   fmt.Sprintf("bucket %q does not exist", bucketID),
+	}}
+}
+
+// bucketKeySwapHint describes the evidence that bucket_id and access_key_id were
+// swapped: keyID names a bucket or bucketID names an access key. It returns ""
+// when neither lookup succeeds; lookup errors count as no evidence.
+func bucketKeySwapHint(ctx context.Context, p *garageProvider, bucketID, keyID string) string {
+	if info, diags := fetchBucketInfo(ctx, p, keyID); len(diags) == 0 && info != nil {
+		return fmt.Sprintf("access_key_id %q is a bucket ID", keyID)
+	}
+	key, _, err := p.client.AccessKeyAPI.
+		GetKeyInfo(p.withToken(ctx)).
+		Id(bucketID).
+		Execute()
+	if err == nil && key != nil {
+		return fmt.Sprintf("bucket_id %q is an access key ID", bucketID)
+	}
+	return ""
+}
+
 func resourceBucketKeyRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	p := m.(*garageProvider)
 
 	bucketID := d.Get("bucket_id").(string)
 	keyID := d.Get("access_key_id").(string)
 
-	state, keyName, found, diags := fetchBucketKeyState(ctx, p, bucketID, keyID)
+	info, diags := fetchBucketInfo(ctx, p, bucketID)
 	if len(diags) > 0 {
 		return diags
 	}
+	if info == nil {
+		removeBucketKeyOfMissingBucket(ctx, d, bucketID, keyID)
+		return nil
+	}
 
+	state, keyName, found := bucketKeyStateFromInfo(info, keyID)
 	if !found {
 		d.SetId("")
 		return nil
@@ -126,14 +270,49 @@ func resourceBucketKeyRead(ctx context.Context, d *schema.ResourceData, m interf
 	_ = d.Set("write", state.Write)
 	_ = d.Set("owner", state.Owner)
 	_ = d.Set("key_name", keyName)
+	_ = d.Set("permissions_source", state.sources())
 
 	return nil
 }
 
+// resourceBucketKeyImport fills bucket_id, access_key_id and the read, write and
+// owner inputs from the server, so the first plan after an import shows no diff.
+func resourceBucketKeyImport(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	p := m.(*garageProvider)
+
+	parts := strings.SplitN(d.Id(), ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid import ID %q: expected <bucket_id>:<access_key_id>", d.Id())
+	}
+	bucketID, keyID := parts[0], parts[1]
+
+	state, keyName, found, diags := fetchBucketKeyState(ctx, p, bucketID, keyID)
+	if diags.HasError() {
+		return nil, diagnosticsError(diags)
+	}
+	if !found {
+		return nil, fmt.Errorf("access key %q has no permissions on bucket %q", keyID, bucketID)
+	}
+
+	_ = d.Set("bucket_id", bucketID)
+	_ = d.Set("access_key_id", keyID)
+	_ = d.Set("read", state.Read)
+	_ = d.Set("write", state.Write)
+	_ = d.Set("owner", state.Owner)
+	_ = d.Set("key_name", keyName)
+	_ = d.Set("permissions_source", state.sources())
+	_ = d.Set("assume_no_existing_permissions", false)
+	_ = d.Set("prevent_orphan_owner", false)
+	_ = d.Set("verify_timeout_seconds", 0)
+
+	return []*schema.ResourceData{d}, nil
+}
+
 func resourceBucketKeyUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	p := m.(*garageProvider)
 
-	if !(d.HasChange("read") || d.HasChange("write") || d.HasChange("owner")) {
+	// a reapply_trigger change reconciles the permissions even when they are unchanged in config
+	if !(d.HasChange("read") || d.HasChange("write") || d.HasChange("owner") || d.HasChange("reapply_trigger")) {
 		return resourceBucketKeyRead(ctx, d, m)
 	}
 
@@ -148,11 +327,45 @@ func resourceBucketKeyUpdate(ctx context.Context, d *schema.ResourceData, m inte
 		}}
 	}
 
-	if diags := ensureBucketKeyPermissions(ctx, p, bucketID, keyID, desired); len(diags) > 0 {
+	info, diags := fetchBucketInfo(ctx, p, bucketID)
+	if len(diags) > 0 {
 		return diags
 	}
+	// Terraform rejects an update that removes the resource, so the bucket key
+	// is only dropped from state by the next refresh
+	if info == nil {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "bucket not found",
+			Detail:   fmt.Sprintf("bucket %q no longer exists, so the permissions of access key %q cannot be updated; the next refresh removes this bucket key from state", bucketID, keyID),
+		}}
+	}
 
-	return resourceBucketKeyRead(ctx, d, m)
+	var warnings diag.Diagnostics
+	if d.HasChange("owner") && !desired.Owner {
+		warnings = orphanOwnerDiagnostics(d, info, bucketID, keyID)
+		if warnings.HasError() {
+			return warnings
+		}
+	}
+
+	current, _, _ := bucketKeyStateFromInfo(info, keyID)
+	if diags := applyBucketKeyChanges(ctx, p, bucketID, keyID, current, desired); len(diags) > 0 {
+		// record what the server holds now instead of the planned values, so a
+		// partly applied change still shows a diff and the next apply converges
+		if readDiags := resourceBucketKeyRead(ctx, d, m); readDiags.HasError() {
+			tflog.Debug(ctx, "could not refresh bucket key after failed update", map[string]interface{}{
+				"bucket_id":     bucketID,
+				"access_key_id": keyID,
+			})
+		}
+		return append(warnings, diags...)
+	}
+	if diags := verifyBucketKeyPermissions(ctx, d, p, desired); len(diags) > 0 {
+		return append(warnings, diags...)
+	}
+
+	return append(warnings, resourceBucketKeyRead(ctx, d, m)...)
 }
 
 func resourceBucketKeyDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
@@ -161,15 +374,28 @@ func resourceBucketKeyDelete(ctx context.Context, d *schema.ResourceData, m inte
 	bucketID := d.Get("bucket_id").(string)
 	keyID := d.Get("access_key_id").(string)
 
-	current, _, found, diags := fetchBucketKeyState(ctx, p, bucketID, keyID)
+	info, diags := fetchBucketInfo(ctx, p, bucketID)
 	if len(diags) > 0 {
 		return diags
 	}
+	if info == nil {
+		removeBucketKeyOfMissingBucket(ctx, d, bucketID, keyID)
+		return nil
+	}
+	current, _, found := bucketKeyStateFromInfo(info, keyID)
 	if !found {
 		d.SetId("")
 		return nil
 	}
 
+	var warnings diag.Diagnostics
+	if current.Owner {
+		warnings = orphanOwnerDiagnostics(d, info, bucketID, keyID)
+		if warnings.HasError() {
+			return warnings
+		}
+	}
+
 	deny := garage.NewApiBucketKeyPerm()
 	if current.Read {
 		deny.SetRead(true)
@@ -188,23 +414,170 @@ func resourceBucketKeyDelete(ctx context.Context, d *schema.ResourceData, m inte
 	}
 
 	d.SetId("")
-	return nil
+	return warnings
+}
+
+// removeBucketKeyOfMissingBucket drops a bucket key from state once its bucket
+// is gone, e.g. deleted outside Terraform or earlier in a coordinated destroy.
+func removeBucketKeyOfMissingBucket(ctx context.Context, d *schema.ResourceData, bucketID, keyID string) {
+	tflog.Info(ctx, "bucket no longer exists, removing bucket key from state", map[string]interface{}{
+		"bucket_id":     bucketID,
+		"access_key_id": keyID,
+	})
+	d.SetId("")
+}
+
+// orphanOwnerDiagnostics reports revoking owner from the bucket's last owning
+// key: a warning, or an error when prevent_orphan_owner is set.
+func orphanOwnerDiagnostics(d *schema.ResourceData, info *garage.GetBucketInfoResponse, bucketID, keyID string) diag.Diagnostics {
+	if info == nil || bucketHasOtherOwner(info, keyID) {
+		return nil
+	}
+	severity := diag.Warning
+	if d.Get("prevent_orphan_owner").(bool) {
+		severity = diag.Error
+	}
+	return diag.Diagnostics{{
+		Severity: severity,
+		Summary:  "bucket would be left without an owner",
+		Detail:   fmt.Sprintf("access key %q is the only owner of bucket %q; revoking owner leaves no key able to manage the bucket. Grant owner to another key first.", keyID, bucketID),
+	}}
+}
+
+// bucketHasOtherOwner reports whether a key other than keyID owns the bucket.
+func bucketHasOtherOwner(info *garage.GetBucketInfoResponse, keyID string) bool {
+	for _, key := range info.GetKeys() {
+		if key.GetAccessKeyId() == keyID {
+			continue
+		}
+		perms := key.GetPermissions()
+		if perms.GetOwner() {
+			return true
+		}
+	}
+	return false
+}
+
+// explicitlyDisabled returns the attributes written as false in the configuration,
+// as opposed to left unset and defaulted to false.
+func explicitlyDisabled(d *schema.ResourceDiff, keys ...string) []string {
+	raw := d.GetRawConfig()
+	if raw.IsNull() || !raw.IsKnown() {
+		return nil
+	}
+	var out []string
+	for _, k := range keys {
+		v := raw.GetAttr(k)
+		if v.IsKnown() && !v.IsNull() && v.False() {
+			out = append(out, k)
+		}
+	}
+	return out
 }
 
 func desiredBucketKeyPermissions(d *schema.ResourceData) bucketKeyPermissions {
 	return bucketKeyPermissions{
-		Read:  d.Get("read").(bool),
-		Write: d.Get("write").(bool),
-		Owner: d.Get("owner").(bool),
+		Read:  getBool(d, "read"),
+		Write: getBool(d, "write"),
+		Owner: getBool(d, "owner"),
 	}
 }
 
 func ensureBucketKeyPermissions(ctx context.Context, p *garageProvider, bucketID, keyID string, desired bucketKeyPermissions) diag.Diagnostics {
-	current, _, _, diags := fetchBucketKeyState(ctx, p, bucketID, keyID)
+	info, diags := fetchBucketInfo(ctx, p, bucketID)
 	if len(diags) > 0 {
 		return diags
 	}
+	if info == nil {
+		return bucketNotFoundDiagnostics(ctx, p, bucketID, keyID)
+	}
+	current, _, _ := bucketKeyStateFromInfo(info, keyID)
+	return applyBucketKeyChanges(ctx, p, bucketID, keyID, current, desired)
+}
+
+// defaultPermissionPollInterval spaces the re-reads of verify_timeout_seconds.
+const defaultPermissionPollInterval = time.Second
+
+// verifyBucketKeyPermissions waits for the permissions to read back as desired
+// when verify_timeout_seconds is set.
+func verifyBucketKeyPermissions(ctx context.Context, d *schema.ResourceData, p *garageProvider, desired bucketKeyPermissions) diag.Diagnostics {
+	timeout := secondsDuration(d.Get("verify_timeout_seconds").(int))
+	if timeout <= 0 {
+		return nil
+	}
+	return waitForBucketKeyPermissions(ctx, p, d.Get("bucket_id").(string), d.Get("access_key_id").(string), desired, timeout, 0)
+}
+
+// waitForBucketKeyPermissions re-reads the key's permissions on the bucket until
+// they equal desired, the timeout elapses, or ctx is cancelled. It absorbs a
+// lag between an allow/deny call and the change being visible on reads.
+func waitForBucketKeyPermissions(ctx context.Context, p *garageProvider, bucketID, keyID string, desired bucketKeyPermissions, timeout, interval time.Duration) diag.Diagnostics {
+	if interval <= 0 {
+		interval = defaultPermissionPollInterval
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// last is the most recent value actually read; a fetch cut short by the
+	// timeout leaves it unchanged
+	var last *bucketKeyPermissions
+	for {
+		current, diags := pollBucketKeyState(ctx, p, bucketID, keyID)
+		if len(diags) > 0 && ctx.Err() == nil {
+			return diags
+		}
+		if len(diags) == 0 {
+			if current == desired {
+				return nil
+			}
+			last = &current
+		}
+		tflog.Debug(ctx, "waiting for bucket key permissions to apply", map[string]interface{}{
+			"bucket_id":     bucketID,
+			"access_key_id": keyID,
+			"current":       current.String(),
+			"desired":       desired.String(),
+		})
+
+		select {
+		case <-ctx.Done():
+			detail := fmt.Sprintf("access key %q on bucket %q could not be read back within %s to confirm %s.", keyID, bucketID, timeout, desired)
+			if last != nil {
+				detail = fmt.Sprintf("access key %q on bucket %q still reads back with %s instead of %s after %s.", keyID, bucketID, *last, desired, timeout)
+			}
+			return diag.Diagnostics{{
+				Severity: diag.Error,
+				Summary:  "bucket key permissions not applied",
+				Detail:   detail + " Raise verify_timeout_seconds if the cluster is slow to propagate changes.",
+			}}
+		case <-ticker.C:
+		}
+	}
+}
+
+// pollBucketKeyState reads one key's permissions on a bucket past the
+// cache_bucket_info cache, so each poll sees the server's current answer.
+func pollBucketKeyState(ctx context.Context, p *garageProvider, bucketID, keyID string) (bucketKeyPermissions, diag.Diagnostics) {
+	info, httpResp, err := p.getBucketInfoUncached(ctx, bucketID)
+	if err != nil {
+		if httpResp != nil && httpResp.StatusCode == http.StatusNotFound {
+			return bucketKeyPermissions{}, nil
+		}
+		return bucketKeyPermissions{}, createDiagnostics(err, httpResp)
+	}
+	if info == nil {
+		return bucketKeyPermissions{}, emptyResponseDiagnostics("GetBucketInfo")
+	}
+	state, _, _ := bucketKeyStateFromInfo(info, keyID)
+	return state, nil
+}
 
+// applyBucketKeyChanges issues the allow/deny calls needed to move from current to desired.
+// Callers that already know the current state (or know it is empty) can use it to skip a fetch.
+func applyBucketKeyChanges(ctx context.Context, p *garageProvider, bucketID, keyID string, current, desired bucketKeyPermissions) diag.Diagnostics {
 	allow := garage.NewApiBucketKeyPerm()
 	deny := garage.NewApiBucketKeyPerm()
 
@@ -237,6 +610,9 @@ func ensureBucketKeyPermissions(ctx context.Context, p *garageProvider, bucketID
 
 	if hasAnyBucketKeyPerm(deny) {
 		if diags := applyBucketKeyDeny(ctx, p, bucketID, keyID, deny); len(diags) > 0 {
+			if hasAnyBucketKeyPerm(allow) {
+				return partialBucketKeyChangeDiagnostics(diags, bucketID, keyID, current, bucketKeyPermsFromAPI(allow), bucketKeyPermsFromAPI(deny))
+			}
 			return diags
 		}
 	}
@@ -244,22 +620,77 @@ func ensureBucketKeyPermissions(ctx context.Context, p *garageProvider, bucketID
 	return nil
 }
 
+// partialBucketKeyChangeDiagnostics explains a deny that failed after the allow
+// of the same change went through, leaving the key with the previous
+// permissions plus the granted ones.
+func partialBucketKeyChangeDiagnostics(diags diag.Diagnostics, bucketID, keyID string, current, granted, revoking bucketKeyPermissions) diag.Diagnostics {
+	now := bucketKeyPermissions{
+		Read:  current.Read || granted.Read,
+		Write: current.Write || granted.Write,
+		Owner: current.Owner || granted.Owner,
+	}
+	for i := range diags {
+		if diags[i].Severity != diag.Error {
+			continue
+		}
+		diags[i].Detail = fmt.Sprintf("%s\n\nThe permission change was only partly applied: %s was granted to access key %q on bucket %q, but revoking %s failed. The key now has %s. Run apply again to retry the revocation, or revoke it manually.",
+			diags[i].Detail, granted, keyID, bucketID, revoking, now)
+	}
+	return diags
+}
+
+// bucketKeyPermsFromAPI converts the flags set in an allow/deny request body.
+func bucketKeyPermsFromAPI(perm *garage.ApiBucketKeyPerm) bucketKeyPermissions {
+	return bucketKeyPermissions{
+		Read:  perm.Read != nil && *perm.Read,
+		Write: perm.Write != nil && *perm.Write,
+		Owner: perm.Owner != nil && *perm.Owner,
+	}
+}
+
+// bucketKeyPermsToAPI builds the permission body setting exactly the permissions in perms.
+func bucketKeyPermsToAPI(perms bucketKeyPermissions) *garage.ApiBucketKeyPerm {
+	perm := garage.NewApiBucketKeyPerm()
+	if perms.Read {
+		perm.SetRead(true)
+	}
+	if perms.Write {
+		perm.SetWrite(true)
+	}
+	if perms.Owner {
+		perm.SetOwner(true)
+	}
+	return perm
+}
+
 func fetchBucketKeyState(ctx context.Context, p *garageProvider, bucketID, keyID string) (bucketKeyPermissions, string, bool, diag.Diagnostics) {
-	req := p.client.BucketAPI.
-		GetBucketInfo(p.withToken(ctx)).
-		Id(bucketID)
+	info, diags := fetchBucketInfo(ctx, p, bucketID)
+	if len(diags) > 0 || info == nil {
+		return bucketKeyPermissions{}, "", false, diags
+	}
+
+	state, name, found := bucketKeyStateFromInfo(info, keyID)
+	return state, name, found, nil
+}
 
-	info, httpResp, err := req.Execute()
+// fetchBucketInfo reads a bucket, returning nil without diagnostics when it does not exist.
+func fetchBucketInfo(ctx context.Context, p *garageProvider, bucketID string) (*garage.GetBucketInfoResponse, diag.Diagnostics) {
+	info, httpResp, err := p.getBucketInfo(ctx, bucketID)
 	if err != nil {
 		if httpResp != nil && httpResp.StatusCode == http.StatusNotFound {
-			return bucketKeyPermissions{}, "", false, nil
+			return nil, nil
 		}
-		return bucketKeyPermissions{}, "", false, createDiagnostics(err, httpResp)
+		return nil, createDiagnostics(err, httpResp)
 	}
 	if info == nil {
-		return bucketKeyPermissions{}, "", false, nil
+		return nil, emptyResponseDiagnostics("GetBucketInfo")
 	}
+	return info, nil
+}
 
+// bucketKeyStateFromInfo extracts one key's permissions and name from a bucket
+// already fetched with GetBucketInfo.
+func bucketKeyStateFromInfo(info *garage.GetBucketInfoResponse, keyID string) (bucketKeyPermissions, string, bool) {
 	for i := range info.Keys {
 		key := info.Keys[i]
 		if key.GetAccessKeyId() != keyID {
@@ -272,10 +703,10 @@ func fetchBucketKeyState(ctx context.Context, p *garageProvider, bucketID, keyID
 			Write: perms.GetWrite(),
 			Owner: perms.GetOwner(),
 		}
-		return state, key.GetName(), true, nil
+		return state, key.GetName(), true
 	}
 
-	return bucketKeyPermissions{}, "", false, nil
+	return bucketKeyPermissions{}, "", false
 }
 
 func applyBucketKeyAllow(ctx context.Context, p *garageProvider, bucketID, keyID string, perm *garage.ApiBucketKeyPerm) diag.Diagnostics {
@@ -0,0 +1,102 @@
+package garage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+/*
+Resource: garage_bucket_key_revoke
+
+Declares that an access key has no access to a bucket. On create, whatever
+read, write and owner permissions the key currently holds on the bucket are
+denied through PermissionAPI.DenyBucketKey. Read drops the resource from state
+when the key has regained any permission, so the next apply revokes it again.
+
+Destroying the resource does not grant anything back.
+
+ID format: <bucket_id>:<access_key_id>
+*/
+
+func resourceBucketKeyRevoke() *schema.Resource {
+	annotate := withResourceDiagnostics("bucket key revoke", "bucket_id", "access_key_id")
+	return &schema.Resource{
+		Description: "Ensures an access key holds no permissions on a Garage bucket by denying read, write and owner. Destroying the resource leaves the permissions as they are.",
+
+		Schema: map[string]*schema.Schema{
+			"bucket_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "ID of the target bucket (UUID).",
+			},
+			"access_key_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Access key ID that must have no access to the bucket.",
+			},
+		},
+
+		CreateContext: annotate(resourceBucketKeyRevokeCreate),
+		ReadContext:   annotate(resourceBucketKeyRevokeRead),
+		DeleteContext: annotate(resourceBucketKeyRevokeDelete),
+	}
+}
+
+func resourceBucketKeyRevokeCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	p := m.(*garageProvider)
+
+	bucketID := d.Get("bucket_id").(string)
+	keyID := d.Get("access_key_id").(string)
+
+	info, diags := fetchBucketInfo(ctx, p, bucketID)
+	if len(diags) > 0 {
+		return diags
+	}
+	if info == nil {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "bucket not found",
+			Detail:   fmt.Sprintf("bucket %q does not exist", bucketID),
+		}}
+	}
+
+	current, _, _ := bucketKeyStateFromInfo(info, keyID)
+	if diags := applyBucketKeyDeny(ctx, p, bucketID, keyID, bucketKeyPermsToAPI(current)); len(diags) > 0 {
+		return diags
+	}
+
+	d.SetId(fmt.Sprintf("%s:%s", bucketID, keyID))
+	return nil
+}
+
+func resourceBucketKeyRevokeRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	p := m.(*garageProvider)
+
+	bucketID := d.Get("bucket_id").(string)
+	keyID := d.Get("access_key_id").(string)
+
+	info, diags := fetchBucketInfo(ctx, p, bucketID)
+	if len(diags) > 0 {
+		return diags
+	}
+	if info == nil {
+		removeBucketKeyOfMissingBucket(ctx, d, bucketID, keyID)
+		return nil
+	}
+	// permissions granted again outside Terraform: plan a new revoke
+	if current, _, _ := bucketKeyStateFromInfo(info, keyID); current.any() {
+		d.SetId("")
+	}
+	return nil
+}
+
+// resourceBucketKeyRevokeDelete only drops the resource from state.
+func resourceBucketKeyRevokeDelete(_ context.Context, d *schema.ResourceData, _ interface{}) diag.Diagnostics {
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,111 @@
+package garage
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestResourceBucketKeyRevokeCreateDeniesHeldPermissions(t *testing.T) {
+	var calls []string
+	var denied map[string]interface{}
+	p := newTestProvider(func(r *http.Request) (*http.Response, error) {
+		calls = append(calls, r.URL.Path)
+		payload := bucketInfoPayload("bucket", "key", "name", bucketKeyPermissions{Read: true, Owner: true})
+		switch r.URL.Path {
+		case "/v2/GetBucketInfo":
+		case "/v2/DenyBucketKey":
+			var body struct {
+				Permissions map[string]interface{} `json:"permissions"`
+			}
+			raw, _ := io.ReadAll(r.Body)
+			r.Body.Close()
+			if err := json.Unmarshal(raw, &body); err != nil {
+				t.Fatalf("invalid deny body %s: %v", raw, err)
+			}
+			denied = body.Permissions
+			payload = bucketInfoPayload("bucket", "key", "name", bucketKeyPermissions{})
+		default:
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Status:     "200 OK",
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(payload)),
+		}, nil
+	})
+
+	d := schema.TestResourceDataRaw(t, resourceBucketKeyRevoke().Schema, map[string]interface{}{
+		"bucket_id":     "bucket",
+		"access_key_id": "key",
+	})
+	if diags := resourceBucketKeyRevokeCreate(context.Background(), d, p); len(diags) > 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+
+	if got := strings.Join(calls, ","); got != "/v2/GetBucketInfo,/v2/DenyBucketKey" {
+		t.Fatalf("unexpected request sequence %s", got)
+	}
+	if denied["read"] != true || denied["owner"] != true {
+		t.Fatalf("expected read and owner to be denied, got %v", denied)
+	}
+	if _, ok := denied["write"]; ok {
+		t.Fatalf("expected write not held and left out of the deny, got %v", denied)
+	}
+	if d.Id() != "bucket:key" {
+		t.Fatalf("unexpected ID %q", d.Id())
+	}
+}
+
+func TestResourceBucketKeyRevokeCreateWithoutPermissions(t *testing.T) {
+	var calls []string
+	p := newTestProvider(func(r *http.Request) (*http.Response, error) {
+		calls = append(calls, r.URL.Path)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Status:     "200 OK",
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(bucketInfoPayloadWithKeys("bucket"))),
+		}, nil
+	})
+
+	d := schema.TestResourceDataRaw(t, resourceBucketKeyRevoke().Schema, map[string]interface{}{
+		"bucket_id":     "bucket",
+		"access_key_id": "key",
+	})
+	if diags := resourceBucketKeyRevokeCreate(context.Background(), d, p); len(diags) > 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	if got := strings.Join(calls, ","); got != "/v2/GetBucketInfo" {
+		t.Fatalf("expected no deny for a key without permissions, got %s", got)
+	}
+}
+
+func TestResourceBucketKeyRevokeReadDetectsRegrantedPermissions(t *testing.T) {
+	p := newTestProvider(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Status:     "200 OK",
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(bucketInfoPayload("bucket", "key", "name", bucketKeyPermissions{Write: true}))),
+		}, nil
+	})
+
+	d := schema.TestResourceDataRaw(t, resourceBucketKeyRevoke().Schema, map[string]interface{}{
+		"bucket_id":     "bucket",
+		"access_key_id": "key",
+	})
+	d.SetId("bucket:key")
+	if diags := resourceBucketKeyRevokeRead(context.Background(), d, p); len(diags) > 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	if d.Id() != "" {
+		t.Fatalf("expected the resource to be dropped so the revoke is planned again")
+	}
+}
@@ -1,6 +1,7 @@
 package garage
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"io"
@@ -12,6 +13,9 @@ import (
 	"unsafe"
 
 	garageapi "git.deuxfleurs.fr/garage-sdk/garage-admin-sdk-golang"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
@@ -80,7 +84,61 @@ func TestResourceBucketKeyCustomizeDiffError(t *testing.T) {
 	}
 }
 
+func TestResourceBucketKeyCustomizeDiffSwappedIDs(t *testing.T) {
+	resource := resourceBucketKey()
+	conf := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"bucket_id":     "GK0123456789abcdef01234567",
+		"access_key_id": "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+		"read":          true,
+	})
+	_, err := resource.Diff(context.Background(), nil, conf, nil)
+	if err == nil || !strings.Contains(err.Error(), "swapped") {
+		t.Fatalf("expected a swap error for an access key ID in bucket_id, got %v", err)
+	}
+}
+
+func TestResourceBucketKeyCustomizeDiffOwnerWithDisabledPerms(t *testing.T) {
+	for name, tc := range map[string]struct {
+		read     cty.Value
+		wantWarn bool
+	}{
+		"explicit false": {read: cty.False, wantWarn: true},
+		"unset":          {read: cty.NullVal(cty.Bool), wantWarn: false},
+	} {
+		var logs bytes.Buffer
+		ctx := tflogtest.RootLogger(context.Background(), &logs)
+
+		raw := map[string]interface{}{
+			"bucket_id":     "bucket",
+			"access_key_id": "key",
+			"owner":         true,
+		}
+		if !tc.read.IsNull() {
+			raw["read"] = false
+		}
+		// Terraform hands the raw configuration over through the prior state
+		state := &terraform.InstanceState{RawConfig: cty.ObjectVal(map[string]cty.Value{
+			"bucket_id":     cty.StringVal("bucket"),
+			"access_key_id": cty.StringVal("key"),
+			"owner":         cty.True,
+			"read":          tc.read,
+			"write":         cty.NullVal(cty.Bool),
+		})}
+
+		if _, err := resourceBucketKey().Diff(ctx, state, terraform.NewResourceConfigRaw(raw), nil); err != nil {
+			t.Fatalf("%s: owner with read=false must stay valid, got %v", name, err)
+		}
+		if got := strings.Contains(logs.String(), "owner grants full access"); got != tc.wantWarn {
+			t.Fatalf("%s: expected warning=%v, logs: %s", name, tc.wantWarn, logs.String())
+		}
+	}
+}
+
 func bucketInfoPayload(bucketID, keyID, keyName string, perms bucketKeyPermissions) string {
+	return bucketInfoPayloadWithKeys(bucketID, bucketInfoKey(keyID, keyName, perms))
+}
+
+func bucketInfoKey(keyID, keyName string, perms bucketKeyPermissions) garageapi.GetBucketInfoKey {
 	perm := garageapi.ApiBucketKeyPerm{}
 	if perms.Read {
 		perm.SetRead(true)
@@ -91,13 +149,16 @@ func bucketInfoPayload(bucketID, keyID, keyName string, perms bucketKeyPermissio
 	if perms.Owner {
 		perm.SetOwner(true)
 	}
+	return garageapi.GetBucketInfoKey{AccessKeyId: keyID, BucketLocalAliases: []string{}, Name: keyName, Permissions: perm}
+}
 
+func bucketInfoPayloadWithKeys(bucketID string, keys ...garageapi.GetBucketInfoKey) string {
 	resp := garageapi.GetBucketInfoResponse{
 		Bytes:                          0,
 		Created:                        time.Now().UTC(),
 		GlobalAliases:                  []string{},
 		Id:                             bucketID,
-		Keys:                           []garageapi.GetBucketInfoKey{{AccessKeyId: keyID, BucketLocalAliases: []string{}, Name: keyName, Permissions: perm}},
+		Keys:                           keys,
 		Objects:                        0,
 		Quotas:                         garageapi.ApiBucketQuotas{},
 		UnfinishedMultipartUploadBytes: 0,
@@ -401,6 +462,40 @@ func TestResourceBucketKeyCreateSuccess(t *testing.T) {
 	}
 }
 
+func TestResourceBucketKeyCreateAssumeNoExistingPermissions(t *testing.T) {
+	bucketID, keyID := "bucket", "key"
+	var paths []string
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		paths = append(paths, r.URL.Path)
+		if r.URL.Path != "/v2/AllowBucketKey" {
+			t.Fatalf("unexpected request %s", r.URL.Path)
+		}
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(bucketInfoPayload(bucketID, keyID, "name", bucketKeyPermissions{Read: true, Write: true})))}, nil
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourceBucketKey().Schema, map[string]interface{}{
+		"bucket_id":                      bucketID,
+		"access_key_id":                  keyID,
+		"read":                           true,
+		"write":                          true,
+		"assume_no_existing_permissions": true,
+	})
+
+	diags := resourceBucketKeyCreate(context.Background(), d, p)
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	if len(paths) != 1 {
+		t.Fatalf("expected a single AllowBucketKey call, got %v", paths)
+	}
+	if d.Id() != bucketID+":"+keyID {
+		t.Fatalf("expected id to be set got %q", d.Id())
+	}
+	if !d.Get("read").(bool) || !d.Get("write").(bool) || d.Get("owner").(bool) {
+		t.Fatalf("unexpected state read=%v write=%v owner=%v", d.Get("read"), d.Get("write"), d.Get("owner"))
+	}
+}
+
 func TestResourceBucketKeyCreateError(t *testing.T) {
 	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
 		if r.URL.Path == "/v2/GetBucketInfo" {
@@ -420,6 +515,154 @@ func TestResourceBucketKeyCreateError(t *testing.T) {
 	}
 }
 
+func TestResourceBucketKeyCreateSwappedIDs(t *testing.T) {
+	notFound := func() (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(`{"code":"NoSuchBucket","message":"not found"}`))}, nil
+	}
+	var calls []string
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		calls = append(calls, r.URL.Path+"?"+r.URL.RawQuery)
+		switch r.URL.Path {
+		case "/v2/GetBucketInfo":
+			if r.URL.Query().Get("id") != "real-bucket" {
+				return notFound()
+			}
+			return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(bucketInfoPayloadWithKeys("real-bucket")))}, nil
+		case "/v2/GetKeyInfo":
+			return notFound()
+		}
+		t.Fatalf("unexpected request %s", r.URL.Path)
+		return nil, nil
+	}))
+
+	// bucket and key IDs given the wrong way round
+	d := schema.TestResourceDataRaw(t, resourceBucketKey().Schema, map[string]interface{}{
+		"bucket_id":     "real-key",
+		"access_key_id": "real-bucket",
+		"read":          true,
+	})
+	diags := resourceBucketKeyCreate(context.Background(), d, p)
+	if len(diags) != 1 || diags[0].Summary != "bucket_id and access_key_id look swapped" {
+		t.Fatalf("expected a swap diagnostic, got %#v", diags)
+	}
+	if !strings.Contains(diags[0].Detail, `access_key_id "real-bucket" is a bucket ID`) {
+		t.Fatalf("unexpected detail %q", diags[0].Detail)
+	}
+	if got := strings.Join(calls, ","); got != "/v2/GetBucketInfo?id=real-key,/v2/GetBucketInfo?id=real-bucket" {
+		t.Fatalf("expected no permission change, got %s", got)
+	}
+	if d.Id() != "" {
+		t.Fatalf("expected no ID on failure, got %q", d.Id())
+	}
+}
+
+func TestResourceBucketKeyCreateBucketNotFound(t *testing.T) {
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(`{"code":"NoSuchBucket","message":"not found"}`))}, nil
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourceBucketKey().Schema, map[string]interface{}{
+		"bucket_id":     "missing",
+		"access_key_id": "key",
+		"read":          true,
+	})
+	diags := resourceBucketKeyCreate(context.Background(), d, p)
+	if len(diags) != 1 || diags[0].Summary != "bucket not found" {
+		t.Fatalf("expected a bucket not found diagnostic, got %#v", diags)
+	}
+}
+
+func TestWaitForBucketKeyPermissionsStaleThenApplied(t *testing.T) {
+	reads := 0
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path != "/v2/GetBucketInfo" {
+			t.Fatalf("unexpected request %s", r.URL.Path)
+		}
+		reads++
+		perms := bucketKeyPermissions{Read: true, Write: true}
+		if reads == 1 {
+			// the grant has not propagated yet
+			perms = bucketKeyPermissions{Read: true}
+		}
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(bucketInfoPayload("bucket", "key", "name", perms)))}, nil
+	}))
+
+	desired := bucketKeyPermissions{Read: true, Write: true}
+	if diags := waitForBucketKeyPermissions(context.Background(), p, "bucket", "key", desired, 5*time.Second, time.Millisecond); len(diags) > 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	if reads != 2 {
+		t.Fatalf("expected the stale read to be retried once, got %d reads", reads)
+	}
+}
+
+func TestWaitForBucketKeyPermissionsTimeout(t *testing.T) {
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(bucketInfoPayload("bucket", "key", "name", bucketKeyPermissions{Read: true})))}, nil
+	}))
+
+	desired := bucketKeyPermissions{Read: true, Write: true}
+	diags := waitForBucketKeyPermissions(context.Background(), p, "bucket", "key", desired, 20*time.Millisecond, time.Millisecond)
+	if len(diags) != 1 || diags[0].Summary != "bucket key permissions not applied" {
+		t.Fatalf("expected a timeout diagnostic, got %#v", diags)
+	}
+	if !strings.Contains(diags[0].Detail, "read instead of read, write") {
+		t.Fatalf("unexpected detail %q", diags[0].Detail)
+	}
+}
+
+func TestWaitForBucketKeyPermissionsBypassesCache(t *testing.T) {
+	reads := 0
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		reads++
+		perms := bucketKeyPermissions{Read: true, Write: true}
+		if reads == 1 {
+			perms = bucketKeyPermissions{Read: true}
+		}
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(bucketInfoPayload("bucket", "key", "name", perms)))}, nil
+	}))
+	p.bucketInfo = &bucketInfoCache{ttl: time.Hour}
+
+	// a stale response cached before the grant must not be replayed to the poll
+	if _, _, _, diags := fetchBucketKeyState(context.Background(), p, "bucket", "key"); len(diags) > 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	desired := bucketKeyPermissions{Read: true, Write: true}
+	if diags := waitForBucketKeyPermissions(context.Background(), p, "bucket", "key", desired, 5*time.Second, time.Millisecond); len(diags) > 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	if reads != 2 {
+		t.Fatalf("expected the poll to read from the server, got %d reads", reads)
+	}
+}
+
+func TestWaitForBucketKeyPermissionsTimeoutWithoutRead(t *testing.T) {
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		<-r.Context().Done()
+		return nil, r.Context().Err()
+	}))
+
+	desired := bucketKeyPermissions{Read: true}
+	diags := waitForBucketKeyPermissions(context.Background(), p, "bucket", "key", desired, 20*time.Millisecond, time.Millisecond)
+	if len(diags) != 1 || diags[0].Summary != "bucket key permissions not applied" {
+		t.Fatalf("expected a timeout diagnostic, got %#v", diags)
+	}
+	if !strings.Contains(diags[0].Detail, "could not be read back") || strings.Contains(diags[0].Detail, "none") {
+		t.Fatalf("expected the detail not to report an unread value, got %q", diags[0].Detail)
+	}
+}
+
+func TestWaitForBucketKeyPermissionsEmptyResponse(t *testing.T) {
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(""))}, nil
+	}))
+
+	diags := waitForBucketKeyPermissions(context.Background(), p, "bucket", "key", bucketKeyPermissions{Read: true}, 5*time.Second, time.Millisecond)
+	if len(diags) != 1 || diags[0].Summary != "empty response from API" {
+		t.Fatalf("expected an empty response diagnostic, got %#v", diags)
+	}
+}
+
 func TestResourceBucketKeyReadSuccess(t *testing.T) {
 	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
 		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(bucketInfoPayload("bucket", "key", "name", bucketKeyPermissions{Write: true})))}, nil
@@ -440,6 +683,43 @@ func TestResourceBucketKeyReadSuccess(t *testing.T) {
 	}
 }
 
+func TestResourceBucketKeyReadPermissionsSourceOwnerOnly(t *testing.T) {
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(bucketInfoPayload("bucket", "key", "name", bucketKeyPermissions{Owner: true})))}, nil
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourceBucketKey().Schema, map[string]interface{}{
+		"bucket_id":     "bucket",
+		"access_key_id": "key",
+		"owner":         true,
+	})
+	d.SetId("bucket:key")
+
+	if diags := resourceBucketKeyRead(context.Background(), d, p); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	got := d.Get("permissions_source").(map[string]interface{})
+	want := map[string]interface{}{"read": "implied", "write": "implied", "owner": "explicit"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestBucketKeyPermissionsSources(t *testing.T) {
+	for _, tc := range []struct {
+		perms bucketKeyPermissions
+		want  map[string]string
+	}{
+		{bucketKeyPermissions{}, map[string]string{"read": "none", "write": "none", "owner": "none"}},
+		{bucketKeyPermissions{Read: true}, map[string]string{"read": "explicit", "write": "none", "owner": "none"}},
+		{bucketKeyPermissions{Read: true, Owner: true}, map[string]string{"read": "explicit", "write": "implied", "owner": "explicit"}},
+	} {
+		if got := tc.perms.sources(); !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("%s: expected %v, got %v", tc.perms, tc.want, got)
+		}
+	}
+}
+
 func TestResourceBucketKeyReadNotFound(t *testing.T) {
 	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
 		return &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found", Body: io.NopCloser(strings.NewReader("")), Header: make(http.Header)}, nil
@@ -532,6 +812,41 @@ func TestResourceBucketKeyUpdateChange(t *testing.T) {
 	}
 }
 
+func TestResourceBucketKeyUpdateReapplyTrigger(t *testing.T) {
+	bucketID, keyID := "bucket", "key"
+	var calls []string
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		calls = append(calls, r.URL.Path)
+		perms := bucketKeyPermissions{Read: true}
+		switch r.URL.Path {
+		case "/v2/GetBucketInfo":
+			// write was revoked outside Terraform; it is back once the allow went through
+			if len(calls) > 1 {
+				perms.Write = true
+			}
+		case "/v2/AllowBucketKey":
+			perms.Write = true
+		default:
+			t.Fatalf("unexpected request %s", r.URL.Path)
+		}
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(bucketInfoPayload(bucketID, keyID, "name", perms)))}, nil
+	}))
+
+	perms := bucketKeyPermissions{Read: true, Write: true}
+	d := prepareBucketKeyData(t, bucketID, keyID, perms, perms)
+	_ = d.Set("reapply_trigger", "2")
+
+	if diags := resourceBucketKeyUpdate(context.Background(), d, p); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	if got := strings.Join(calls, ","); got != "/v2/GetBucketInfo,/v2/AllowBucketKey,/v2/GetBucketInfo" {
+		t.Fatalf("expected the trigger to reconcile permissions, got requests %s", got)
+	}
+	if !d.Get("write").(bool) {
+		t.Fatalf("expected write to be re-granted")
+	}
+}
+
 func TestResourceBucketKeyUpdateError(t *testing.T) {
 	allowCalled := false
 	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
@@ -550,6 +865,70 @@ func TestResourceBucketKeyUpdateError(t *testing.T) {
 	}
 }
 
+func TestResourceBucketKeyUpdateBucketNotFound(t *testing.T) {
+	var calls []string
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		calls = append(calls, r.URL.Path)
+		return &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found", Body: io.NopCloser(strings.NewReader("")), Header: make(http.Header)}, nil
+	}))
+
+	d := prepareBucketKeyData(t, "bucket", "key", bucketKeyPermissions{Read: true}, bucketKeyPermissions{Read: true, Write: true})
+
+	diags := resourceBucketKeyUpdate(context.Background(), d, p)
+	if len(diags) != 1 || diags[0].Severity != diag.Error || diags[0].Summary != "bucket not found" {
+		t.Fatalf("expected bucket not found error, got %#v", diags)
+	}
+	// left to the next refresh: Terraform rejects an update that removes the resource
+	if d.Id() == "" {
+		t.Fatalf("expected the bucket key to stay in state")
+	}
+	if len(calls) != 1 || calls[0] != "/v2/GetBucketInfo" {
+		t.Fatalf("expected no permission change for a missing bucket, got %v", calls)
+	}
+}
+
+func TestResourceBucketKeyUpdatePartialFailure(t *testing.T) {
+	bucketID, keyID := "bucket", "key"
+	var calls []string
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		calls = append(calls, r.URL.Path)
+		switch r.URL.Path {
+		case "/v2/GetBucketInfo":
+			// before the change the key can read; after the allow it can also write
+			perms := bucketKeyPermissions{Read: true}
+			if len(calls) > 1 {
+				perms.Write = true
+			}
+			return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(bucketInfoPayload(bucketID, keyID, "name", perms)))}, nil
+		case "/v2/AllowBucketKey":
+			return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(bucketInfoPayload(bucketID, keyID, "name", bucketKeyPermissions{Read: true, Write: true})))}, nil
+		case "/v2/DenyBucketKey":
+			return &http.Response{StatusCode: http.StatusInternalServerError, Status: "500 Internal Server Error", Body: io.NopCloser(strings.NewReader("boom")), Header: make(http.Header)}, nil
+		}
+		t.Fatalf("unexpected request %s", r.URL.Path)
+		return nil, nil
+	}))
+
+	d := prepareBucketKeyData(t, bucketID, keyID, bucketKeyPermissions{Read: true}, bucketKeyPermissions{Write: true})
+
+	diags := resourceBucketKeyUpdate(context.Background(), d, p)
+	if !diags.HasError() {
+		t.Fatalf("expected error when the deny fails")
+	}
+	detail := diags[0].Detail
+	for _, want := range []string{"write was granted", "revoking read failed", "now has read, write"} {
+		if !strings.Contains(detail, want) {
+			t.Fatalf("expected detail to contain %q, got %q", want, detail)
+		}
+	}
+	if !d.Get("read").(bool) || !d.Get("write").(bool) {
+		t.Fatalf("expected state to hold the partly applied permissions, got read=%v write=%v", d.Get("read"), d.Get("write"))
+	}
+	if got := strings.Join(calls, ","); got != "/v2/GetBucketInfo,/v2/AllowBucketKey,/v2/DenyBucketKey,/v2/GetBucketInfo" {
+		t.Fatalf("unexpected request sequence %s", got)
+	}
+}
+
 func TestResourceBucketKeyDeleteSuccess(t *testing.T) {
 	idx := 0
 	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
@@ -578,6 +957,64 @@ func TestResourceBucketKeyDeleteSuccess(t *testing.T) {
 	}
 }
 
+func TestResourceBucketKeyDeleteLastOwner(t *testing.T) {
+	for _, prevent := range []bool{false, true} {
+		denied := false
+		p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+			switch r.URL.Path {
+			case "/v2/GetBucketInfo":
+				return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(bucketInfoPayloadWithKeys("bucket",
+					bucketInfoKey("owner-key", "owner", bucketKeyPermissions{Owner: true}),
+					bucketInfoKey("reader", "reader", bucketKeyPermissions{Read: true}),
+				)))}, nil
+			case "/v2/DenyBucketKey":
+				denied = true
+				return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(bucketInfoPayload("bucket", "owner-key", "owner", bucketKeyPermissions{})))}, nil
+			default:
+				t.Fatalf("unexpected request %s", r.URL.Path)
+			}
+			return nil, nil
+		}))
+
+		d := schema.TestResourceDataRaw(t, resourceBucketKey().Schema, map[string]interface{}{
+			"bucket_id":            "bucket",
+			"access_key_id":        "owner-key",
+			"owner":                true,
+			"prevent_orphan_owner": prevent,
+		})
+		d.SetId("bucket:owner-key")
+
+		diags := resourceBucketKeyDelete(context.Background(), d, p)
+		if len(diags) != 1 || diags[0].Summary != "bucket would be left without an owner" {
+			t.Fatalf("prevent=%v: expected orphan owner diagnostic, got %#v", prevent, diags)
+		}
+		if prevent {
+			if diags[0].Severity != diag.Error || denied {
+				t.Fatalf("expected an error and no deny call, got severity %v denied=%v", diags[0].Severity, denied)
+			}
+			continue
+		}
+		if diags[0].Severity != diag.Warning || !denied || d.Id() != "" {
+			t.Fatalf("expected a warning and the owner to be revoked, got severity %v denied=%v id=%q", diags[0].Severity, denied, d.Id())
+		}
+	}
+}
+
+func TestBucketHasOtherOwner(t *testing.T) {
+	info := &garageapi.GetBucketInfoResponse{Keys: []garageapi.GetBucketInfoKey{
+		bucketInfoKey("a", "a", bucketKeyPermissions{Owner: true}),
+		bucketInfoKey("b", "b", bucketKeyPermissions{Owner: true}),
+		bucketInfoKey("c", "c", bucketKeyPermissions{Read: true}),
+	}}
+	if !bucketHasOtherOwner(info, "a") {
+		t.Fatalf("expected b to count as another owner of a")
+	}
+	info.Keys = info.Keys[:1]
+	if bucketHasOtherOwner(info, "a") {
+		t.Fatalf("expected a to be the only owner")
+	}
+}
+
 func TestResourceBucketKeyDeleteNotFound(t *testing.T) {
 	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
 		return &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found", Body: io.NopCloser(strings.NewReader("")), Header: make(http.Header)}, nil
@@ -619,3 +1056,56 @@ func TestResourceBucketKeyDeleteError(t *testing.T) {
 		t.Fatalf("expected diagnostics on deny failure")
 	}
 }
+
+func TestResourceBucketKeyImportSetsPermissionInputs(t *testing.T) {
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path != "/v2/GetBucketInfo" {
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(bucketInfoPayload("bucket", "GK1", "app", bucketKeyPermissions{Read: true, Write: true})))}, nil
+	}))
+
+	res := resourceBucketKey()
+	d := res.TestResourceData()
+	d.SetId("bucket:GK1")
+
+	imported, err := res.Importer.StateContext(context.Background(), d, p)
+	if err != nil {
+		t.Fatalf("unexpected import error: %v", err)
+	}
+	if len(imported) != 1 {
+		t.Fatalf("expected one imported resource, got %d", len(imported))
+	}
+	got := imported[0]
+	if got.Get("bucket_id").(string) != "bucket" || got.Get("access_key_id").(string) != "GK1" {
+		t.Fatalf("unexpected identifiers %q %q", got.Get("bucket_id"), got.Get("access_key_id"))
+	}
+	if !got.Get("read").(bool) || !got.Get("write").(bool) || got.Get("owner").(bool) {
+		t.Fatalf("expected read+write from server, got read=%v write=%v owner=%v", got.Get("read"), got.Get("write"), got.Get("owner"))
+	}
+
+	// the matching configuration plans no changes
+	conf := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"bucket_id":     "bucket",
+		"access_key_id": "GK1",
+		"read":          true,
+		"write":         true,
+	})
+	diff, err := res.Diff(context.Background(), got.State(), conf, p)
+	if err != nil {
+		t.Fatalf("unexpected diff error: %v", err)
+	}
+	if diff != nil && len(diff.Attributes) > 0 {
+		t.Fatalf("expected no diff after import, got %#v", diff.Attributes)
+	}
+}
+
+func TestResourceBucketKeyImportInvalidID(t *testing.T) {
+	res := resourceBucketKey()
+	d := res.TestResourceData()
+	d.SetId("bucket-only")
+
+	if _, err := res.Importer.StateContext(context.Background(), d, newTestProvider(nil)); err == nil {
+		t.Fatalf("expected error for malformed import ID")
+	}
+}
@@ -1,8 +1,10 @@
 package garage
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"reflect"
@@ -12,6 +14,9 @@ import (
 	"unsafe"
 
 	garageapi "git.deuxfleurs.fr/garage-sdk/garage-admin-sdk-golang"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
@@ -22,7 +27,7 @@ func TestBuildWebsiteAccessRequiresIndex(t *testing.T) {
 		"website_access_enabled": true,
 	})
 
-	wa, diags := buildWebsiteAccess(data)
+	wa, diags := buildWebsiteAccess(data, "")
 	if wa != nil {
 		t.Fatalf("expected nil website access when missing index document, got %#v", wa)
 	}
@@ -31,13 +36,47 @@ func TestBuildWebsiteAccessRequiresIndex(t *testing.T) {
 	}
 }
 
+func TestBuildWebsiteAccessProviderDefaultIndex(t *testing.T) {
+	res := resourceBucket()
+	data := schema.TestResourceDataRaw(t, res.Schema, map[string]interface{}{
+		"website_access_enabled": true,
+	})
+
+	wa, diags := buildWebsiteAccess(data, "index.html")
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %#v", diags)
+	}
+	if idx := wa.IndexDocument.Get(); idx == nil || *idx != "index.html" {
+		t.Fatalf("expected provider default index document, got %#v", idx)
+	}
+
+	// the bucket's own value wins over the default
+	data = schema.TestResourceDataRaw(t, res.Schema, map[string]interface{}{
+		"website_access_enabled":        true,
+		"website_config_index_document": "home.html",
+	})
+	wa, _ = buildWebsiteAccess(data, "index.html")
+	if idx := wa.IndexDocument.Get(); idx == nil || *idx != "home.html" {
+		t.Fatalf("expected bucket index document to override the default, got %#v", idx)
+	}
+
+	// plan-time check accepts the omitted index document when a default exists
+	conf := terraform.NewResourceConfigRaw(map[string]interface{}{"website_access_enabled": true})
+	if _, err := res.Diff(context.Background(), nil, conf, &garageProvider{defaultIndexDocument: "index.html"}); err != nil {
+		t.Fatalf("unexpected diff error with provider default: %v", err)
+	}
+	if _, err := res.Diff(context.Background(), nil, conf, &garageProvider{}); err == nil {
+		t.Fatalf("expected missing index document to be rejected without a default")
+	}
+}
+
 func TestBuildWebsiteAccessDisabled(t *testing.T) {
 	res := resourceBucket()
 	data := schema.TestResourceDataRaw(t, res.Schema, map[string]interface{}{
 		"website_access_enabled": false,
 	})
 
-	wa, diags := buildWebsiteAccess(data)
+	wa, diags := buildWebsiteAccess(data, "")
 	if len(diags) != 0 {
 		t.Fatalf("unexpected diagnostics: %#v", diags)
 	}
@@ -54,7 +93,7 @@ func TestBuildWebsiteAccessEnabled(t *testing.T) {
 		"website_config_error_document": "error.html",
 	})
 
-	wa, diags := buildWebsiteAccess(data)
+	wa, diags := buildWebsiteAccess(data, "")
 	if len(diags) != 0 {
 		t.Fatalf("unexpected diagnostics: %#v", diags)
 	}
@@ -69,6 +108,162 @@ func TestBuildWebsiteAccessEnabled(t *testing.T) {
 	}
 }
 
+func TestResourceBucketCustomizeDiffRejectsRedirect(t *testing.T) {
+	resource := resourceBucket()
+	conf := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"website_access_enabled":           true,
+		"website_config_index_document":    "index.html",
+		"website_redirect_all_requests_to": "www.example.com",
+	})
+	_, err := resource.Diff(context.Background(), nil, conf, nil)
+	if err == nil || !strings.Contains(err.Error(), "website_redirect_all_requests_to is not supported") {
+		t.Fatalf("expected redirect to be rejected, got %v", err)
+	}
+}
+
+func TestResourceBucketCustomizeDiffRejectsKeyGrantChange(t *testing.T) {
+	resource := resourceBucket()
+	grant := []interface{}{map[string]interface{}{"access_key_id": "key-1", "read": true}}
+
+	// set on create
+	if _, err := resource.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"key_grant": grant,
+	}), nil); err != nil {
+		t.Fatalf("expected key_grant to be accepted on create, got %v", err)
+	}
+
+	// added on update
+	state := &terraform.InstanceState{ID: "bucket-1", Attributes: map[string]string{"id": "bucket-1"}}
+	_, err := resource.Diff(context.Background(), state, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"key_grant": grant,
+	}), nil)
+	if err == nil || !strings.Contains(err.Error(), "key_grant cannot be changed") {
+		t.Fatalf("expected key_grant change to be rejected on update, got %v", err)
+	}
+
+	// unchanged after create
+	state.Attributes = map[string]string{
+		"id":                        "bucket-1",
+		"key_grant.#":               "1",
+		"key_grant.0.access_key_id": "key-1",
+		"key_grant.0.read":          "true",
+		"key_grant.0.write":         "false",
+		"key_grant.0.owner":         "false",
+	}
+	if _, err := resource.Diff(context.Background(), state, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"key_grant": grant,
+	}), nil); err != nil {
+		t.Fatalf("expected an unchanged key_grant to be accepted, got %v", err)
+	}
+}
+
+func TestResourceBucketCustomizeDiffGlobalAndLocalAlias(t *testing.T) {
+	resource := resourceBucket()
+	conf := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"global_alias": "shared",
+		"local_alias": []interface{}{
+			map[string]interface{}{"alias": "mine", "access_key_id": "key-123"},
+		},
+	})
+	if _, err := resource.Diff(context.Background(), nil, conf, nil); err != nil {
+		t.Fatalf("expected global and local alias to be accepted together, got %v", err)
+	}
+}
+
+func TestResourceBucketCustomizeDiffLifecycleRule(t *testing.T) {
+	resource := resourceBucket()
+	rule := []interface{}{map[string]interface{}{"prefix": "logs/", "expiration_days": 30}}
+
+	// set on create
+	_, err := resource.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"lifecycle_rule": rule,
+	}), nil)
+	if err == nil || !strings.Contains(err.Error(), "lifecycle_rule is not supported") {
+		t.Fatalf("expected lifecycle_rule to be rejected on create, got %v", err)
+	}
+
+	// added on update
+	state := &terraform.InstanceState{ID: "bucket-1", Attributes: map[string]string{"id": "bucket-1"}}
+	_, err = resource.Diff(context.Background(), state, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"lifecycle_rule": rule,
+	}), nil)
+	if err == nil || !strings.Contains(err.Error(), "lifecycle_rule is not supported") {
+		t.Fatalf("expected lifecycle_rule to be rejected on update, got %v", err)
+	}
+
+	// removed, or never set
+	if _, err := resource.Diff(context.Background(), state, terraform.NewResourceConfigRaw(map[string]interface{}{}), nil); err != nil {
+		t.Fatalf("expected a bucket without lifecycle_rule to be accepted, got %v", err)
+	}
+}
+
+func TestResourceBucketCustomizeDiffPublicRead(t *testing.T) {
+	resource := resourceBucket()
+
+	_, err := resource.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"public_read": true,
+	}), nil)
+	if err == nil || !strings.Contains(err.Error(), "public_read is not supported") {
+		t.Fatalf("expected public_read=true to be rejected, got %v", err)
+	}
+
+	if _, err := resource.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"public_read": false,
+	}), nil); err != nil {
+		t.Fatalf("expected public_read=false to be accepted, got %v", err)
+	}
+}
+
+func TestResourceBucketUpdateBodyOmitsPublicRead(t *testing.T) {
+	var body string
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		switch r.URL.Path {
+		case "/v2/UpdateBucket":
+			raw, _ := io.ReadAll(r.Body)
+			r.Body.Close()
+			body = string(raw)
+			return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(bucketInfoJSON("bucket", nil, 0)))}, nil
+		case "/v2/GetBucketInfo":
+			return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(bucketInfoJSON("bucket", nil, 0)))}, nil
+		default:
+			t.Fatalf("unexpected request %s", r.URL.Path)
+		}
+		return nil, nil
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourceBucket().Schema, map[string]interface{}{
+		"public_read":                   false,
+		"website_access_enabled":        true,
+		"website_config_index_document": "index.html",
+	})
+	d.SetId("bucket")
+
+	if diags := resourceBucketUpdate(context.Background(), d, p); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	if strings.Contains(strings.ToLower(body), "public") {
+		t.Fatalf("expected no public-read field in update body, got %s", body)
+	}
+	if !strings.Contains(body, "websiteAccess") {
+		t.Fatalf("expected website access in update body, got %s", body)
+	}
+}
+
+func TestResourceBucketCustomizeDiffRequiresIndex(t *testing.T) {
+	resource := resourceBucket()
+	conf := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"website_access_enabled":        true,
+		"website_config_error_document": "error.html",
+	})
+	_, err := resource.Diff(context.Background(), nil, conf, nil)
+	if err == nil {
+		t.Fatalf("expected diff to fail without index document or redirect")
+	}
+	if !strings.Contains(err.Error(), "website_config_index_document is required") {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
 func TestBuildQuotasValidation(t *testing.T) {
 	res := resourceBucket()
 
@@ -91,6 +286,103 @@ func TestBuildQuotasValidation(t *testing.T) {
 	}
 }
 
+func TestParseByteSize(t *testing.T) {
+	cases := map[string]int64{
+		"10GiB":   10 * 1 << 30,
+		"500MB":   500 * 1000 * 1000,
+		"1.5 KiB": 1536,
+		"42":      42,
+		"2tb":     2 * 1000 * 1000 * 1000 * 1000,
+	}
+	for in, want := range cases {
+		got, err := parseByteSize(in)
+		if err != nil {
+			t.Fatalf("parseByteSize(%q) returned error: %v", in, err)
+		}
+		if got != want {
+			t.Fatalf("parseByteSize(%q) = %d, want %d", in, got, want)
+		}
+	}
+
+	for _, in := range []string{"10XB", "GiB", "", "0.5B", "-1GiB"} {
+		if _, err := parseByteSize(in); err == nil {
+			t.Fatalf("expected parseByteSize(%q) to fail", in)
+		}
+	}
+}
+
+func TestBuildQuotasHumanSize(t *testing.T) {
+	res := resourceBucket()
+	data := schema.TestResourceDataRaw(t, res.Schema, map[string]interface{}{
+		"quotas": []interface{}{
+			map[string]interface{}{
+				"max_size_human": "10GiB",
+				"max_objects":    5,
+			},
+		},
+	})
+
+	quotas, diags := buildQuotas(data)
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %#v", diags)
+	}
+	if got := quotas.GetMaxSize(); got != 10737418240 {
+		t.Fatalf("expected 10GiB in bytes, got %d", got)
+	}
+}
+
+func TestResourceBucketQuotaSizeConflicts(t *testing.T) {
+	res := resourceBucket()
+	diags := res.Validate(terraform.NewResourceConfigRaw(map[string]interface{}{
+		"quotas": []interface{}{
+			map[string]interface{}{
+				"max_size":       1024,
+				"max_size_human": "1KiB",
+			},
+		},
+	}))
+	if !diags.HasError() {
+		t.Fatalf("expected max_size and max_size_human to conflict")
+	}
+
+	diags = res.Validate(terraform.NewResourceConfigRaw(map[string]interface{}{
+		"quotas": []interface{}{
+			map[string]interface{}{"max_size_human": "10 bananas"},
+		},
+	}))
+	if !diags.HasError() {
+		t.Fatalf("expected invalid unit to be rejected")
+	}
+}
+
+func TestPreserveQuotaSizeHuman(t *testing.T) {
+	res := resourceBucket()
+	data := schema.TestResourceDataRaw(t, res.Schema, map[string]interface{}{
+		"quotas": []interface{}{
+			map[string]interface{}{"max_size_human": "500MB", "max_objects": 1},
+		},
+	})
+
+	flat := map[string]interface{}{
+		"quotas": []interface{}{map[string]interface{}{"max_size": 500000000, "max_objects": 1}},
+	}
+	preserveQuotaSizeHuman(data, flat)
+	q := flat["quotas"].([]interface{})[0].(map[string]interface{})
+	if q["max_size_human"] != "500MB" {
+		t.Fatalf("expected configured human size to be kept, got %#v", q)
+	}
+
+	// quota changed out of band: drop the stale human value
+	flat = map[string]interface{}{
+		"quotas": []interface{}{map[string]interface{}{"max_size": 1, "max_objects": 1}},
+	}
+	preserveQuotaSizeHuman(data, flat)
+	q = flat["quotas"].([]interface{})[0].(map[string]interface{})
+	if _, ok := q["max_size_human"]; ok {
+		t.Fatalf("expected stale human size to be dropped, got %#v", q)
+	}
+}
+
 func TestFlattenBucketInfo(t *testing.T) {
 	now := time.Now().UTC()
 	quotas := garageapi.ApiBucketQuotas{}
@@ -143,6 +435,179 @@ func TestFlattenBucketInfo(t *testing.T) {
 	}
 }
 
+func TestFlattenBucketInfoQuotaUsage(t *testing.T) {
+	quotas := garageapi.ApiBucketQuotas{}
+	quotas.SetMaxSize(1000)
+	quotas.SetMaxObjects(8)
+
+	bucket := garageapi.NewGetBucketInfoResponse(
+		250,
+		time.Now().UTC(),
+		[]string{},
+		"bucket-id",
+		[]garageapi.GetBucketInfoKey{},
+		2,
+		quotas,
+		0, 0, 0, 0,
+		false,
+	)
+
+	flat := flattenBucketInfo(bucket)
+	if v := flat["quota_size_used_percent"].(float64); v != 25 {
+		t.Fatalf("expected 25%% size usage, got %v", v)
+	}
+	if v := flat["quota_objects_used_percent"].(float64); v != 25 {
+		t.Fatalf("expected 25%% object usage, got %v", v)
+	}
+	if !flat["quotas_enabled"].(bool) {
+		t.Fatalf("expected quotas_enabled with quotas set")
+	}
+
+	res := resourceBucket()
+	d := schema.TestResourceDataRaw(t, res.Schema, map[string]interface{}{})
+	for k, v := range flat {
+		if err := d.Set(k, v); err != nil {
+			t.Fatalf("set %s: %v", k, err)
+		}
+	}
+}
+
+func TestResourceBucketReadWarnsAboveUsageThreshold(t *testing.T) {
+	quotas := garageapi.ApiBucketQuotas{}
+	quotas.SetMaxSize(1000)
+	quotas.SetMaxObjects(8)
+	bucket := garageapi.NewGetBucketInfoResponse(
+		900,
+		time.Now().UTC(),
+		[]string{},
+		"bucket-id",
+		[]garageapi.GetBucketInfoKey{},
+		2,
+		quotas,
+		0, 0, 0, 0,
+		false,
+	)
+	payload, err := json.Marshal(bucket)
+	if err != nil {
+		t.Fatalf("marshal bucket: %v", err)
+	}
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(string(payload)))}, nil
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourceBucket().Schema, map[string]interface{}{
+		"warn_at_size_percent":    80,
+		"warn_at_objects_percent": 80,
+	})
+	d.SetId("bucket-id")
+
+	diags := resourceBucketRead(context.Background(), d, p)
+	// 90% of the size quota is used, only 25% of the object quota
+	if len(diags) != 1 || diags[0].Severity != diag.Warning {
+		t.Fatalf("expected a single warning, got %#v", diags)
+	}
+	if !strings.Contains(diags[0].Detail, "max_size") || !strings.Contains(diags[0].Detail, "90.0%") {
+		t.Fatalf("unexpected warning detail %q", diags[0].Detail)
+	}
+	if d.Get("bytes").(int) != 900 {
+		t.Fatalf("expected state to be refreshed despite the warning, got bytes=%v", d.Get("bytes"))
+	}
+}
+
+func TestFlattenBucketInfoQuotaUsageWithoutQuota(t *testing.T) {
+	bucket := garageapi.NewGetBucketInfoResponse(
+		250,
+		time.Now().UTC(),
+		[]string{},
+		"bucket-id",
+		[]garageapi.GetBucketInfoKey{},
+		2,
+		garageapi.ApiBucketQuotas{},
+		0, 0, 0, 0,
+		false,
+	)
+
+	flat := flattenBucketInfo(bucket)
+	if flat["quota_size_used_percent"].(float64) != 0 || flat["quota_objects_used_percent"].(float64) != 0 {
+		t.Fatalf("expected zero usage without quotas, got %#v", flat)
+	}
+	if flat["quotas_enabled"].(bool) {
+		t.Fatalf("expected quotas_enabled=false without quotas")
+	}
+	if usedPercent(10, 0) != 0 {
+		t.Fatalf("expected zero limit to yield zero percent")
+	}
+}
+
+func TestFlattenBucketInfoQuotasEnabledIgnoresZero(t *testing.T) {
+	quotas := garageapi.ApiBucketQuotas{}
+	quotas.SetMaxSize(0)
+	quotas.SetMaxObjects(3)
+
+	bucket := garageapi.NewGetBucketInfoResponse(0, time.Now().UTC(), []string{}, "bucket-id", []garageapi.GetBucketInfoKey{}, 0, quotas, 0, 0, 0, 0, false)
+	if !flattenBucketInfo(bucket)["quotas_enabled"].(bool) {
+		t.Fatalf("expected a positive max_objects alone to enable quotas")
+	}
+
+	quotas.SetMaxObjects(0)
+	bucket.Quotas = quotas
+	if flattenBucketInfo(bucket)["quotas_enabled"].(bool) {
+		t.Fatalf("expected zero quotas to count as disabled")
+	}
+}
+
+func TestIndirectString(t *testing.T) {
+	value := "index.html"
+	unset := garageapi.NullableString{}
+	var nilPtr *string
+	var nilNullable *garageapi.NullableString
+
+	cases := []struct {
+		name string
+		in   interface{}
+		want string
+	}{
+		{"plain", value, "index.html"},
+		{"pointer", &value, "index.html"},
+		{"nil pointer", nilPtr, ""},
+		{"nullable", *garageapi.NewNullableString(&value), "index.html"},
+		{"nullable pointer", garageapi.NewNullableString(&value), "index.html"},
+		{"nullable unset", unset, ""},
+		{"nil nullable pointer", nilNullable, ""},
+		{"nil", nil, ""},
+	}
+	for _, tc := range cases {
+		if got := indirectString(tc.in); got != tc.want {
+			t.Fatalf("%s: expected %q, got %q", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestGetBool(t *testing.T) {
+	for _, v := range []interface{}{nil, "true", 1} {
+		if asBool(v) {
+			t.Fatalf("expected %#v to coerce to false", v)
+		}
+	}
+	if !asBool(true) {
+		t.Fatalf("expected true to stay true")
+	}
+
+	data := schema.TestResourceDataRaw(t, resourceBucketKey().Schema, map[string]interface{}{
+		"write": true,
+	})
+	if getBool(data, "read") || !getBool(data, "write") {
+		t.Fatalf("expected unset read=false and write=true")
+	}
+	// not in the schema: d.Get returns nil
+	if getBool(data, "missing") {
+		t.Fatalf("expected unknown attribute to read as false")
+	}
+	if got := desiredBucketKeyPermissions(data); got != (bucketKeyPermissions{Write: true}) {
+		t.Fatalf("unexpected desired permissions %#v", got)
+	}
+}
+
 func TestGetOkString(t *testing.T) {
 	res := resourceBucket()
 	data := schema.TestResourceDataRaw(t, res.Schema, map[string]interface{}{})
@@ -386,63 +851,325 @@ func TestResourceBucketCreateSuccess(t *testing.T) {
 	}
 }
 
-func TestResourceBucketCreateError(t *testing.T) {
-	step := 0
+func TestResourceBucketCreateGlobalAndLocalAlias(t *testing.T) {
+	var body map[string]interface{}
 	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
-		if step > 0 {
-			t.Fatalf("unexpected extra request %s", r.URL.Path)
-		}
-		step++
-		if r.URL.Path != "/v2/CreateBucket" {
-			t.Fatalf("unexpected path %s", r.URL.Path)
+		switch r.URL.Path {
+		case "/v2/CreateBucket":
+			raw, _ := io.ReadAll(r.Body)
+			r.Body.Close()
+			if err := json.Unmarshal(raw, &body); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+		case "/v2/GetBucketInfo":
+		default:
+			t.Fatalf("unexpected request %s", r.URL.Path)
 		}
 		return &http.Response{
-			StatusCode: http.StatusInternalServerError,
-			Status:     "500 Internal Server Error",
-			Body:       io.NopCloser(strings.NewReader("boom")),
-			Header:     make(http.Header),
+			StatusCode: http.StatusOK,
+			Status:     "200 OK",
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(bucketInfoJSON("bucket-id", []string{"shared"}, 0))),
 		}, nil
 	}))
 
-	raw := map[string]interface{}{
-		"global_alias": "alias",
+	d := schema.TestResourceDataRaw(t, resourceBucket().Schema, map[string]interface{}{
+		"global_alias": "shared",
+		"local_alias": []interface{}{
+			map[string]interface{}{"alias": "mine", "access_key_id": "key-123"},
+		},
+	})
+	if diags := resourceBucketCreate(context.Background(), d, p); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
 	}
-	d := schema.TestResourceDataRaw(t, resourceBucket().Schema, raw)
 
-	diags := resourceBucketCreate(context.Background(), d, p)
-	if len(diags) == 0 {
-		t.Fatalf("expected diagnostics on create error")
-	}
-	if d.Id() != "" {
-		t.Fatalf("expected resource ID to remain unset, got %s", d.Id())
+	// both aliases travel in the single CreateBucket request
+	if body["globalAlias"] != "shared" {
+		t.Fatalf("expected globalAlias in the CreateBucket body, got %#v", body)
 	}
-	if step != 1 {
-		t.Fatalf("expected single API call, got %d", step)
+	local, _ := body["localAlias"].(map[string]interface{})
+	if local["alias"] != "mine" || local["accessKeyId"] != "key-123" {
+		t.Fatalf("expected localAlias in the CreateBucket body, got %#v", body)
 	}
 }
 
-func TestResourceBucketUpdateRenameGlobalAlias(t *testing.T) {
-	bucketID := "bucket"
-	oldAlias := "old"
-	newAlias := "new"
-	step := 0
+func TestResourceBucketCreateWithKeyGrants(t *testing.T) {
+	bucketID := "bucket-id"
+	var allowBodies []string
 	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
-		switch step {
-		case 0:
-			step++
-			if r.URL.Path != "/v2/AddBucketAlias" {
-				t.Fatalf("unexpected path %s", r.URL.Path)
-			}
+		payload := "null"
+		switch r.URL.Path {
+		case "/v2/CreateBucket", "/v2/GetBucketInfo":
+			payload = bucketInfoJSON(bucketID, []string{"site"}, 0)
+		case "/v2/AllowBucketKey":
 			body, _ := io.ReadAll(r.Body)
 			r.Body.Close()
-			if !strings.Contains(string(body), newAlias) {
-				t.Fatalf("expected new alias in body %s", body)
-			}
-			return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader("null"))}, nil
-		case 1:
-			step++
-			if r.URL.Path != "/v2/RemoveBucketAlias" {
-				t.Fatalf("unexpected path %s", r.URL.Path)
+			allowBodies = append(allowBodies, string(body))
+			payload = bucketInfoJSON(bucketID, []string{"site"}, 0)
+		default:
+			t.Fatalf("unexpected request %s", r.URL.Path)
+		}
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(payload))}, nil
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourceBucket().Schema, map[string]interface{}{
+		"global_alias": "site",
+		"key_grant": []interface{}{
+			map[string]interface{}{"access_key_id": "GKreader", "read": true},
+			map[string]interface{}{"access_key_id": "GKowner", "read": true, "write": true, "owner": true},
+		},
+	})
+	if diags := resourceBucketCreate(context.Background(), d, p); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+
+	if len(allowBodies) != 2 {
+		t.Fatalf("expected one AllowBucketKey per grant, got %v", allowBodies)
+	}
+	for i, keyID := range []string{"GKreader", "GKowner"} {
+		if !strings.Contains(allowBodies[i], keyID) || !strings.Contains(allowBodies[i], bucketID) {
+			t.Fatalf("expected grant for %s on %s, got %s", keyID, bucketID, allowBodies[i])
+		}
+	}
+	if strings.Contains(allowBodies[0], `"owner":true`) || !strings.Contains(allowBodies[1], `"owner":true`) {
+		t.Fatalf("expected owner only on the second grant, got %v", allowBodies)
+	}
+}
+
+func TestResourceBucketCreateRejectsEmptyKeyGrant(t *testing.T) {
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		t.Fatalf("unexpected request %s", r.URL.Path)
+		return nil, nil
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourceBucket().Schema, map[string]interface{}{
+		"key_grant": []interface{}{
+			map[string]interface{}{"access_key_id": "GKnone"},
+		},
+	})
+	diags := resourceBucketCreate(context.Background(), d, p)
+	if !diags.HasError() || diags[0].Summary != "invalid key_grant" {
+		t.Fatalf("expected invalid key_grant before creating the bucket, got %#v", diags)
+	}
+}
+
+func TestResourceBucketCreateAdoptExisting(t *testing.T) {
+	var paths []string
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		paths = append(paths, r.URL.Path)
+		switch r.URL.Path {
+		case "/v2/CreateBucket":
+			return &http.Response{StatusCode: http.StatusConflict, Status: "409 Conflict", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(`{"code":"BucketAlreadyExists","message":"Bucket already exists"}`))}, nil
+		case "/v2/GetBucketInfo":
+			if got := r.URL.Query().Get("globalAlias"); got != "" && got != "shared" {
+				t.Fatalf("unexpected alias lookup %q", got)
+			}
+			return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(bucketInfoJSON("existing-id", []string{"shared"}, 0)))}, nil
+		default:
+			t.Fatalf("unexpected request %s", r.URL.Path)
+		}
+		return nil, nil
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourceBucket().Schema, map[string]interface{}{
+		"global_alias":   "shared",
+		"adopt_existing": true,
+	})
+
+	if diags := resourceBucketCreate(context.Background(), d, p); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	if d.Id() != "existing-id" {
+		t.Fatalf("expected adopted bucket id, got %q", d.Id())
+	}
+	if len(paths) != 3 {
+		t.Fatalf("expected create, lookup and read, got %v", paths)
+	}
+}
+
+func TestResourceBucketCreateConflictWithoutAdopt(t *testing.T) {
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path != "/v2/CreateBucket" {
+			t.Fatalf("unexpected request %s", r.URL.Path)
+		}
+		return &http.Response{StatusCode: http.StatusConflict, Status: "409 Conflict", Header: make(http.Header), Body: io.NopCloser(strings.NewReader("exists"))}, nil
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourceBucket().Schema, map[string]interface{}{
+		"global_alias": "shared",
+	})
+
+	if diags := resourceBucketCreate(context.Background(), d, p); len(diags) == 0 {
+		t.Fatalf("expected conflict to fail without adopt_existing")
+	}
+	if d.Id() != "" {
+		t.Fatalf("expected no id, got %q", d.Id())
+	}
+}
+
+func TestResourceBucketCreateError(t *testing.T) {
+	step := 0
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		if step > 0 {
+			t.Fatalf("unexpected extra request %s", r.URL.Path)
+		}
+		step++
+		if r.URL.Path != "/v2/CreateBucket" {
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+		return &http.Response{
+			StatusCode: http.StatusInternalServerError,
+			Status:     "500 Internal Server Error",
+			Body:       io.NopCloser(strings.NewReader("boom")),
+			Header:     make(http.Header),
+		}, nil
+	}))
+
+	raw := map[string]interface{}{
+		"global_alias": "alias",
+	}
+	d := schema.TestResourceDataRaw(t, resourceBucket().Schema, raw)
+
+	diags := resourceBucketCreate(context.Background(), d, p)
+	if len(diags) == 0 {
+		t.Fatalf("expected diagnostics on create error")
+	}
+	if d.Id() != "" {
+		t.Fatalf("expected resource ID to remain unset, got %s", d.Id())
+	}
+	if step != 1 {
+		t.Fatalf("expected single API call, got %d", step)
+	}
+}
+
+func TestPlanAliasChanges(t *testing.T) {
+	cases := []struct {
+		oldAlias, newAlias string
+		want               []string
+	}{
+		{"old", "new", []string{"add:new", "remove:old"}},
+		{"", "new", []string{"add:new"}},
+		{"old", "", []string{"remove:old"}},
+		{"same", "same", []string{"add:same"}},
+	}
+	for _, tc := range cases {
+		var got []string
+		for _, op := range planAliasChanges(tc.oldAlias, tc.newAlias) {
+			got = append(got, op.String())
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("planAliasChanges(%q, %q) = %v, want %v", tc.oldAlias, tc.newAlias, got, tc.want)
+		}
+	}
+}
+
+func TestResourceBucketCustomizeDiffAliasChangePlan(t *testing.T) {
+	resource := resourceBucket()
+	state := &terraform.InstanceState{
+		ID: "bucket",
+		Attributes: map[string]string{
+			"id":                     "bucket",
+			"global_alias":           "old",
+			"website_access_enabled": "false",
+		},
+	}
+	conf := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"global_alias": "new",
+	})
+
+	diff, err := resource.Diff(context.Background(), state, conf, nil)
+	if err != nil {
+		t.Fatalf("unexpected diff error: %v", err)
+	}
+	if diff == nil {
+		t.Fatalf("expected a diff for the alias rename")
+	}
+	want := map[string]string{
+		"alias_change_plan.#": "2",
+		"alias_change_plan.0": "add:new",
+		"alias_change_plan.1": "remove:old",
+	}
+	for k, v := range want {
+		attr := diff.Attributes[k]
+		if attr == nil || attr.New != v {
+			t.Fatalf("expected %s=%q in planned diff, got %#v", k, v, attr)
+		}
+	}
+}
+
+func TestResourceBucketUpdateKeepsAliasChangePlan(t *testing.T) {
+	aliases := []string{"old"}
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		payload := "null"
+		switch r.URL.Path {
+		case "/v2/GetBucketInfo":
+			payload = bucketInfoJSON("bucket", aliases, 0)
+		case "/v2/AddBucketAlias", "/v2/RemoveBucketAlias", "/v2/UpdateBucket":
+			aliases = []string{"new"}
+		default:
+			t.Fatalf("unexpected request %s", r.URL.Path)
+		}
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(payload))}, nil
+	}))
+
+	resource := resourceBucket()
+	state := &terraform.InstanceState{
+		ID: "bucket",
+		Attributes: map[string]string{
+			"id":                     "bucket",
+			"global_alias":           "old",
+			"website_access_enabled": "false",
+		},
+	}
+	diff, err := resource.Diff(context.Background(), state, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"global_alias": "new",
+	}), p)
+	if err != nil {
+		t.Fatalf("unexpected diff error: %v", err)
+	}
+	d, err := schema.InternalMap(resource.Schema).Data(state, diff)
+	if err != nil {
+		t.Fatalf("data: %v", err)
+	}
+	planned := d.Get("alias_change_plan").([]interface{})
+
+	if diags := resourceBucketUpdate(context.Background(), d, p); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	// the applied value must match the plan, or Terraform reports an inconsistent result
+	if got := d.Get("alias_change_plan").([]interface{}); len(planned) != 2 || !reflect.DeepEqual(got, planned) {
+		t.Fatalf("expected the planned operations %v to be kept, got %v", planned, got)
+	}
+}
+
+func TestResourceBucketUpdateRenameGlobalAlias(t *testing.T) {
+	bucketID := "bucket"
+	oldAlias := "old"
+	newAlias := "new"
+	step := 0
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		switch step {
+		case 0:
+			step++
+			if r.URL.Path != "/v2/GetBucketInfo" {
+				t.Fatalf("unexpected path %s", r.URL.Path)
+			}
+			return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(bucketInfoJSON(bucketID, []string{oldAlias}, 0)))}, nil
+		case 1:
+			step++
+			if r.URL.Path != "/v2/AddBucketAlias" {
+				t.Fatalf("unexpected path %s", r.URL.Path)
+			}
+			body, _ := io.ReadAll(r.Body)
+			r.Body.Close()
+			if !strings.Contains(string(body), newAlias) {
+				t.Fatalf("expected new alias in body %s", body)
+			}
+			return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader("null"))}, nil
+		case 2:
+			step++
+			if r.URL.Path != "/v2/RemoveBucketAlias" {
+				t.Fatalf("unexpected path %s", r.URL.Path)
 			}
 			body, _ := io.ReadAll(r.Body)
 			r.Body.Close()
@@ -450,13 +1177,13 @@ func TestResourceBucketUpdateRenameGlobalAlias(t *testing.T) {
 				t.Fatalf("expected old alias in body %s", body)
 			}
 			return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader("null"))}, nil
-		case 2:
+		case 3:
 			step++
 			if r.URL.Path != "/v2/UpdateBucket" {
 				t.Fatalf("unexpected path %s", r.URL.Path)
 			}
 			return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader("null"))}, nil
-		case 3:
+		case 4:
 			if r.URL.Path != "/v2/GetBucketInfo" {
 				t.Fatalf("unexpected path %s", r.URL.Path)
 			}
@@ -481,6 +1208,117 @@ func TestResourceBucketUpdateRenameGlobalAlias(t *testing.T) {
 	}
 }
 
+func TestResourceBucketUpdateRenameSkipsExistingAlias(t *testing.T) {
+	bucketID := "bucket"
+	var paths []string
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		paths = append(paths, r.URL.Path)
+		payload := "null"
+		switch r.URL.Path {
+		case "/v2/GetBucketInfo":
+			// the new alias was added by an earlier apply that failed afterwards
+			payload = bucketInfoJSON(bucketID, []string{"old", "new"}, 0)
+		case "/v2/RemoveBucketAlias":
+			body, _ := io.ReadAll(r.Body)
+			r.Body.Close()
+			if !strings.Contains(string(body), `"old"`) {
+				t.Fatalf("expected old alias in body %s", body)
+			}
+		case "/v2/UpdateBucket":
+		default:
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(payload))}, nil
+	}))
+
+	d := prepareBucketData(t, bucketID, "old", "new")
+	if diags := resourceBucketUpdate(context.Background(), d, p); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	for _, path := range paths {
+		if path == "/v2/AddBucketAlias" {
+			t.Fatalf("expected add to be skipped, got requests %v", paths)
+		}
+	}
+	if len(paths) < 2 || paths[1] != "/v2/RemoveBucketAlias" {
+		t.Fatalf("expected the old alias to be removed, got requests %v", paths)
+	}
+}
+
+func TestResourceBucketUpdateClearGlobalAlias(t *testing.T) {
+	bucketID := "bucket"
+	removed := false
+	var paths []string
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		paths = append(paths, r.URL.Path)
+		payload := "null"
+		switch r.URL.Path {
+		case "/v2/RemoveBucketAlias":
+			body, _ := io.ReadAll(r.Body)
+			r.Body.Close()
+			if !strings.Contains(string(body), `"old"`) {
+				t.Fatalf("expected old alias in body %s", body)
+			}
+			removed = true
+		case "/v2/GetBucketInfo":
+			if !removed {
+				t.Fatalf("bucket read before the alias was removed")
+			}
+			payload = bucketInfoJSON(bucketID, nil, 0)
+		case "/v2/UpdateBucket":
+		default:
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(payload))}, nil
+	}))
+
+	var logs bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &logs)
+
+	d := prepareBucketData(t, bucketID, "old", "")
+	if diags := resourceBucketUpdate(ctx, d, p); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	if len(paths) < 2 || paths[0] != "/v2/RemoveBucketAlias" || paths[1] != "/v2/GetBucketInfo" {
+		t.Fatalf("expected removal then a confirmation read, got requests %v", paths)
+	}
+	if got := d.Get("global_alias").(string); got != "" {
+		t.Fatalf("expected no global alias, got %q", got)
+	}
+
+	entries, err := tflogtest.MultilineJSONDecode(&logs)
+	if err != nil {
+		t.Fatalf("decoding logs: %v", err)
+	}
+	for _, e := range entries {
+		if e["@level"] == "warn" && strings.Contains(fmt.Sprint(e["@message"]), "no global alias") {
+			return
+		}
+	}
+	t.Fatalf("expected a warning about the missing global alias, got %v", entries)
+}
+
+func TestResourceBucketUpdateClearGlobalAliasStillBound(t *testing.T) {
+	bucketID := "bucket"
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		payload := "null"
+		switch r.URL.Path {
+		case "/v2/RemoveBucketAlias":
+		case "/v2/GetBucketInfo":
+			payload = bucketInfoJSON(bucketID, []string{"old"}, 0)
+		default:
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(payload))}, nil
+	}))
+
+	d := prepareBucketData(t, bucketID, "old", "")
+	diags := resourceBucketUpdate(context.Background(), d, p)
+	if len(diags) != 1 || diags[0].Summary != "global alias not removed" {
+		t.Fatalf("expected global alias not removed error, got %#v", diags)
+	}
+}
+
 func TestResourceBucketUpdateWebsiteAndQuotas(t *testing.T) {
 	bucketID := "bucket"
 	step := 0
@@ -525,6 +1363,179 @@ func TestResourceBucketUpdateWebsiteAndQuotas(t *testing.T) {
 	}
 }
 
+func TestResourceBucketUpdateDisableWebsiteClearsConfig(t *testing.T) {
+	var updateBody string
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		switch r.URL.Path {
+		case "/v2/UpdateBucket":
+			body, _ := io.ReadAll(r.Body)
+			r.Body.Close()
+			updateBody = string(body)
+			return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader("null"))}, nil
+		case "/v2/GetBucketInfo":
+			return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(bucketInfoJSON("bucket", []string{}, 0)))}, nil
+		default:
+			t.Fatalf("unexpected request %s", r.URL.Path)
+		}
+		return nil, nil
+	}))
+
+	res := resourceBucket()
+	state := &terraform.InstanceState{
+		ID: "bucket",
+		Attributes: map[string]string{
+			"id":                            "bucket",
+			"website_access_enabled":        "true",
+			"website_config_index_document": "index.html",
+			"website_config_error_document": "error.html",
+		},
+	}
+	conf := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"website_access_enabled": false,
+	})
+	diff, err := res.Diff(context.Background(), state, conf, nil)
+	if err != nil {
+		t.Fatalf("unexpected diff error: %v", err)
+	}
+	d, err := schema.InternalMap(res.Schema).Data(state, diff)
+	if err != nil {
+		t.Fatalf("unexpected error building resource data: %v", err)
+	}
+
+	if diags := resourceBucketUpdate(context.Background(), d, p); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(updateBody), &body); err != nil {
+		t.Fatalf("expected UpdateBucket to be called with a JSON body, got %q", updateBody)
+	}
+	want := `{"enabled":false,"errorDocument":null,"indexDocument":null}`
+	if got := string(body["websiteAccess"]); got != want {
+		t.Fatalf("expected websiteAccess %s, got %s", want, got)
+	}
+}
+
+func TestResourceBucketUpdateErrorDocumentOnlyKeepsIndex(t *testing.T) {
+	websiteInfo := `{"bytes":0,"created":"2025-01-01T00:00:00Z","globalAliases":[],"id":"bucket","keys":[],"objects":0,"quotas":{},"unfinishedMultipartUploadBytes":0,"unfinishedMultipartUploadParts":0,"unfinishedMultipartUploads":0,"unfinishedUploads":0,"websiteAccess":true,"websiteConfig":{"indexDocument":"home.html","errorDocument":"old.html"}}`
+	var updateBody string
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		switch r.URL.Path {
+		case "/v2/UpdateBucket":
+			body, _ := io.ReadAll(r.Body)
+			r.Body.Close()
+			updateBody = string(body)
+			return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader("null"))}, nil
+		case "/v2/GetBucketInfo":
+			return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(websiteInfo))}, nil
+		default:
+			t.Fatalf("unexpected request %s", r.URL.Path)
+		}
+		return nil, nil
+	}))
+
+	res := resourceBucket()
+	state := &terraform.InstanceState{
+		ID: "bucket",
+		Attributes: map[string]string{
+			"id":                     "bucket",
+			"website_access_enabled": "true",
+			// state lags behind the server; the server value must win
+			"website_config_index_document": "index.html",
+			"website_config_error_document": "old.html",
+		},
+	}
+	conf := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"website_access_enabled":        true,
+		"website_config_error_document": "new.html",
+	})
+	diff, err := res.Diff(context.Background(), state, conf, nil)
+	if err != nil {
+		t.Fatalf("unexpected diff error: %v", err)
+	}
+	d, err := schema.InternalMap(res.Schema).Data(state, diff)
+	if err != nil {
+		t.Fatalf("unexpected error building resource data: %v", err)
+	}
+
+	if diags := resourceBucketUpdate(context.Background(), d, p); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(updateBody), &body); err != nil {
+		t.Fatalf("expected UpdateBucket to be called with a JSON body, got %q", updateBody)
+	}
+	want := `{"enabled":true,"errorDocument":"new.html","indexDocument":"home.html"}`
+	if got := string(body["websiteAccess"]); got != want {
+		t.Fatalf("expected websiteAccess %s, got %s", want, got)
+	}
+}
+
+func TestResourceBucketUpdateWebsiteOnlyOmitsQuotas(t *testing.T) {
+	var updateBody string
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		switch r.URL.Path {
+		case "/v2/UpdateBucket":
+			body, _ := io.ReadAll(r.Body)
+			r.Body.Close()
+			updateBody = string(body)
+			return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader("null"))}, nil
+		case "/v2/GetBucketInfo":
+			return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(bucketInfoJSON("bucket", []string{}, 0)))}, nil
+		default:
+			t.Fatalf("unexpected request %s", r.URL.Path)
+		}
+		return nil, nil
+	}))
+
+	res := resourceBucket()
+	state := &terraform.InstanceState{
+		ID: "bucket",
+		Attributes: map[string]string{
+			"id":                            "bucket",
+			"website_access_enabled":        "false",
+			"quotas.#":                      "1",
+			"quotas.0.max_size":             "1024",
+			"quotas.0.max_objects":          "10",
+			"website_config_index_document": "",
+		},
+	}
+	conf := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"website_access_enabled":        true,
+		"website_config_index_document": "index.html",
+		"quotas": []interface{}{
+			map[string]interface{}{
+				"max_size":    1024,
+				"max_objects": 10,
+			},
+		},
+	})
+	diff, err := res.Diff(context.Background(), state, conf, nil)
+	if err != nil {
+		t.Fatalf("unexpected diff error: %v", err)
+	}
+	d, err := schema.InternalMap(res.Schema).Data(state, diff)
+	if err != nil {
+		t.Fatalf("unexpected error building resource data: %v", err)
+	}
+
+	if diags := resourceBucketUpdate(context.Background(), d, p); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(updateBody), &body); err != nil {
+		t.Fatalf("expected UpdateBucket to be called with a JSON body, got %q", updateBody)
+	}
+	if _, ok := body["websiteAccess"]; !ok {
+		t.Fatalf("expected websiteAccess in update body, got %s", updateBody)
+	}
+	if q, ok := body["quotas"]; ok && string(q) != "null" {
+		t.Fatalf("expected unchanged quotas to be omitted, got %s", updateBody)
+	}
+}
+
 func TestResourceBucketUpdateNoChange(t *testing.T) {
 	step := 0
 	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
@@ -615,3 +1626,174 @@ func TestResourceBucketDeleteError(t *testing.T) {
 		t.Fatalf("expected diagnostics on delete error")
 	}
 }
+
+func TestResourceBucketDeleteNotEmptyEnrichesDiagnostic(t *testing.T) {
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		switch r.URL.Path {
+		case "/v2/DeleteBucket":
+			return &http.Response{StatusCode: http.StatusBadRequest, Status: "400 Bad Request", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(`{"message":"Bucket is not empty"}`))}, nil
+		case "/v2/GetBucketInfo":
+			var info garageapi.GetBucketInfoResponse
+			if err := json.Unmarshal([]byte(bucketUsageJSON("bucket", nil, 42, 4096)), &info); err != nil {
+				t.Fatalf("decode fixture: %v", err)
+			}
+			info.UnfinishedUploads = 3
+			data, _ := json.Marshal(info)
+			return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(string(data)))}, nil
+		default:
+			t.Fatalf("unexpected request %s", r.URL.Path)
+		}
+		return nil, nil
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourceBucket().Schema, map[string]interface{}{})
+	d.SetId("bucket")
+
+	diags := resourceBucketDelete(context.Background(), d, p)
+	if len(diags) != 1 {
+		t.Fatalf("expected a single diagnostic, got %#v", diags)
+	}
+	detail := diags[0].Detail
+	for _, want := range []string{"Bucket is not empty", "42 objects", "4096 bytes", "3 unfinished uploads", "force_destroy"} {
+		if !strings.Contains(detail, want) {
+			t.Fatalf("expected detail to contain %q, got %q", want, detail)
+		}
+	}
+}
+
+func TestFlattenBucketInfoKeysWithoutPermissions(t *testing.T) {
+	keys := []garageapi.GetBucketInfoKey{
+		bucketInfoKey("GKreader", "reader", bucketKeyPermissions{Read: true}),
+		bucketInfoKey("GKalias", "alias-only", bucketKeyPermissions{}),
+		bucketInfoKey("GKowner", "owner", bucketKeyPermissions{Owner: true}),
+	}
+	bucket := garageapi.NewGetBucketInfoResponse(0, time.Now().UTC(), []string{}, "bucket-id", keys, 0, garageapi.ApiBucketQuotas{}, 0, 0, 0, 0, false)
+
+	got := flattenBucketInfo(bucket)["keys_without_permissions"].([]string)
+	if len(got) != 1 || got[0] != "GKalias" {
+		t.Fatalf("expected only the key without permissions to be listed, got %v", got)
+	}
+}
+
+func TestResourceBucketReadResponseTooLarge(t *testing.T) {
+	handler := keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Status:     "200 OK",
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(bucketInfoJSON("bucket-id", []string{"site"}, 50))),
+		}, nil
+	})
+	guard := &responseSizeTransport{base: handler, limit: 1024}
+	p := newTestProvider(guard.RoundTrip)
+
+	d := schema.TestResourceDataRaw(t, resourceBucket().Schema, map[string]interface{}{})
+	d.SetId("bucket-id")
+
+	diags := resourceBucketRead(context.Background(), d, p)
+	if !diags.HasError() || diags[0].Summary != "Garage API response too large" {
+		t.Fatalf("expected response size diagnostic, got %#v", diags)
+	}
+	if !strings.Contains(diags[0].Detail, "max_response_size") {
+		t.Fatalf("expected detail to mention max_response_size, got %q", diags[0].Detail)
+	}
+}
+
+func TestResourceBucketImportWebsiteEnabledNoDiff(t *testing.T) {
+	bucket := garageapi.NewGetBucketInfoResponse(0, time.Now().UTC(), []string{"site"}, "bucket-id", []garageapi.GetBucketInfoKey{}, 0, garageapi.ApiBucketQuotas{}, 0, 0, 0, 0, true)
+	bucket.WebsiteConfig = *garageapi.NewNullableGetBucketInfoWebsiteResponse(garageapi.NewGetBucketInfoWebsiteResponse("index.html"))
+	payload, err := json.Marshal(bucket)
+	if err != nil {
+		t.Fatalf("marshal bucket: %v", err)
+	}
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path != "/v2/GetBucketInfo" {
+			t.Fatalf("unexpected request %s", r.URL.Path)
+		}
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(string(payload)))}, nil
+	}))
+
+	res := resourceBucket()
+	d := res.TestResourceData()
+	d.SetId("bucket-id")
+	imported, err := res.Importer.StateContext(context.Background(), d, p)
+	if err != nil || len(imported) != 1 {
+		t.Fatalf("unexpected import result %v (%d)", err, len(imported))
+	}
+	if diags := resourceBucketRead(context.Background(), imported[0], p); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	state := imported[0].State()
+
+	diff, err := res.Diff(context.Background(), state, terraform.NewResourceConfigRaw(map[string]interface{}{}), nil)
+	if err != nil {
+		t.Fatalf("unexpected diff error: %v", err)
+	}
+	if diff != nil {
+		if attr, ok := diff.Attributes["website_access_enabled"]; ok {
+			t.Fatalf("expected no diff on website_access_enabled, got %#v", attr)
+		}
+	}
+
+	// an explicit false still disables hosting
+	diff, err = res.Diff(context.Background(), state, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"website_access_enabled": false,
+	}), nil)
+	if err != nil {
+		t.Fatalf("unexpected diff error: %v", err)
+	}
+	if diff == nil || diff.Attributes["website_access_enabled"] == nil || diff.Attributes["website_access_enabled"].New != "false" {
+		t.Fatalf("expected an explicit false to plan disabling hosting, got %#v", diff)
+	}
+}
+
+func TestResourceBucketWebsiteManagedElsewhereNoDrift(t *testing.T) {
+	bucket := garageapi.NewGetBucketInfoResponse(0, time.Now().UTC(), []string{"site"}, "bucket-id", []garageapi.GetBucketInfoKey{}, 0, garageapi.ApiBucketQuotas{}, 0, 0, 0, 0, true)
+	website := garageapi.NewGetBucketInfoWebsiteResponse("index.html")
+	website.ErrorDocument = *garageapi.NewNullableString(garageapi.PtrString("404.html"))
+	bucket.WebsiteConfig = *garageapi.NewNullableGetBucketInfoWebsiteResponse(website)
+	payload, err := json.Marshal(bucket)
+	if err != nil {
+		t.Fatalf("marshal bucket: %v", err)
+	}
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path != "/v2/GetBucketInfo" {
+			t.Fatalf("unexpected request %s", r.URL.Path)
+		}
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(string(payload)))}, nil
+	}))
+
+	res := resourceBucket()
+	d := schema.TestResourceDataRaw(t, res.Schema, map[string]interface{}{
+		"global_alias": "site",
+	})
+	d.SetId("bucket-id")
+	if diags := resourceBucketRead(context.Background(), d, p); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	state := d.State()
+	if state.Attributes["website_config_index_document"] != "index.html" || state.Attributes["website_config_error_document"] != "404.html" {
+		t.Fatalf("expected the server website config in state, got %v", state.Attributes)
+	}
+
+	// the configuration leaves the website to another resource
+	conf := map[string]interface{}{"global_alias": "site"}
+	state.RawConfig = cty.ObjectVal(map[string]cty.Value{
+		"global_alias":                     cty.StringVal("site"),
+		"website_access_enabled":           cty.NullVal(cty.Bool),
+		"website_config_index_document":    cty.NullVal(cty.String),
+		"website_config_error_document":    cty.NullVal(cty.String),
+		"website_redirect_all_requests_to": cty.NullVal(cty.String),
+	})
+	diff, err := res.Diff(context.Background(), state, terraform.NewResourceConfigRaw(conf), p)
+	if err != nil {
+		t.Fatalf("unexpected diff error: %v", err)
+	}
+	if diff != nil {
+		for _, k := range websiteFields {
+			if attr, ok := diff.Attributes[k]; ok {
+				t.Fatalf("expected no diff on %s, got %#v", k, attr)
+			}
+		}
+	}
+}
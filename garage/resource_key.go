@@ -2,13 +2,17 @@ package garage
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"reflect"
+	"strconv"
+	"strings"
 	"time"
 
 	garage "git.deuxfleurs.fr/garage-sdk/garage-admin-sdk-golang"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/id"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
@@ -18,33 +22,49 @@ Resource: garage_key
 Manages an access key via AccessKeyAPI:
   - Create: AccessKeyAPI.CreateKey(ctx).Body(UpdateKeyRequestBody).Execute()
   - Read:   AccessKeyAPI.GetKeyInfo(ctx).Id(id).Execute()
+    (with ShowSecretKey(true) when show_secret_on_read is set and state has no secret)
   - Update: AccessKeyAPI.UpdateKey(ctx).Id(id).UpdateKeyRequestBody(UpdateKeyRequestBody).Execute()
   - Delete: AccessKeyAPI.DeleteKey(ctx).Id(id).Execute()
+    (on 409 the key's bucket access is revoked via PermissionAPI.DenyBucketKey and the delete retried)
 
 Inputs:
   - name (optional)
   - expiration (optional RFC3339)
-  - permissions block with read/write/admin booleans (optional)
+  - expires_in (optional duration, resolved into expiration at create or when changed)
+  - permissions block with read/write/admin/create_bucket booleans (optional)
+  - show_secret_on_read (optional bool)
+  - metadata (optional map of strings; state-only, the admin API has no key metadata)
 
 Outputs:
   - id (access_key_id)
   - secret_access_key (sensitive, only available on create/read if API returns it)
+  - credentials_json (sensitive, access_key_id and secret_access_key as JSON, set on create only)
   - created (RFC3339, if available)
+  - age_days (whole days since created)
   - expired (bool)
+  - never_expires (bool, no expiration reported)
   - permissions (echoed)
 */
 
 func resourceKey() *schema.Resource {
+	annotate := withResourceDiagnostics("key", "name", "name_prefix")
 	return &schema.Resource{
 		Description:   "Manage a Garage access key.",
 		Schema:        schemaKey(),
-		CreateContext: resourceKeyCreate,
-		ReadContext:   resourceKeyRead,
-		UpdateContext: resourceKeyUpdate,
-		DeleteContext: resourceKeyDelete,
+		CreateContext: annotate(resourceKeyCreate),
+		ReadContext:   annotate(resourceKeyRead),
+		UpdateContext: annotate(resourceKeyUpdate),
+		DeleteContext: annotate(resourceKeyDelete),
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
+		CustomizeDiff: func(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+			// a new expires_in moves expiration to a time only known at apply
+			if d.Id() != "" && d.HasChange("expires_in") && d.Get("expires_in").(string) != "" {
+				return d.SetNewComputed("expiration")
+			}
+			return nil
+		},
 	}
 }
 
@@ -53,15 +73,42 @@ func schemaKey() map[string]*schema.Schema {
 		/* ------------------------------ Inputs ------------------------------ */
 
 		"name": {
-			Type:        schema.TypeString,
-			Optional:    true,
-			Description: "Human-friendly label for the access key. Does not affect permissions or behavior.",
+			Type:          schema.TypeString,
+			Optional:      true,
+			Computed:      true,
+			ConflictsWith: []string{"name_prefix"},
+			Description:   "Human-friendly label for the access key. Does not affect permissions or behavior. Computed when `name_prefix` is used. Because it is computed, removing `name` from the configuration keeps the current name instead of clearing it; set a new value to rename the key.",
+		},
+
+		"name_prefix": {
+			Type:          schema.TypeString,
+			Optional:      true,
+			ForceNew:      true,
+			ConflictsWith: []string{"name"},
+			Description:   "Generates a unique `name` starting with this prefix, for keys created with `count` or `for_each`. Changing it replaces the key.",
 		},
 
 		"expiration": {
-			Type:        schema.TypeString,
+			Type:          schema.TypeString,
+			Optional:      true,
+			Computed:      true,
+			ConflictsWith: []string{"expires_in"},
+			Description:   "Optional expiration timestamp in RFC3339 format (e.g. `2025-09-26T12:00:00Z`). After this time the key becomes invalid. Computed when `expires_in` is used. Read back from Garage, so an expiration changed outside Terraform shows as drift.",
+		},
+
+		"expires_in": {
+			Type:          schema.TypeString,
+			Optional:      true,
+			ConflictsWith: []string{"expiration"},
+			ValidateFunc:  validateExpiresIn,
+			Description:   "Expire the key this long after it is created or after `expires_in` is changed, e.g. `720h` or `90d`. Accepts Go durations plus a `d` suffix for days. The resulting time is stored in `expiration`; it is not moved on later applies.",
+		},
+
+		"show_secret_on_read": {
+			Type:        schema.TypeBool,
 			Optional:    true,
-			Description: "Optional expiration timestamp in RFC3339 format (e.g. `2025-09-26T12:00:00Z`). After this time the key becomes invalid.",
+			Default:     false,
+			Description: "Ask the admin API for the secret on refresh when `secret_access_key` is missing from state, e.g. after import. The secret is then stored in state.",
 		},
 
 		"permissions": {
@@ -72,24 +119,50 @@ func schemaKey() map[string]*schema.Schema {
 			Elem: &schema.Resource{
 				Schema: map[string]*schema.Schema{
 					"read": {
-						Type:        schema.TypeBool,
-						Optional:    true,
-						Description: "Allow read access to buckets and objects.",
+						Type:             schema.TypeBool,
+						Optional:         true,
+						DiffSuppressFunc: suppressAdminImpliedPerm,
+						Description:      "Allow read access to buckets and objects. Implied by `admin`.",
 					},
 					"write": {
-						Type:        schema.TypeBool,
-						Optional:    true,
-						Description: "Allow write access (create/update/delete objects).",
+						Type:             schema.TypeBool,
+						Optional:         true,
+						DiffSuppressFunc: suppressAdminImpliedPerm,
+						Description:      "Allow write access (create/update/delete objects). Implied by `admin`.",
 					},
 					"admin": {
 						Type:        schema.TypeBool,
 						Optional:    true,
-						Description: "Allow administrative access (bucket/key management).",
+						Description: "Allow administrative access (bucket/key management). Implies all other permissions.",
+					},
+					"create_bucket": {
+						Type:             schema.TypeBool,
+						Optional:         true,
+						DiffSuppressFunc: suppressAdminImpliedPerm,
+						Description:      "Allow the key to create new buckets. Implied by `admin`.",
 					},
 				},
 			},
 		},
 
+		"metadata": {
+			Type:        schema.TypeMap,
+			Optional:    true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Description: "Arbitrary labels for the key. The Garage admin API cannot store metadata on keys, so these are kept in Terraform state only: they are not sent to Garage, changing them makes no API call, and they are not recovered on import.",
+		},
+
+		"permission_set": {
+			Type:          schema.TypeSet,
+			Optional:      true,
+			ConflictsWith: []string{"permissions"},
+			Description:   "Shorthand for the `permissions` block: the permissions to grant, any of `read`, `write`, `admin` and `create_bucket`. Permissions not listed are denied, so an empty set denies all four.",
+			Elem: &schema.Schema{
+				Type:         schema.TypeString,
+				ValidateFunc: validateKeyPermissionName,
+			},
+		},
+
 		/* ------------------------------ Outputs ----------------------------- */
 
 		"access_key_id": {
@@ -105,10 +178,23 @@ func schemaKey() map[string]*schema.Schema {
 			Description: "Secret token associated with the key. Only visible at creation time — it will not be returned again.",
 		},
 
+		"credentials_json": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Sensitive:   true,
+			Description: "JSON object with `access_key_id` and `secret_access_key`, for passing both to another provider or a secret store in one value (e.g. with `jsondecode`). Only set when the key is created.",
+		},
+
 		"created": {
 			Type:        schema.TypeString,
 			Computed:    true,
-			Description: "Timestamp (RFC3339) when the key was created.",
+			Description: "Timestamp (RFC3339) when the key was created, in the provider `display_timezone` (UTC by default).",
+		},
+
+		"age_days": {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "Whole days elapsed since `created`, refreshed on read. `0` when the creation time is unknown.",
 		},
 
 		"expired": {
@@ -117,15 +203,54 @@ func schemaKey() map[string]*schema.Schema {
 			Description: "True if the key is expired according to its `expiration` setting.",
 		},
 
+		"never_expires": {
+			Type:        schema.TypeBool,
+			Computed:    true,
+			Description: "True if the server reports no expiration for the key. Unlike `expired`, this flags keys that stay valid indefinitely.",
+		},
+
+		"has_admin": {
+			Type:        schema.TypeBool,
+			Computed:    true,
+			Description: "True if the key's effective permissions include `admin`. Convenience for policy checks.",
+		},
+
 		"effective_permissions": {
 			Type:        schema.TypeList,
 			Computed:    true,
-			Description: "The effective permissions currently active for the key (read/write/admin).",
+			Description: "The effective permissions currently active for the key (read/write/admin/create_bucket).",
 			Elem: &schema.Resource{
 				Schema: map[string]*schema.Schema{
-					"read":  {Type: schema.TypeBool, Computed: true, Description: "Whether read access is enabled."},
-					"write": {Type: schema.TypeBool, Computed: true, Description: "Whether write access is enabled."},
-					"admin": {Type: schema.TypeBool, Computed: true, Description: "Whether admin access is enabled."},
+					"read":          {Type: schema.TypeBool, Computed: true, Description: "Whether read access is enabled."},
+					"write":         {Type: schema.TypeBool, Computed: true, Description: "Whether write access is enabled."},
+					"admin":         {Type: schema.TypeBool, Computed: true, Description: "Whether admin access is enabled."},
+					"create_bucket": {Type: schema.TypeBool, Computed: true, Description: "Whether bucket creation is enabled."},
+				},
+			},
+		},
+
+		"buckets": {
+			Type:        schema.TypeList,
+			Computed:    true,
+			Description: "Buckets this key can access, with their aliases and the key's permissions on each.",
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"id": {Type: schema.TypeString, Computed: true, Description: "Bucket ID."},
+					"global_aliases": {
+						Type:        schema.TypeList,
+						Computed:    true,
+						Elem:        &schema.Schema{Type: schema.TypeString},
+						Description: "Global aliases of the bucket.",
+					},
+					"local_aliases": {
+						Type:        schema.TypeList,
+						Computed:    true,
+						Elem:        &schema.Schema{Type: schema.TypeString},
+						Description: "Local aliases of the bucket scoped to this key.",
+					},
+					"read":  {Type: schema.TypeBool, Computed: true, Description: "Whether the key can read from the bucket."},
+					"write": {Type: schema.TypeBool, Computed: true, Description: "Whether the key can write to the bucket."},
+					"owner": {Type: schema.TypeBool, Computed: true, Description: "Whether the key owns the bucket."},
 				},
 			},
 		},
@@ -137,26 +262,53 @@ func schemaKey() map[string]*schema.Schema {
 func resourceKeyCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	p := m.(*garageProvider)
 
+	// generated once at create time; state keeps it from then on
+	if prefix, ok := getOkString(d, "name_prefix"); ok {
+		if err := d.Set("name", id.PrefixedUniqueId(prefix)); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	if diags := resolveExpiresIn(d, time.Now().In(p.location())); len(diags) > 0 {
+		return diags
+	}
+
 	body, diags := buildUpdateKeyRequestBody(d) // shape reused by Create
 	if len(diags) > 0 {
 		return diags
 	}
+	applyKeyNamePrefix(p, d, body)
 
 	resp, httpResp, err := p.client.AccessKeyAPI.
 		CreateKey(p.withToken(ctx)).
 		Body(*body).
 		Execute()
 	if err != nil {
+		if isAlreadyExists(err, httpResp) {
+			// the conflicting key's ID is not known here
+			return alreadyExistsDiagnostics("garage_key", "<access_key_id>", err, httpResp)
+		}
 		return createDiagnostics(err, httpResp)
 	}
+	if resp == nil {
+		return emptyResponseDiagnostics("CreateKey")
+	}
 
 	d.SetId(resp.GetAccessKeyId())
 	_ = d.Set("access_key_id", resp.GetAccessKeyId())
 	if s := safeGetStringPtr(resp.GetSecretAccessKeyOk()); s != "" {
 		_ = d.Set("secret_access_key", s)
+		creds, err := json.Marshal(map[string]string{
+			"access_key_id":     resp.GetAccessKeyId(),
+			"secret_access_key": s,
+		})
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		_ = d.Set("credentials_json", string(creds))
 	}
 
-	flattenKeyInfo(resp, d)
+	flattenKeyInfo(resp, d, p)
 	return nil
 }
 
@@ -165,10 +317,15 @@ func resourceKeyCreate(ctx context.Context, d *schema.ResourceData, m interface{
 func resourceKeyRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	p := m.(*garageProvider)
 
-	resp, httpResp, err := p.client.AccessKeyAPI.
+	req := p.client.AccessKeyAPI.
 		GetKeyInfo(p.withToken(ctx)).
-		Id(d.Id()).
-		Execute()
+		Id(d.Id())
+	// only fetch the secret when it is missing, so it is not sent on every refresh
+	if d.Get("show_secret_on_read").(bool) && d.Get("secret_access_key").(string) == "" {
+		req = req.ShowSecretKey(true)
+	}
+
+	resp, httpResp, err := req.Execute()
 	if err != nil {
 		if httpResp != nil && httpResp.StatusCode == http.StatusNotFound {
 			d.SetId("")
@@ -176,13 +333,16 @@ func resourceKeyRead(ctx context.Context, d *schema.ResourceData, m interface{})
 		}
 		return createDiagnostics(err, httpResp)
 	}
+	if resp == nil {
+		return emptyResponseDiagnostics("GetKeyInfo")
+	}
 
 	_ = d.Set("access_key_id", resp.GetAccessKeyId())
 	if s := safeGetStringPtr(resp.GetSecretAccessKeyOk()); s != "" {
 		_ = d.Set("secret_access_key", s) // preserve if API returns it
 	}
 
-	flattenKeyInfo(resp, d)
+	flattenKeyInfo(resp, d, p)
 	return nil
 }
 
@@ -191,14 +351,19 @@ func resourceKeyRead(ctx context.Context, d *schema.ResourceData, m interface{})
 func resourceKeyUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	p := m.(*garageProvider)
 
-	if !(d.HasChange("name") || d.HasChange("expiration") || d.HasChange("permissions")) {
+	if !(d.HasChange("name") || d.HasChange("expiration") || d.HasChange("expires_in") || d.HasChange("permissions") || d.HasChange("permission_set")) {
 		return resourceKeyRead(ctx, d, m)
 	}
 
+	if diags := resolveExpiresIn(d, time.Now().In(p.location())); len(diags) > 0 {
+		return diags
+	}
+
 	body, diags := buildUpdateKeyRequestBody(d)
 	if len(diags) > 0 {
 		return diags
 	}
+	applyKeyNamePrefix(p, d, body)
 
 	resp, httpResp, err := p.client.AccessKeyAPI.
 		UpdateKey(p.withToken(ctx)).
@@ -208,23 +373,82 @@ func resourceKeyUpdate(ctx context.Context, d *schema.ResourceData, m interface{
 	if err != nil {
 		return createDiagnostics(err, httpResp)
 	}
+	if resp == nil {
+		return emptyResponseDiagnostics("UpdateKey")
+	}
 
 	_ = d.Set("access_key_id", resp.GetAccessKeyId())
 	if s := safeGetStringPtr(resp.GetSecretAccessKeyOk()); s != "" {
 		_ = d.Set("secret_access_key", s)
 	}
-	flattenKeyInfo(resp, d)
+	flattenKeyInfo(resp, d, p)
 	return nil
 }
 
 /* -------------------------------- Delete --------------------------------- */
 
+// keyDeleteAttempts and keyDeleteBackoff bound the DeleteKey retries performed
+// after revoking bucket access from a key that is still referenced.
+var (
+	keyDeleteAttempts = 4
+	keyDeleteBackoff  = 500 * time.Millisecond
+)
+
 func resourceKeyDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	p := m.(*garageProvider)
 
-	httpResp, err := p.client.AccessKeyAPI.
+	httpResp, err := deleteKey(ctx, p, d.Id())
+	if err == nil {
+		return nil
+	}
+	if httpResp != nil && httpResp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if httpResp == nil || httpResp.StatusCode != http.StatusConflict {
+		return createDiagnostics(err, httpResp)
+	}
+
+	// key still referenced: revoke its bucket access, then retry the delete since
+	// the revocation may not be visible to DeleteKey straight away
+	if diags := revokeKeyBucketAccess(ctx, p, d.Id()); len(diags) > 0 {
+		return diags
+	}
+
+	backoff := keyDeleteBackoff
+	for attempt := 1; attempt <= keyDeleteAttempts; attempt++ {
+		httpResp, err = deleteKey(ctx, p, d.Id())
+		if err == nil {
+			return nil
+		}
+		if httpResp != nil && httpResp.StatusCode == http.StatusNotFound {
+			return nil
+		}
+		if httpResp == nil || httpResp.StatusCode != http.StatusConflict || attempt == keyDeleteAttempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return diag.FromErr(ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	return createDiagnostics(err, httpResp)
+}
+
+func deleteKey(ctx context.Context, p *garageProvider, id string) (*http.Response, error) {
+	return p.client.AccessKeyAPI.
 		DeleteKey(p.withToken(ctx)).
-		Id(d.Id()).
+		Id(id).
+		Execute()
+}
+
+// revokeKeyBucketAccess denies every permission the key holds on any bucket.
+func revokeKeyBucketAccess(ctx context.Context, p *garageProvider, keyID string) diag.Diagnostics {
+	resp, httpResp, err := p.client.AccessKeyAPI.
+		GetKeyInfo(p.withToken(ctx)).
+		Id(keyID).
 		Execute()
 	if err != nil {
 		if httpResp != nil && httpResp.StatusCode == http.StatusNotFound {
@@ -232,38 +456,105 @@ func resourceKeyDelete(ctx context.Context, d *schema.ResourceData, m interface{
 		}
 		return createDiagnostics(err, httpResp)
 	}
+	if resp == nil {
+		return emptyResponseDiagnostics("GetKeyInfo")
+	}
+
+	for _, b := range resp.Buckets {
+		perms := b.Permissions
+		deny := garage.NewApiBucketKeyPerm()
+		if perms.GetRead() {
+			deny.SetRead(true)
+		}
+		if perms.GetWrite() {
+			deny.SetWrite(true)
+		}
+		if perms.GetOwner() {
+			deny.SetOwner(true)
+		}
+		if diags := applyBucketKeyDeny(ctx, p, b.Id, keyID, deny); len(diags) > 0 {
+			return diags
+		}
+	}
 	return nil
 }
 
 /* ------------------------------- Helpers --------------------------------- */
 
-func flattenKeyInfo(resp *garage.GetKeyInfoResponse, d *schema.ResourceData) {
+func flattenKeyInfo(resp *garage.GetKeyInfoResponse, d *schema.ResourceData, p *garageProvider) {
 	_ = d.Set("expired", resp.GetExpired())
-	if t, ok := resp.GetCreatedOk(); ok {
-		_ = d.Set("created", t.Format(time.RFC3339))
+	exp, ok := resp.GetExpirationOk()
+	_ = d.Set("never_expires", !ok || exp == nil || exp.IsZero())
+	// expiration follows the server so an out-of-band change shows as drift; a
+	// configured value naming the same instant is kept, so its format never flaps
+	expiration := ""
+	if ok && exp != nil && !exp.IsZero() {
+		expiration = p.formatTimestamp(*exp)
+		cur := d.Get("expiration").(string)
+		if t, err := time.Parse(time.RFC3339, cur); err == nil && t.Equal(*exp) {
+			expiration = cur
+		}
 	}
+	_ = d.Set("expiration", expiration)
+	// absent, null, or zero timestamps are reported as "" rather than 0001-01-01T00:00:00Z
+	created := ""
+	ageDays := 0
+	if t, ok := resp.GetCreatedOk(); ok && t != nil && !t.IsZero() {
+		created = p.formatTimestamp(*t)
+		ageDays = keyAgeDays(*t, time.Now())
+	}
+	_ = d.Set("created", created)
+	_ = d.Set("age_days", ageDays)
 
 	// Echo effective permissions if we can introspect them
 	if perms, ok := resp.GetPermissionsOk(); ok {
 		read, write, admin := reflectKeyPerm(*perms)
+		createBucket := getBoolFieldOrGetter(perms, "CreateBucket")
+		read, write, admin, createBucket = normalizeKeyPerms(read, write, admin, createBucket)
 		_ = d.Set("effective_permissions", []interface{}{
-			map[string]interface{}{"read": read, "write": write, "admin": admin},
+			map[string]interface{}{"read": read, "write": write, "admin": admin, "create_bucket": createBucket},
 		})
+		_ = d.Set("has_admin", admin)
 	}
+
+	buckets := make([]interface{}, 0, len(resp.GetBuckets()))
+	for _, b := range resp.GetBuckets() {
+		perms := b.GetPermissions()
+		buckets = append(buckets, map[string]interface{}{
+			"id":             b.GetId(),
+			"global_aliases": b.GetGlobalAliases(),
+			"local_aliases":  b.GetLocalAliases(),
+			"read":           perms.GetRead(),
+			"write":          perms.GetWrite(),
+			"owner":          perms.GetOwner(),
+		})
+	}
+	_ = d.Set("buckets", buckets)
+}
+
+// keyAgeDays returns the whole days between created and now; clock skew never yields a negative age.
+func keyAgeDays(created, now time.Time) int {
+	if !now.After(created) {
+		return 0
+	}
+	return int(now.Sub(created) / (24 * time.Hour))
 }
 
 // buildUpdateKeyRequestBody builds the UpdateKeyRequestBody using reflection-friendly setters.
-// It fills name, expiration (RFC3339), and permissions {read,write,admin}.
+// It fills name, expiration (RFC3339), and permissions {read,write,admin,create_bucket}.
+// Every permission is sent with its desired value, so disabling one revokes it server-side.
+// On create every configured field is sent; on update only the fields that changed,
+// so a rename does not overwrite permissions changed out of band.
 func buildUpdateKeyRequestBody(d *schema.ResourceData) (*garage.UpdateKeyRequestBody, diag.Diagnostics) {
 	body := garage.NewUpdateKeyRequestBody() // If your SDK uses a different ctor, adjust here.
 
 	// name
-	if v, ok := d.GetOk("name"); ok && v.(string) != "" {
+	if v, ok := d.GetOk("name"); ok && v.(string) != "" && keyFieldChanged(d, "name") {
 		setStringFieldOrSetter(body, "Name", v.(string))
 	}
 
 	// expiration
-	if v, ok := d.GetOk("expiration"); ok && v.(string) != "" {
+	if v, ok := d.GetOk("expiration"); ok && v.(string) != "" && keyFieldChanged(d, "expiration") {
 		t, err := time.Parse(time.RFC3339, v.(string))
 		if err != nil {
 			return nil, diag.Diagnostics{diag.Diagnostic{
@@ -276,48 +567,192 @@ func buildUpdateKeyRequestBody(d *schema.ResourceData) (*garage.UpdateKeyRequest
 		setTimeFieldOrSetter(body, "Expiration", t)
 	}
 
-	// permissions block
-	if v, ok := d.GetOk("permissions"); ok {
+	// permissions block or permission_set shorthand
+	if pm, ok := configuredKeyPerms(d); ok {
+		read := pm["read"] == true
+		write := pm["write"] == true
+		admin := pm["admin"] == true
+		createBucket := pm["create_bucket"] == true
+		// admin implies the rest; never deny what admin grants
+		read, write, admin, createBucket = normalizeKeyPerms(read, write, admin, createBucket)
+
+		perm := buildKeyPerm(read, write, admin, createBucket)
+		setStructFieldOrSetter(body, "Permissions", perm)
+
+		// SDKs modelling changes as allow/deny sets: deny everything that is not desired
+		setStructFieldOrSetter(body, "Allow", perm)
+		setStructFieldOrSetter(body, "Deny", buildKeyPerm(!read, !write, !admin, !createBucket))
+	}
+
+	return body, nil
+}
+
+// resolveExpiresIn turns expires_in into an absolute expiration counted from
+// now, on create or when expires_in changed, so buildUpdateKeyRequestBody sends
+// it and state records the resulting time, rendered in now's location.
+func resolveExpiresIn(d *schema.ResourceData, now time.Time) diag.Diagnostics {
+	v, ok := getOkString(d, "expires_in")
+	if !ok || !keyFieldChanged(d, "expires_in") {
+		return nil
+	}
+	dur, err := parseExpiresIn(v)
+	if err != nil {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "invalid expires_in",
+			Detail:   err.Error(),
+		}}
+	}
+	_ = d.Set("expiration", now.Add(dur).Format(time.RFC3339))
+	return nil
+}
+
+// parseExpiresIn parses a positive Go duration, also accepting a whole number
+// of days such as "90d".
+func parseExpiresIn(s string) (time.Duration, error) {
+	var dur time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a number of days", s)
+		}
+		dur = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if dur, err = time.ParseDuration(s); err != nil {
+			return 0, fmt.Errorf("%q is not a duration such as 720h or 90d", s)
+		}
+	}
+	if dur <= 0 {
+		return 0, fmt.Errorf("%q must be positive", s)
+	}
+	return dur, nil
+}
+
+func validateExpiresIn(v interface{}, k string) (ws []string, es []error) {
+	if _, err := parseExpiresIn(v.(string)); err != nil {
+		es = append(es, fmt.Errorf("%q: %v", k, err))
+	}
+	return
+}
+
+// keyPermissionNames are the values accepted by permission_set, matching the
+// attributes of the permissions block.
+var keyPermissionNames = []string{"read", "write", "admin", "create_bucket"}
+
+func validateKeyPermissionName(v interface{}, k string) (ws []string, es []error) {
+	name := v.(string)
+	for _, allowed := range keyPermissionNames {
+		if name == allowed {
+			return
+		}
+	}
+	es = append(es, fmt.Errorf("%q must be one of %s, got %q", k, strings.Join(keyPermissionNames, ", "), name))
+	return
+}
+
+// configuredKeyPerms returns the desired permissions in the shape of the
+// permissions block, from either the block or permission_set. ok is false when
+// neither is set or neither belongs in the request body.
+func configuredKeyPerms(d *schema.ResourceData) (map[string]interface{}, bool) {
+	if v, ok := d.GetOk("permissions"); ok && keyFieldChanged(d, "permissions") {
 		list := v.([]interface{})
 		if len(list) == 1 && list[0] != nil {
-			pm := list[0].(map[string]interface{})
-			read := pm["read"] == true
-			write := pm["write"] == true
-			admin := pm["admin"] == true
-
-			perm := buildKeyPerm(read, write, admin)
-			setStructFieldOrSetter(body, "Permissions", perm)
+			return list[0].(map[string]interface{}), true
 		}
+		return nil, false
 	}
+	if v, ok := d.GetOk("permission_set"); ok && keyFieldChanged(d, "permission_set") {
+		pm := map[string]interface{}{}
+		for _, name := range v.(*schema.Set).List() {
+			pm[name.(string)] = true
+		}
+		return pm, true
+	}
+	// permission_set = [] lists nothing, so every permission is denied
+	if keyFieldChanged(d, "permission_set") && permissionSetConfiguredEmpty(d) {
+		return map[string]interface{}{}, true
+	}
+	return nil, false
+}
 
-	return body, nil
+// permissionSetConfiguredEmpty reports whether the configuration sets
+// permission_set to an empty set, as opposed to leaving it out.
+func permissionSetConfiguredEmpty(d *schema.ResourceData) bool {
+	raw := d.GetRawConfig()
+	if raw.IsNull() || !raw.IsKnown() {
+		return false
+	}
+	v := raw.GetAttr("permission_set")
+	return !v.IsNull() && v.IsKnown() && v.LengthInt() == 0
 }
 
-// buildKeyPerm constructs a KeyPerm (or compatible struct) with read/write/admin via reflection.
-func buildKeyPerm(read, write, admin bool) interface{} {
+// keyFieldChanged reports whether a key field belongs in the request body: always
+// while creating (no ID yet), otherwise only when it changed.
+func keyFieldChanged(d *schema.ResourceData, key string) bool {
+	return d.Id() == "" || d.HasChange(key)
+}
+
+// applyKeyNamePrefix sends the key name with the provider resource_name_prefix.
+// State keeps the configured name so plans stay stable. A key without a name
+// stays unnamed rather than being named after the bare prefix.
+func applyKeyNamePrefix(p *garageProvider, d *schema.ResourceData, body *garage.UpdateKeyRequestBody) {
+	if p.namePrefix == "" || !keyFieldChanged(d, "name") {
+		return
+	}
+	name, _ := d.Get("name").(string)
+	if name == "" {
+		return
+	}
+	setStringFieldOrSetter(body, "Name", p.prefixName(name))
+}
+
+// buildKeyPerm constructs a KeyPerm (or compatible struct) with read/write/admin/createBucket via reflection.
+// False values are set explicitly rather than left unset.
+func buildKeyPerm(read, write, admin, createBucket bool) garage.KeyPerm {
 	// Create zero value of garage.KeyPerm
 	var kp garage.KeyPerm
+	fillKeyPerm(&kp, read, write, admin, createBucket)
+	return kp
+}
 
+// fillKeyPerm sets every known permission flag on a KeyPerm-like struct pointer.
+func fillKeyPerm(kp interface{}, read, write, admin, createBucket bool) {
 	// Try setters first
-	setBoolFieldOrSetter(&kp, "Read", read)
-	setBoolFieldOrSetter(&kp, "Write", write)
-	setBoolFieldOrSetter(&kp, "Admin", admin)
+	setBoolFieldOrSetter(kp, "Read", read)
+	setBoolFieldOrSetter(kp, "Write", write)
+	setBoolFieldOrSetter(kp, "Admin", admin)
+	setBoolFieldOrSetter(kp, "CreateBucket", createBucket)
 
 	// In case the SDK uses different field names, try a few alternates
-	setBoolFieldOrSetter(&kp, "CanRead", read)
-	setBoolFieldOrSetter(&kp, "CanWrite", write)
-	setBoolFieldOrSetter(&kp, "IsAdmin", admin)
-
-	return kp
+	setBoolFieldOrSetter(kp, "CanRead", read)
+	setBoolFieldOrSetter(kp, "CanWrite", write)
+	setBoolFieldOrSetter(kp, "IsAdmin", admin)
 }
 
 func reflectKeyPerm(kp garage.KeyPerm) (read, write, admin bool) {
-	read = getBoolFieldOrGetter(kp, "Read") || getBoolFieldOrGetter(kp, "CanRead")
-	write = getBoolFieldOrGetter(kp, "Write") || getBoolFieldOrGetter(kp, "CanWrite")
-	admin = getBoolFieldOrGetter(kp, "Admin") || getBoolFieldOrGetter(kp, "IsAdmin")
+	// pass a pointer so pointer-receiver getters are reachable
+	read = getBoolFieldOrGetter(&kp, "Read") || getBoolFieldOrGetter(&kp, "CanRead")
+	write = getBoolFieldOrGetter(&kp, "Write") || getBoolFieldOrGetter(&kp, "CanWrite")
+	admin = getBoolFieldOrGetter(&kp, "Admin") || getBoolFieldOrGetter(&kp, "IsAdmin")
 	return
 }
 
+// normalizeKeyPerms applies Garage's admin-implies-all semantics so that
+// configured and reported permissions compare equal.
+func normalizeKeyPerms(read, write, admin, createBucket bool) (bool, bool, bool, bool) {
+	if admin {
+		return true, true, true, true
+	}
+	return read, write, admin, createBucket
+}
+
+// suppressAdminImpliedPerm ignores changes to permissions that admin already implies.
+func suppressAdminImpliedPerm(_, _, _ string, d *schema.ResourceData) bool {
+	admin, _ := d.Get("permissions.0.admin").(bool)
+	return admin
+}
+
 func safeGetStringPtr(ptr *string, ok bool) string {
 	if ok && ptr != nil {
 		return *ptr
@@ -370,10 +805,14 @@ func setTimeFieldOrSetter(obj interface{}, name string, t time.Time) {
 				f.Set(arg)
 				return
 			}
-			// If the field is a NullableTime-like struct with Set/Get methods, try SetTime
-			if m := reflect.New(f.Type()).Elem(); m.IsValid() {
-				// fallback: set zero (won't help much without type knowledge)
-				// prefer using real setter via MethodByName above where possible
+			// a *time.Time field
+			if f.Type() == reflect.TypeOf(&t) {
+				f.Set(reflect.ValueOf(&t))
+				return
+			}
+			// a NullableTime-like wrapper: Set(*time.Time) on the field's address
+			if m := f.Addr().MethodByName("Set"); m.IsValid() && m.Type().NumIn() == 1 && m.Type().In(0) == reflect.TypeOf(&t) {
+				m.Call([]reflect.Value{reflect.ValueOf(&t)})
 			}
 		}
 	}
@@ -427,8 +866,16 @@ func setBoolFieldOrSetter(obj interface{}, name string, val bool) {
 	}
 	if rv.Kind() == reflect.Struct {
 		f := rv.FieldByName(name)
-		if f.IsValid() && f.CanSet() && f.Kind() == reflect.Bool {
+		if !f.IsValid() || !f.CanSet() {
+			return
+		}
+		switch {
+		case f.Kind() == reflect.Bool:
 			f.SetBool(val)
+		case f.Kind() == reflect.Pointer && f.Type().Elem().Kind() == reflect.Bool:
+			// optional fields are *bool; point at an explicit value so false is not omitted
+			v := val
+			f.Set(reflect.ValueOf(&v))
 		}
 	}
 }
@@ -449,6 +896,33 @@ func getBoolFieldOrGetter(obj interface{}, name string) bool {
 		if f.IsValid() && f.Kind() == reflect.Bool {
 			return f.Bool()
 		}
+		if f.IsValid() && f.Kind() == reflect.Pointer && f.Type().Elem().Kind() == reflect.Bool && !f.IsNil() {
+			return f.Elem().Bool()
+		}
 	}
 	return false
 }
+
+// getStringFieldOrGetter reads a string through Get<Name>() or a <Name> field; ok is false when neither exists.
+func getStringFieldOrGetter(obj interface{}, name string) (string, bool) {
+	rv := reflect.ValueOf(obj)
+	if m := rv.MethodByName("Get" + name); m.IsValid() && m.Type().NumIn() == 0 && m.Type().NumOut() == 1 && m.Type().Out(0).Kind() == reflect.String {
+		return m.Call(nil)[0].String(), true
+	}
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return "", false
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() == reflect.Struct {
+		f := rv.FieldByName(name)
+		if f.IsValid() && f.Kind() == reflect.String {
+			return f.String(), true
+		}
+		if f.IsValid() && f.Kind() == reflect.Pointer && f.Type().Elem().Kind() == reflect.String && !f.IsNil() {
+			return f.Elem().String(), true
+		}
+	}
+	return "", false
+}
@@ -2,14 +2,19 @@ package garage
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
 	garageapi "git.deuxfleurs.fr/garage-sdk/garage-admin-sdk-golang"
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
 func TestBuildUpdateKeyRequestBodyValid(t *testing.T) {
@@ -41,6 +46,144 @@ func TestBuildUpdateKeyRequestBodyValid(t *testing.T) {
 	}
 }
 
+func TestBuildUpdateKeyRequestBodyExplicitFalsePermissions(t *testing.T) {
+	res := resourceKey()
+	data := schema.TestResourceDataRaw(t, res.Schema, map[string]interface{}{
+		"permissions": []interface{}{
+			map[string]interface{}{
+				"read":          true,
+				"write":         false,
+				"create_bucket": false,
+			},
+		},
+	})
+
+	body, diags := buildUpdateKeyRequestBody(data)
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %#v", diags)
+	}
+	allow := body.Allow.Get()
+	if allow == nil || allow.CreateBucket == nil || *allow.CreateBucket {
+		t.Fatalf("expected allow.createBucket to be explicitly false, got %#v", allow)
+	}
+	deny := body.Deny.Get()
+	if deny == nil || !deny.GetCreateBucket() {
+		t.Fatalf("expected deny.createBucket to be true, got %#v", deny)
+	}
+}
+
+func TestBuildUpdateKeyRequestBodyPermissionSet(t *testing.T) {
+	res := resourceKey()
+	data := schema.TestResourceDataRaw(t, res.Schema, map[string]interface{}{
+		"permission_set": []interface{}{"read", "admin"},
+	})
+
+	pm, ok := configuredKeyPerms(data)
+	if !ok || pm["read"] != true || pm["admin"] != true || pm["write"] == true {
+		t.Fatalf("expected read and admin from permission_set, got %#v", pm)
+	}
+
+	body, diags := buildUpdateKeyRequestBody(data)
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %#v", diags)
+	}
+	// admin implies create_bucket, so it is allowed and not denied
+	allow := body.Allow.Get()
+	if allow == nil || !allow.GetCreateBucket() {
+		t.Fatalf("expected allow.createBucket=true, got %#v", allow)
+	}
+	deny := body.Deny.Get()
+	if deny == nil || deny.GetCreateBucket() {
+		t.Fatalf("expected deny.createBucket=false, got %#v", deny)
+	}
+}
+
+func TestConfiguredKeyPermsEmptyPermissionSet(t *testing.T) {
+	res := resourceKey()
+	for name, set := range map[string]cty.Value{
+		"emptied": cty.SetValEmpty(cty.String),
+		"removed": cty.NullVal(cty.Set(cty.String)),
+	} {
+		prior := schema.TestResourceDataRaw(t, res.Schema, map[string]interface{}{
+			"permission_set": []interface{}{"read"},
+		})
+		prior.SetId("key-123")
+		state := prior.State()
+		// Terraform hands the raw configuration over through the prior state
+		state.RawConfig = cty.ObjectVal(map[string]cty.Value{
+			"permission_set": set,
+		})
+		diff, err := res.Diff(context.Background(), state, terraform.NewResourceConfigRaw(map[string]interface{}{
+			"permission_set": []interface{}{},
+		}), nil)
+		if err != nil {
+			t.Fatalf("%s: unexpected diff error: %v", name, err)
+		}
+		d, err := schema.InternalMap(res.Schema).Data(state, diff)
+		if err != nil {
+			t.Fatalf("%s: data: %v", name, err)
+		}
+
+		pm, ok := configuredKeyPerms(d)
+		if name == "removed" {
+			if ok {
+				t.Fatalf("removed: expected permissions to be left alone, got %#v", pm)
+			}
+			continue
+		}
+		if !ok || len(pm) != 0 {
+			t.Fatalf("emptied: expected every permission to be denied, got %#v, %v", pm, ok)
+		}
+		body, diags := buildUpdateKeyRequestBody(d)
+		if len(diags) != 0 {
+			t.Fatalf("emptied: unexpected diagnostics: %#v", diags)
+		}
+		deny := body.Deny.Get()
+		if deny == nil || !deny.GetCreateBucket() {
+			t.Fatalf("emptied: expected all permissions denied, got %#v", deny)
+		}
+	}
+}
+
+func TestResourceKeyPermissionSetValidation(t *testing.T) {
+	res := resourceKey()
+	diags := res.Validate(terraform.NewResourceConfigRaw(map[string]interface{}{
+		"permission_set": []interface{}{"read", "owner"},
+	}))
+	if !diags.HasError() {
+		t.Fatalf("expected unknown permission to be rejected")
+	}
+
+	diags = res.Validate(terraform.NewResourceConfigRaw(map[string]interface{}{
+		"permission_set": []interface{}{"read"},
+		"permissions": []interface{}{
+			map[string]interface{}{"read": true},
+		},
+	}))
+	if !diags.HasError() {
+		t.Fatalf("expected permission_set and permissions to conflict")
+	}
+}
+
+type keyPermHolder struct {
+	Read  *bool `json:"read,omitempty"`
+	Write *bool `json:"write,omitempty"`
+	Admin *bool `json:"admin,omitempty"`
+}
+
+func TestFillKeyPermKeepsFalseValues(t *testing.T) {
+	var kp keyPermHolder
+	fillKeyPerm(&kp, true, false, false, false)
+
+	raw, err := json.Marshal(kp)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if !strings.Contains(string(raw), `"write":false`) || !strings.Contains(string(raw), `"read":true`) {
+		t.Fatalf("expected write=false to be carried explicitly, got %s", raw)
+	}
+}
+
 func TestBuildUpdateKeyRequestBodyInvalidExpiration(t *testing.T) {
 	res := resourceKey()
 	data := schema.TestResourceDataRaw(t, res.Schema, map[string]interface{}{
@@ -112,6 +255,14 @@ type timeFieldHolder struct {
 	Expiration time.Time
 }
 
+type nullableTimeFieldHolder struct {
+	Expiration garageapi.NullableTime
+}
+
+type timePointerFieldHolder struct {
+	Expiration *time.Time
+}
+
 type structSetterHolder struct {
 	config map[string]string
 }
@@ -184,6 +335,18 @@ func TestSetTimeFieldOrSetterStructField(t *testing.T) {
 	if !h.Expiration.Equal(value) {
 		t.Fatalf("expected expiration field to be set, got %v", h.Expiration)
 	}
+
+	var nh nullableTimeFieldHolder
+	setTimeFieldOrSetter(&nh, "Expiration", value)
+	if !nh.Expiration.IsSet() || nh.Expiration.Get() == nil || !nh.Expiration.Get().Equal(value) {
+		t.Fatalf("expected nullable expiration to be set, got %v (set=%v)", nh.Expiration.Get(), nh.Expiration.IsSet())
+	}
+
+	var ph timePointerFieldHolder
+	setTimeFieldOrSetter(&ph, "Expiration", value)
+	if ph.Expiration == nil || !ph.Expiration.Equal(value) {
+		t.Fatalf("expected pointer expiration to be set, got %v", ph.Expiration)
+	}
 }
 
 func TestSetStructFieldOrSetterSetterAssignable(t *testing.T) {
@@ -255,7 +418,7 @@ func TestFlattenKeyInfo(t *testing.T) {
 	res := resourceKey()
 	d := schema.TestResourceDataRaw(t, res.Schema, map[string]interface{}{})
 
-	flattenKeyInfo(k, d)
+	flattenKeyInfo(k, d, nil)
 
 	if v := d.Get("expired").(bool); !v {
 		t.Fatalf("expected expired to be true")
@@ -273,6 +436,161 @@ func TestFlattenKeyInfo(t *testing.T) {
 	}
 }
 
+func TestFlattenKeyInfoHasAdmin(t *testing.T) {
+	for _, admin := range []bool{false, true} {
+		k := garageapi.NewGetKeyInfoResponse("id", nil, false, "name", garageapi.KeyPerm{})
+		var perms garageapi.KeyPerm
+		fillKeyPerm(&perms, false, false, admin, false)
+		k.SetPermissions(perms)
+
+		d := schema.TestResourceDataRaw(t, resourceKey().Schema, map[string]interface{}{})
+		flattenKeyInfo(k, d, nil)
+
+		eff := d.Get("effective_permissions").([]interface{})[0].(map[string]interface{})
+		if d.Get("has_admin").(bool) != eff["admin"].(bool) {
+			t.Fatalf("expected has_admin to match effective admin %v, got %v", eff["admin"], d.Get("has_admin"))
+		}
+	}
+}
+
+func TestFlattenKeyInfoBuckets(t *testing.T) {
+	payload := `{"accessKeyId":"key-123","buckets":[{"id":"bucket-1","globalAliases":["site"],"localAliases":["mine"],"permissions":{"read":true,"write":false,"owner":true}}],"expired":false,"name":"key","permissions":{}}`
+	var k garageapi.GetKeyInfoResponse
+	if err := json.Unmarshal([]byte(payload), &k); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	d := schema.TestResourceDataRaw(t, resourceKey().Schema, map[string]interface{}{})
+	flattenKeyInfo(&k, d, nil)
+
+	buckets := d.Get("buckets").([]interface{})
+	if len(buckets) != 1 {
+		t.Fatalf("expected one bucket, got %#v", buckets)
+	}
+	want := map[string]interface{}{
+		"id":             "bucket-1",
+		"global_aliases": []interface{}{"site"},
+		"local_aliases":  []interface{}{"mine"},
+		"read":           true,
+		"write":          false,
+		"owner":          true,
+	}
+	if got := buckets[0].(map[string]interface{}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected bucket entry %#v", got)
+	}
+}
+
+func TestFlattenKeyInfoAgeDays(t *testing.T) {
+	k := garageapi.NewGetKeyInfoResponse("id", nil, false, "name", garageapi.KeyPerm{})
+	k.SetCreated(time.Now().Add(-(3*24*time.Hour + time.Hour)))
+
+	d := schema.TestResourceDataRaw(t, resourceKey().Schema, map[string]interface{}{})
+	flattenKeyInfo(k, d, nil)
+
+	if got := d.Get("age_days").(int); got != 3 {
+		t.Fatalf("expected age_days 3, got %d", got)
+	}
+}
+
+func TestFlattenKeyInfoNeverExpires(t *testing.T) {
+	k := garageapi.NewGetKeyInfoResponse("id", nil, false, "name", garageapi.KeyPerm{})
+	d := schema.TestResourceDataRaw(t, resourceKey().Schema, map[string]interface{}{})
+	flattenKeyInfo(k, d, nil)
+	if !d.Get("never_expires").(bool) {
+		t.Fatalf("expected never_expires for a key without expiration")
+	}
+
+	k.SetExpiration(time.Now().Add(24 * time.Hour))
+	flattenKeyInfo(k, d, nil)
+	if d.Get("never_expires").(bool) {
+		t.Fatalf("expected never_expires=false once an expiration is set")
+	}
+}
+
+func TestFlattenKeyInfoExpiration(t *testing.T) {
+	exp := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	k := garageapi.NewGetKeyInfoResponse("id", nil, false, "name", garageapi.KeyPerm{})
+	k.SetExpiration(exp)
+
+	// a configured value in another offset names the same instant and is kept
+	configured := "2030-01-01T02:00:00+02:00"
+	res := resourceKey()
+	d := schema.TestResourceDataRaw(t, res.Schema, map[string]interface{}{"name": "app", "expiration": configured})
+	d.SetId("id")
+	flattenKeyInfo(k, d, nil)
+	if got := d.Get("expiration").(string); got != configured {
+		t.Fatalf("expected configured expiration to be kept, got %q", got)
+	}
+	diff, err := res.Diff(context.Background(), d.State(), terraform.NewResourceConfigRaw(map[string]interface{}{
+		"name":       "app",
+		"expiration": configured,
+	}), nil)
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	if diff != nil {
+		if attr, ok := diff.Attributes["expiration"]; ok {
+			t.Fatalf("expected no expiration diff, got %#v", attr)
+		}
+	}
+
+	// an out-of-band change is read back as drift
+	k.SetExpiration(exp.Add(24 * time.Hour))
+	flattenKeyInfo(k, d, nil)
+	if got := d.Get("expiration").(string); got != "2030-01-02T00:00:00Z" {
+		t.Fatalf("expected server expiration, got %q", got)
+	}
+
+	// a removed expiration is read back as empty
+	k.Expiration.Unset()
+	flattenKeyInfo(k, d, nil)
+	if got := d.Get("expiration").(string); got != "" {
+		t.Fatalf("expected empty expiration, got %q", got)
+	}
+}
+
+func TestKeyAgeDays(t *testing.T) {
+	created := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	cases := map[time.Time]int{
+		created.Add(23 * time.Hour):      0,
+		created.Add(24 * time.Hour):      1,
+		created.Add(30*24*time.Hour + 1): 30,
+		created.Add(-time.Hour):          0,
+	}
+	for now, want := range cases {
+		if got := keyAgeDays(created, now); got != want {
+			t.Fatalf("keyAgeDays(%s, %s) = %d, want %d", created, now, got, want)
+		}
+	}
+}
+
+func TestFlattenKeyInfoWithoutCreated(t *testing.T) {
+	for name, payload := range map[string]string{
+		"absent": `{"accessKeyId":"key-123","buckets":[],"expired":false,"name":"key","permissions":{}}`,
+		"null":   `{"accessKeyId":"key-123","buckets":[],"created":null,"expired":false,"name":"key","permissions":{}}`,
+		"zero":   `{"accessKeyId":"key-123","buckets":[],"created":"0001-01-01T00:00:00Z","expired":false,"name":"key","permissions":{}}`,
+	} {
+		var k garageapi.GetKeyInfoResponse
+		if err := json.Unmarshal([]byte(payload), &k); err != nil {
+			t.Fatalf("%s: decode: %v", name, err)
+		}
+
+		d := schema.TestResourceDataRaw(t, resourceKey().Schema, map[string]interface{}{})
+		if err := d.Set("created", "2024-01-01T00:00:00Z"); err != nil {
+			t.Fatalf("%s: set created: %v", name, err)
+		}
+
+		flattenKeyInfo(&k, d, nil)
+
+		if v := d.Get("created").(string); v != "" {
+			t.Fatalf("%s: expected empty created, got %q", name, v)
+		}
+		if v := d.Get("age_days").(int); v != 0 {
+			t.Fatalf("%s: expected age_days 0, got %d", name, v)
+		}
+	}
+}
+
 type keyRoundTripper func(*http.Request) (*http.Response, error)
 
 func (f keyRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
@@ -339,6 +657,80 @@ func TestResourceKeyDeleteNotFound(t *testing.T) {
 	}
 }
 
+func TestResourceKeyDeleteConflictRevokesAndRetries(t *testing.T) {
+	origBackoff := keyDeleteBackoff
+	keyDeleteBackoff = time.Millisecond
+	t.Cleanup(func() { keyDeleteBackoff = origBackoff })
+
+	var paths []string
+	deletes := 0
+	p := newTestProvider(func(r *http.Request) (*http.Response, error) {
+		paths = append(paths, r.URL.Path)
+		switch r.URL.Path {
+		case "/v2/DeleteKey":
+			deletes++
+			if deletes == 1 {
+				return &http.Response{StatusCode: http.StatusConflict, Status: "409 Conflict", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(`{"message":"key is still in use"}`))}, nil
+			}
+			return &http.Response{StatusCode: http.StatusNoContent, Status: "204 No Content", Header: make(http.Header), Body: io.NopCloser(strings.NewReader(""))}, nil
+		case "/v2/GetKeyInfo":
+			payload := `{"accessKeyId":"key-123","buckets":[{"id":"bucket-1","globalAliases":[],"localAliases":[],"permissions":{"read":true,"owner":true}}],"expired":false,"name":"key","permissions":{}}`
+			return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(payload))}, nil
+		case "/v2/DenyBucketKey":
+			raw, _ := io.ReadAll(r.Body)
+			r.Body.Close()
+			var body map[string]interface{}
+			if err := json.Unmarshal(raw, &body); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			perms, _ := body["permissions"].(map[string]interface{})
+			if body["bucketId"] != "bucket-1" || perms["read"] != true || perms["owner"] != true {
+				t.Fatalf("unexpected deny body %s", raw)
+			}
+			return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(bucketInfoPayload("bucket-1", "key-123", "key", bucketKeyPermissions{})))}, nil
+		default:
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+		return nil, nil
+	})
+
+	d := schema.TestResourceDataRaw(t, resourceKey().Schema, map[string]interface{}{})
+	d.SetId("key-123")
+
+	if diags := resourceKeyDelete(context.Background(), d, p); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %#v", diags)
+	}
+	want := []string{"/v2/DeleteKey", "/v2/GetKeyInfo", "/v2/DenyBucketKey", "/v2/DeleteKey"}
+	if strings.Join(paths, ",") != strings.Join(want, ",") {
+		t.Fatalf("unexpected call sequence %v", paths)
+	}
+}
+
+func TestResourceKeyDeleteConflictGivesUp(t *testing.T) {
+	origBackoff, origAttempts := keyDeleteBackoff, keyDeleteAttempts
+	keyDeleteBackoff, keyDeleteAttempts = time.Millisecond, 2
+	t.Cleanup(func() { keyDeleteBackoff, keyDeleteAttempts = origBackoff, origAttempts })
+
+	deletes := 0
+	p := newTestProvider(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path == "/v2/GetKeyInfo" {
+			return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(keyResponseJSON("")))}, nil
+		}
+		deletes++
+		return &http.Response{StatusCode: http.StatusConflict, Status: "409 Conflict", Header: make(http.Header), Body: io.NopCloser(strings.NewReader("still in use"))}, nil
+	})
+
+	d := schema.TestResourceDataRaw(t, resourceKey().Schema, map[string]interface{}{})
+	d.SetId("key-123")
+
+	if diags := resourceKeyDelete(context.Background(), d, p); len(diags) == 0 {
+		t.Fatalf("expected diagnostics once retries are exhausted")
+	}
+	if deletes != 3 {
+		t.Fatalf("expected initial delete plus 2 retries, got %d", deletes)
+	}
+}
+
 func keyResponseJSON(secret string) string {
 	json := `{"accessKeyId":"key-123","buckets":[],"expired":false,"name":"key","permissions":{}}`
 	if secret != "" {
@@ -374,6 +766,198 @@ func TestResourceKeyCreateSuccess(t *testing.T) {
 	if d.Get("secret_access_key").(string) != "secret" {
 		t.Fatalf("expected secret to be set")
 	}
+
+	var creds map[string]string
+	if err := json.Unmarshal([]byte(d.Get("credentials_json").(string)), &creds); err != nil {
+		t.Fatalf("expected credentials_json to be valid JSON: %v", err)
+	}
+	if creds["access_key_id"] != "key-123" || creds["secret_access_key"] != "secret" {
+		t.Fatalf("unexpected credentials_json %v", creds)
+	}
+}
+
+func TestParseExpiresIn(t *testing.T) {
+	for in, want := range map[string]time.Duration{
+		"90d":   90 * 24 * time.Hour,
+		"720h":  720 * time.Hour,
+		"1h30m": 90 * time.Minute,
+	} {
+		got, err := parseExpiresIn(in)
+		if err != nil || got != want {
+			t.Fatalf("%s: expected %s, got %s (%v)", in, want, got, err)
+		}
+	}
+	for _, in := range []string{"", "90", "1.5d", "-1h", "0d", "soon"} {
+		if _, err := parseExpiresIn(in); err == nil {
+			t.Fatalf("expected %q to be rejected", in)
+		}
+	}
+}
+
+func TestResourceKeyCreateExpiresIn(t *testing.T) {
+	for in, dur := range map[string]time.Duration{
+		"90d":  90 * 24 * time.Hour,
+		"720h": 720 * time.Hour,
+	} {
+		var body map[string]interface{}
+		p := newTestProvider(func(r *http.Request) (*http.Response, error) {
+			raw, _ := io.ReadAll(r.Body)
+			r.Body.Close()
+			if err := json.Unmarshal(raw, &body); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			// the server reports back the expiration it stored
+			resp := keyResponseJSON("secret")
+			if exp, ok := body["expiration"].(string); ok {
+				resp = strings.Replace(resp, `"expired":false`, `"expiration":"`+exp+`","expired":false`, 1)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Status:     "200 OK",
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(resp)),
+			}, nil
+		})
+
+		d := schema.TestResourceDataRaw(t, resourceKey().Schema, map[string]interface{}{
+			"name":       "mykey",
+			"expires_in": in,
+		})
+		want := time.Now().Add(dur)
+		if diags := resourceKeyCreate(context.Background(), d, p); len(diags) != 0 {
+			t.Fatalf("%s: unexpected diagnostics: %#v", in, diags)
+		}
+
+		sent, err := time.Parse(time.RFC3339, fmt.Sprint(body["expiration"]))
+		if err != nil {
+			t.Fatalf("%s: expected an RFC3339 expiration in the body, got %v", in, body["expiration"])
+		}
+		if diff := sent.Sub(want); diff < -5*time.Second || diff > 5*time.Second {
+			t.Fatalf("%s: expected expiration near %s, got %s", in, want, sent)
+		}
+		if got := d.Get("expiration").(string); got != sent.Format(time.RFC3339) {
+			t.Fatalf("%s: expected state expiration %s, got %q", in, sent.Format(time.RFC3339), got)
+		}
+	}
+}
+
+func TestResourceKeyExpiresInConflictsWithExpiration(t *testing.T) {
+	diags := resourceKey().Validate(terraform.NewResourceConfigRaw(map[string]interface{}{
+		"expiration": "2030-01-01T00:00:00Z",
+		"expires_in": "90d",
+	}))
+	if !diags.HasError() {
+		t.Fatalf("expected expiration and expires_in to conflict")
+	}
+}
+
+func TestResourceKeyDiffExpiresInChangeRecomputesExpiration(t *testing.T) {
+	state := &terraform.InstanceState{ID: "key-123", Attributes: map[string]string{
+		"id":         "key-123",
+		"name":       "mykey",
+		"expires_in": "30d",
+		"expiration": "2030-01-01T00:00:00Z",
+	}}
+	diff, err := resourceKey().Diff(context.Background(), state, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"name":       "mykey",
+		"expires_in": "90d",
+	}), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if attr := diff.Attributes["expiration"]; attr == nil || !attr.NewComputed {
+		t.Fatalf("expected expiration to be recomputed, got %#v", attr)
+	}
+}
+
+func TestResourceKeyCreateAcceptsAny2xx(t *testing.T) {
+	states := map[int]map[string]string{}
+	for _, status := range []int{http.StatusOK, http.StatusCreated} {
+		p := newTestProvider(func(r *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: status,
+				Status:     fmt.Sprintf("%d %s", status, http.StatusText(status)),
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(keyResponseJSON("secret"))),
+			}, nil
+		})
+
+		d := schema.TestResourceDataRaw(t, resourceKey().Schema, map[string]interface{}{
+			"name": "mykey",
+		})
+		if diags := resourceKeyCreate(context.Background(), d, p); len(diags) != 0 {
+			t.Fatalf("status %d: unexpected diagnostics: %#v", status, diags)
+		}
+		states[status] = d.State().Attributes
+	}
+
+	if !reflect.DeepEqual(states[http.StatusOK], states[http.StatusCreated]) {
+		t.Fatalf("expected 200 and 201 to give the same state:\n200: %v\n201: %v", states[http.StatusOK], states[http.StatusCreated])
+	}
+}
+
+func TestResourceKeyCreateUndecodableSuccessResponse(t *testing.T) {
+	p := newTestProvider(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusCreated,
+			Status:     "201 Created",
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"accessKeyId":`)),
+		}, nil
+	})
+
+	d := schema.TestResourceDataRaw(t, resourceKey().Schema, map[string]interface{}{
+		"name": "mykey",
+	})
+	diags := resourceKeyCreate(context.Background(), d, p)
+	if len(diags) != 1 || diags[0].Summary != "unexpected Garage API response (201 Created)" {
+		t.Fatalf("expected an unexpected response diagnostic, got %#v", diags)
+	}
+	if d.Id() != "" {
+		t.Fatalf("expected no ID, got %q", d.Id())
+	}
+}
+
+func TestResourceKeyCreateAppliesNamePrefix(t *testing.T) {
+	var body map[string]interface{}
+	p := newTestProvider(func(r *http.Request) (*http.Response, error) {
+		raw, _ := io.ReadAll(r.Body)
+		r.Body.Close()
+		if err := json.Unmarshal(raw, &body); err != nil {
+			t.Fatalf("decode body: %v", err)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Status:     "200 OK",
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(keyResponseJSON("secret"))),
+		}, nil
+	})
+	p.namePrefix = "tf-acc-"
+
+	d := schema.TestResourceDataRaw(t, resourceKey().Schema, map[string]interface{}{
+		"name": "mykey",
+	})
+
+	if diags := resourceKeyCreate(context.Background(), d, p); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %#v", diags)
+	}
+	if body["name"] != "tf-acc-mykey" {
+		t.Fatalf("expected prefixed key name, got %#v", body["name"])
+	}
+	if d.Get("name").(string) != "mykey" {
+		t.Fatalf("expected state to keep the configured name, got %q", d.Get("name"))
+	}
+
+	// an unnamed key is not named after the bare prefix
+	body = nil
+	d = schema.TestResourceDataRaw(t, resourceKey().Schema, map[string]interface{}{})
+	if diags := resourceKeyCreate(context.Background(), d, p); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %#v", diags)
+	}
+	if name, ok := body["name"]; ok && name != nil {
+		t.Fatalf("expected no key name to be sent, got %#v", name)
+	}
 }
 
 func TestResourceKeyCreateError(t *testing.T) {
@@ -418,6 +1002,96 @@ func TestResourceKeyReadSuccess(t *testing.T) {
 	}
 }
 
+func TestResourceKeyReadRecoversMissingSecret(t *testing.T) {
+	for _, show := range []bool{false, true} {
+		var query string
+		p := newTestProvider(func(r *http.Request) (*http.Response, error) {
+			if r.URL.Path != "/v2/GetKeyInfo" {
+				t.Fatalf("unexpected path %s", r.URL.Path)
+			}
+			query = r.URL.Query().Get("showSecretKey")
+			secret := ""
+			if query == "true" {
+				secret = "recovered-secret"
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Status:     "200 OK",
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(keyResponseJSON(secret))),
+			}, nil
+		})
+
+		d := schema.TestResourceDataRaw(t, resourceKey().Schema, map[string]interface{}{
+			"show_secret_on_read": show,
+		})
+		d.SetId("key-123")
+
+		if diags := resourceKeyRead(context.Background(), d, p); len(diags) != 0 {
+			t.Fatalf("unexpected diagnostics %#v", diags)
+		}
+		got := d.Get("secret_access_key").(string)
+		if show && (query != "true" || got != "recovered-secret") {
+			t.Fatalf("expected secret to be recovered via showSecretKey, query=%q secret=%q", query, got)
+		}
+		if !show && (query != "" || got != "") {
+			t.Fatalf("expected no secret request without show_secret_on_read, query=%q secret=%q", query, got)
+		}
+	}
+}
+
+func TestResourceKeyReadSkipsSecretWhenPresent(t *testing.T) {
+	p := newTestProvider(func(r *http.Request) (*http.Response, error) {
+		if q := r.URL.Query().Get("showSecretKey"); q != "" {
+			t.Fatalf("secret already in state, expected no showSecretKey, got %q", q)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Status:     "200 OK",
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(keyResponseJSON(""))),
+		}, nil
+	})
+
+	d := schema.TestResourceDataRaw(t, resourceKey().Schema, map[string]interface{}{
+		"show_secret_on_read": true,
+	})
+	d.SetId("key-123")
+	_ = d.Set("secret_access_key", "stored")
+
+	if diags := resourceKeyRead(context.Background(), d, p); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	if got := d.Get("secret_access_key").(string); got != "stored" {
+		t.Fatalf("expected stored secret to be kept, got %q", got)
+	}
+}
+
+func TestResourceKeyReadDisplayTimezone(t *testing.T) {
+	p := newTestProvider(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Status:     "200 OK",
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"accessKeyId":"key-123","buckets":[],"created":"2025-01-01T00:00:00Z","expired":false,"name":"key","permissions":{}}`)),
+		}, nil
+	})
+	loc, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+	p.displayLocation = loc
+
+	d := schema.TestResourceDataRaw(t, resourceKey().Schema, map[string]interface{}{})
+	d.SetId("key-123")
+	if diags := resourceKeyRead(context.Background(), d, p); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	if got := d.Get("created").(string); got != "2025-01-01T05:30:00+05:30" {
+		t.Fatalf("expected created in the display time zone, got %q", got)
+	}
+}
+
 func TestResourceKeyReadNotFound(t *testing.T) {
 	p := newTestProvider(func(r *http.Request) (*http.Response, error) {
 		return &http.Response{
@@ -525,6 +1199,283 @@ func TestResourceKeyUpdateChange(t *testing.T) {
 	}
 }
 
+func TestResourceKeyUpdateRevokesPermission(t *testing.T) {
+	var body map[string]interface{}
+	p := newTestProvider(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path != "/v2/UpdateKey" {
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+		raw, _ := io.ReadAll(r.Body)
+		r.Body.Close()
+		if err := json.Unmarshal(raw, &body); err != nil {
+			t.Fatalf("decode body: %v", err)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Status:     "200 OK",
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(keyResponseJSON(""))),
+		}, nil
+	})
+
+	d := schema.TestResourceDataRaw(t, resourceKey().Schema, map[string]interface{}{
+		"permissions": []interface{}{
+			map[string]interface{}{"read": true, "write": true, "create_bucket": true},
+		},
+	})
+	d.SetId("key-123")
+	if err := d.Set("permissions", []interface{}{
+		map[string]interface{}{"read": true, "write": false, "create_bucket": false},
+	}); err != nil {
+		t.Fatalf("set permissions: %v", err)
+	}
+
+	diags := resourceKeyUpdate(context.Background(), d, p)
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	allow, _ := body["allow"].(map[string]interface{})
+	if v, ok := allow["createBucket"]; !ok || v != false {
+		t.Fatalf("expected allow.createBucket=false to be sent, got %#v", body)
+	}
+	deny, _ := body["deny"].(map[string]interface{})
+	if deny["createBucket"] != true {
+		t.Fatalf("expected deny.createBucket=true to revoke the permission, got %#v", body)
+	}
+}
+
+func TestResourceKeyUpdateNameOnlyOmitsPermissions(t *testing.T) {
+	var body map[string]interface{}
+	p := newTestProvider(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path != "/v2/UpdateKey" {
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+		raw, _ := io.ReadAll(r.Body)
+		r.Body.Close()
+		if err := json.Unmarshal(raw, &body); err != nil {
+			t.Fatalf("decode body: %v", err)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Status:     "200 OK",
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(keyResponseJSON(""))),
+		}, nil
+	})
+
+	res := resourceKey()
+	state := &terraform.InstanceState{
+		ID: "key-123",
+		Attributes: map[string]string{
+			"id":                          "key-123",
+			"name":                        "old",
+			"permissions.#":               "1",
+			"permissions.0.read":          "true",
+			"permissions.0.write":         "true",
+			"permissions.0.admin":         "false",
+			"permissions.0.create_bucket": "false",
+		},
+	}
+	conf := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"name": "new",
+		"permissions": []interface{}{
+			map[string]interface{}{"read": true, "write": true},
+		},
+	})
+	diff, err := res.Diff(context.Background(), state, conf, nil)
+	if err != nil {
+		t.Fatalf("unexpected diff error: %v", err)
+	}
+	d, err := schema.InternalMap(res.Schema).Data(state, diff)
+	if err != nil {
+		t.Fatalf("unexpected error building resource data: %v", err)
+	}
+	if d.HasChange("permissions") {
+		t.Fatalf("expected only the name to change")
+	}
+
+	if diags := resourceKeyUpdate(context.Background(), d, p); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	if body["name"] != "new" {
+		t.Fatalf("expected new name to be sent, got %#v", body)
+	}
+	for _, field := range []string{"allow", "deny", "expiration"} {
+		if _, ok := body[field]; ok {
+			t.Fatalf("expected %s to be omitted on a name-only update, got %#v", field, body)
+		}
+	}
+}
+
+func TestResourceKeyUpdateMetadataStateOnly(t *testing.T) {
+	cases := []struct {
+		name  string
+		state map[string]string
+		conf  map[string]interface{}
+		want  map[string]interface{}
+	}{
+		{
+			name: "add",
+			conf: map[string]interface{}{"team": "storage"},
+			want: map[string]interface{}{"team": "storage"},
+		},
+		{
+			name:  "change",
+			state: map[string]string{"metadata.%": "2", "metadata.team": "storage", "metadata.env": "dev"},
+			conf:  map[string]interface{}{"team": "platform", "env": "dev"},
+			want:  map[string]interface{}{"team": "platform", "env": "dev"},
+		},
+		{
+			name:  "remove",
+			state: map[string]string{"metadata.%": "1", "metadata.team": "storage"},
+			want:  map[string]interface{}{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := newTestProvider(func(r *http.Request) (*http.Response, error) {
+				if r.URL.Path != "/v2/GetKeyInfo" {
+					t.Fatalf("expected metadata changes to skip the API update, got %s", r.URL.Path)
+				}
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Status:     "200 OK",
+					Header:     http.Header{"Content-Type": []string{"application/json"}},
+					Body:       io.NopCloser(strings.NewReader(keyResponseJSON(""))),
+				}, nil
+			})
+
+			res := resourceKey()
+			attrs := map[string]string{"id": "key-123", "name": "test-key"}
+			for k, v := range tc.state {
+				attrs[k] = v
+			}
+			state := &terraform.InstanceState{ID: "key-123", Attributes: attrs}
+			raw := map[string]interface{}{"name": "test-key"}
+			if tc.conf != nil {
+				raw["metadata"] = tc.conf
+			}
+			diff, err := res.Diff(context.Background(), state, terraform.NewResourceConfigRaw(raw), nil)
+			if err != nil {
+				t.Fatalf("unexpected diff error: %v", err)
+			}
+			d, err := schema.InternalMap(res.Schema).Data(state, diff)
+			if err != nil {
+				t.Fatalf("unexpected error building resource data: %v", err)
+			}
+			if !d.HasChange("metadata") {
+				t.Fatalf("expected metadata to change")
+			}
+
+			if diags := resourceKeyUpdate(context.Background(), d, p); len(diags) != 0 {
+				t.Fatalf("unexpected diagnostics %#v", diags)
+			}
+			if got := d.Get("metadata").(map[string]interface{}); !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("expected metadata %#v after update and read, got %#v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestNormalizeKeyPerms(t *testing.T) {
+	read, write, admin, createBucket := normalizeKeyPerms(false, false, true, false)
+	if !read || !write || !admin || !createBucket {
+		t.Fatalf("expected admin to imply all permissions")
+	}
+	read, write, admin, createBucket = normalizeKeyPerms(true, false, false, false)
+	if !read || write || admin || createBucket {
+		t.Fatalf("expected non-admin permissions to be left untouched")
+	}
+}
+
+func TestBuildUpdateKeyRequestBodyAdminDoesNotDenyImplied(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceKey().Schema, map[string]interface{}{
+		"permissions": []interface{}{
+			map[string]interface{}{"admin": true},
+		},
+	})
+
+	body, diags := buildUpdateKeyRequestBody(d)
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	if body.Deny.Get().GetCreateBucket() {
+		t.Fatalf("expected admin-only config not to deny implied permissions")
+	}
+	if !body.Allow.Get().GetCreateBucket() {
+		t.Fatalf("expected admin-only config to allow implied permissions")
+	}
+}
+
+func TestResourceKeyAdminOnlyNoDriftAfterRead(t *testing.T) {
+	p := newTestProvider(func(r *http.Request) (*http.Response, error) {
+		// server reports admin together with the capabilities it derives from it
+		payload := `{"accessKeyId":"key-123","buckets":[],"expired":false,"name":"key",` +
+			`"permissions":{"admin":true,"read":true,"write":true,"createBucket":true}}`
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Status:     "200 OK",
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(payload)),
+		}, nil
+	})
+
+	res := resourceKey()
+	raw := map[string]interface{}{
+		"permissions": []interface{}{
+			map[string]interface{}{"admin": true},
+		},
+	}
+
+	// state as left behind by an earlier apply that listed the derived perms explicitly
+	d := schema.TestResourceDataRaw(t, res.Schema, map[string]interface{}{
+		"permissions": []interface{}{
+			map[string]interface{}{"admin": true, "read": true, "write": true, "create_bucket": true},
+		},
+	})
+	d.SetId("key-123")
+	if diags := resourceKeyRead(context.Background(), d, p); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+
+	diff, err := res.Diff(context.Background(), d.State(), terraform.NewResourceConfigRaw(raw), nil)
+	if err != nil {
+		t.Fatalf("unexpected diff error: %v", err)
+	}
+	if diff != nil && !diff.Empty() {
+		t.Fatalf("expected no diff against admin-only config, got %#v", diff.Attributes)
+	}
+}
+
+func TestResourceKeyAdminToggleStillDiffs(t *testing.T) {
+	res := resourceKey()
+	state := &terraform.InstanceState{
+		ID: "key-123",
+		Attributes: map[string]string{
+			"id":                          "key-123",
+			"permissions.#":               "1",
+			"permissions.0.admin":         "true",
+			"permissions.0.read":          "true",
+			"permissions.0.write":         "false",
+			"permissions.0.create_bucket": "false",
+		},
+	}
+	raw := map[string]interface{}{
+		"permissions": []interface{}{
+			map[string]interface{}{"admin": false, "read": true},
+		},
+	}
+
+	diff, err := res.Diff(context.Background(), state, terraform.NewResourceConfigRaw(raw), nil)
+	if err != nil {
+		t.Fatalf("unexpected diff error: %v", err)
+	}
+	if diff == nil || diff.Attributes["permissions.0.admin"] == nil {
+		t.Fatalf("expected dropping admin to produce a diff, got %#v", diff)
+	}
+}
+
 func TestResourceKeyUpdateBuildError(t *testing.T) {
 	p := newTestProvider(func(r *http.Request) (*http.Response, error) {
 		t.Fatalf("api should not be called when build errors")
@@ -571,3 +1522,60 @@ func TestResourceKeyUpdateError(t *testing.T) {
 		t.Fatalf("expected diagnostics on update error")
 	}
 }
+
+func TestResourceKeyCreateNamePrefix(t *testing.T) {
+	var sentName string
+	p := newTestProvider(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path != "/v2/CreateKey" {
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+		var body map[string]interface{}
+		raw, _ := io.ReadAll(r.Body)
+		r.Body.Close()
+		if err := json.Unmarshal(raw, &body); err != nil {
+			t.Fatalf("decode body: %v", err)
+		}
+		sentName, _ = body["name"].(string)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Status:     "200 OK",
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(keyResponseJSON("secret"))),
+		}, nil
+	})
+
+	d := schema.TestResourceDataRaw(t, resourceKey().Schema, map[string]interface{}{
+		"name_prefix": "app-",
+	})
+	if diags := resourceKeyCreate(context.Background(), d, p); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+
+	name := d.Get("name").(string)
+	if !strings.HasPrefix(name, "app-") || len(name) <= len("app-") {
+		t.Fatalf("expected a generated name starting with app-, got %q", name)
+	}
+	if sentName != name {
+		t.Fatalf("expected the generated name %q to be sent, got %q", name, sentName)
+	}
+
+	other := schema.TestResourceDataRaw(t, resourceKey().Schema, map[string]interface{}{
+		"name_prefix": "app-",
+	})
+	if diags := resourceKeyCreate(context.Background(), other, p); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	if other.Get("name").(string) == name {
+		t.Fatalf("expected unique names for the same prefix, got %q twice", name)
+	}
+}
+
+func TestResourceKeyNamePrefixConflictsWithName(t *testing.T) {
+	diags := resourceKey().Validate(terraform.NewResourceConfigRaw(map[string]interface{}{
+		"name":        "app",
+		"name_prefix": "app-",
+	}))
+	if !diags.HasError() {
+		t.Fatalf("expected name and name_prefix to conflict")
+	}
+}
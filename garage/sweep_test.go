@@ -0,0 +1,107 @@
+package garage
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	garageapi "git.deuxfleurs.fr/garage-sdk/garage-admin-sdk-golang"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+)
+
+/*
+Sweeper support
+
+Resources created while resource_name_prefix is set carry the prefix in the key
+name (garage_key) or the global alias (garage_bucket). The helpers below list
+what still carries a given prefix, for test code cleaning up leftovers.
+*/
+
+// listKeyIDsWithNamePrefix returns the IDs of access keys whose name starts with prefix.
+func listKeyIDsWithNamePrefix(ctx context.Context, p *garageProvider, prefix string) ([]string, diag.Diagnostics) {
+	items, httpResp, err := p.client.AccessKeyAPI.
+		ListKeys(p.withToken(ctx)).
+		Execute()
+	if err != nil {
+		return nil, createDiagnostics(err, httpResp)
+	}
+
+	var ids []string
+	for _, item := range items {
+		if prefix != "" && strings.HasPrefix(item.Name, prefix) {
+			ids = append(ids, item.Id)
+		}
+	}
+	return ids, nil
+}
+
+// listBucketIDsWithAliasPrefix returns the IDs of buckets with a global alias starting with prefix.
+func listBucketIDsWithAliasPrefix(ctx context.Context, p *garageProvider, prefix string) ([]string, diag.Diagnostics) {
+	items, httpResp, err := p.client.BucketAPI.
+		ListBuckets(p.withToken(ctx)).
+		Execute()
+	if err != nil {
+		return nil, createDiagnostics(err, httpResp)
+	}
+
+	var ids []string
+	for _, item := range items {
+		if prefix != "" && hasAliasWithPrefix(item.GlobalAliases, prefix) {
+			ids = append(ids, item.Id)
+		}
+	}
+	return ids, nil
+}
+
+func TestListKeyIDsWithNamePrefix(t *testing.T) {
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path != "/v2/ListKeys" {
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+		items := []garageapi.ListKeysResponseItem{
+			*garageapi.NewListKeysResponseItem(false, "GK1", "tf-acc-app"),
+			*garageapi.NewListKeysResponseItem(false, "GK2", "prod-app"),
+			*garageapi.NewListKeysResponseItem(false, "GK3", "tf-acc-other"),
+		}
+		data, _ := json.Marshal(items)
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(string(data)))}, nil
+	}))
+
+	ids, diags := listKeyIDsWithNamePrefix(context.Background(), p, "tf-acc-")
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	if !reflect.DeepEqual(ids, []string{"GK1", "GK3"}) {
+		t.Fatalf("unexpected ids %v", ids)
+	}
+
+	ids, _ = listKeyIDsWithNamePrefix(context.Background(), p, "")
+	if len(ids) != 0 {
+		t.Fatalf("expected empty prefix to match nothing, got %v", ids)
+	}
+}
+
+func TestListBucketIDsWithAliasPrefix(t *testing.T) {
+	p := newTestProvider(keyRoundTripper(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path != "/v2/ListBuckets" {
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+		body := listBucketsJSON(map[string][]string{
+			"b1": {"tf-acc-data"},
+			"b2": {"prod"},
+		})
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(body))}, nil
+	}))
+
+	ids, diags := listBucketIDsWithAliasPrefix(context.Background(), p, "tf-acc-")
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics %#v", diags)
+	}
+	if !reflect.DeepEqual(ids, []string{"b1"}) {
+		t.Fatalf("unexpected ids %v", ids)
+	}
+}
@@ -0,0 +1,467 @@
+package garage
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// defaultRequestTimeout bounds a single admin API request when the caller's
+// context carries no earlier deadline.
+const defaultRequestTimeout = 10 * time.Second
+
+// deadlineTransport applies a per-request timeout through the request context
+// instead of http.Client.Timeout, so that the effective limit is the minimum of
+// the provider timeout and any deadline already set on the caller's context
+// (e.g. resource timeouts). The shorter of the two always wins.
+//
+// readTimeout and writeTimeout, when non-zero, replace timeout for read-only
+// (GET/HEAD/OPTIONS) and mutating requests respectively.
+type deadlineTransport struct {
+	base         http.RoundTripper
+	timeout      time.Duration
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+}
+
+func (t *deadlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	ctx := req.Context()
+	timeout, ok := effectiveTimeout(ctx, t.timeoutFor(req.Method))
+	if !ok {
+		// context deadline is already the tighter bound
+		return base.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	resp, err := base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, &requestTimeoutError{timeout: timeout, err: err}
+		}
+		return nil, err
+	}
+	// keep the deadline alive until the caller is done reading the body
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// requestTimeoutError reports a request cut short by the provider timeout
+// rather than by a deadline on the caller's context.
+type requestTimeoutError struct {
+	timeout time.Duration
+	err     error
+}
+
+func (e *requestTimeoutError) Error() string {
+	return fmt.Sprintf("no response within %s: %v", e.timeout, e.err)
+}
+
+func (e *requestTimeoutError) Unwrap() error {
+	return e.err
+}
+
+// timeoutFor picks the configured timeout for a request method.
+func (t *deadlineTransport) timeoutFor(method string) time.Duration {
+	if retryableMethod(method) {
+		if t.readTimeout > 0 {
+			return t.readTimeout
+		}
+	} else if t.writeTimeout > 0 {
+		return t.writeTimeout
+	}
+	return t.timeout
+}
+
+// effectiveTimeout returns the provider timeout and true when it is tighter
+// than the context deadline (or the context has none). It returns false when
+// the context deadline expires first or no provider timeout is configured.
+func effectiveTimeout(ctx context.Context, timeout time.Duration) (time.Duration, bool) {
+	if timeout <= 0 {
+		return 0, false
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= timeout {
+		return 0, false
+	}
+	return timeout, true
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+// Retry defaults for transient admin API failures. Backoff doubles after each
+// attempt up to retryMaxBackoff; retry_max_elapsed_seconds bounds the total.
+const (
+	defaultRetryMaxAttempts = 4
+	defaultRetryBackoff     = 500 * time.Millisecond
+	retryMaxBackoff         = 5 * time.Second
+	defaultRetryMaxElapsed  = 30 * time.Second
+)
+
+// retryTransport retries idempotent requests that fail with a transport error
+// or a 502/503/504. It stops after maxAttempts, once maxElapsed has passed since
+// the first attempt, or when the request context is done, whichever comes first,
+// and then returns the last response or error. When budget is set, every retry
+// also spends one token from it and the transport fails fast once it is empty.
+type retryTransport struct {
+	base        http.RoundTripper
+	maxAttempts int
+	backoff     time.Duration
+	maxElapsed  time.Duration
+	budget      *retryBudget
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if !retryableMethod(req.Method) || t.maxAttempts <= 1 {
+		return base.RoundTrip(req)
+	}
+
+	ctx := req.Context()
+	start := time.Now()
+	wait := t.backoff
+
+	for attempt := 1; ; attempt++ {
+		resp, err := base.RoundTrip(req)
+		if !retryableResult(resp, err) || attempt >= t.maxAttempts {
+			return resp, err
+		}
+		// the next attempt would start past the elapsed cap: give up now
+		if t.maxElapsed > 0 && time.Since(start)+wait > t.maxElapsed {
+			return resp, err
+		}
+		// a context deadline is the harder bound
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= wait {
+			return resp, err
+		}
+		// retries are shared across all concurrent requests of the provider
+		if !t.budget.take() {
+			return resp, err
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return resp, err
+		case <-timer.C:
+		}
+
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		wait *= 2
+		if wait > retryMaxBackoff {
+			wait = retryMaxBackoff
+		}
+	}
+}
+
+// retryBudget is a token bucket shared by every request of one provider instance,
+// bounding the total number of retries across concurrent operations. Tokens are
+// not refilled; a nil budget never runs out.
+type retryBudget struct {
+	mu     sync.Mutex
+	tokens int
+}
+
+func newRetryBudget(tokens int) *retryBudget {
+	return &retryBudget{tokens: tokens}
+}
+
+// take spends one token and reports whether a retry may proceed.
+func (b *retryBudget) take() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens <= 0 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+func retryableMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+func retryableResult(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// callCountingTransport counts requests per URL path, so repeated calls to the
+// same admin endpoint (e.g. a double GetBucketInfo in one create) stand out.
+type callCountingTransport struct {
+	base http.RoundTripper
+
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (t *callCountingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	if t.counts == nil {
+		t.counts = map[string]int{}
+	}
+	t.counts[req.URL.Path]++
+	t.mu.Unlock()
+
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
+
+// snapshot returns a copy of the counts recorded so far.
+func (t *callCountingTransport) snapshot() map[string]int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make(map[string]int, len(t.counts))
+	for path, n := range t.counts {
+		out[path] = n
+	}
+	return out
+}
+
+// bucketInfoInvalidatingTransport clears the shared GetBucketInfo cache around
+// every mutating request, so reads that follow a write see its effect.
+type bucketInfoInvalidatingTransport struct {
+	base  http.RoundTripper
+	cache *bucketInfoCache
+}
+
+func (t *bucketInfoInvalidatingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if retryableMethod(req.Method) {
+		return base.RoundTrip(req)
+	}
+	// cleared before and after: a read racing the write must not be served
+	// from, or stored in, the cache
+	t.cache.clear()
+	resp, err := base.RoundTrip(req)
+	t.cache.clear()
+	return resp, err
+}
+
+// debugLoggingTransport implements sdk_debug: every request and response is
+// logged through tflog at debug level, with the Authorization header and secret
+// fields of JSON bodies redacted. It replaces the SDK's own Debug dump, which
+// writes bearer tokens and secret keys to the standard logger verbatim.
+type debugLoggingTransport struct {
+	base http.RoundTripper
+}
+
+// redactedValue replaces credentials in debug logs.
+const redactedValue = "<redacted>"
+
+// secretFieldPattern matches JSON string fields that carry credentials.
+var secretFieldPattern = regexp.MustCompile(`("(?:secretAccessKey|secretToken)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+
+func (t *debugLoggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	ctx := req.Context()
+
+	var reqBody []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		b, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		reqBody = b
+		req = req.Clone(ctx)
+		req.Body = io.NopCloser(bytes.NewReader(b))
+		req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(b)), nil }
+	}
+	tflog.Debug(ctx, "Garage API request", map[string]interface{}{
+		"method":  req.Method,
+		"url":     req.URL.String(),
+		"headers": redactHeaders(req.Header),
+		"body":    redactBody(reqBody),
+	})
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		tflog.Debug(ctx, "Garage API request failed", map[string]interface{}{
+			"method": req.Method,
+			"url":    req.URL.String(),
+			"error":  err.Error(),
+		})
+		return resp, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	tflog.Debug(ctx, "Garage API response", map[string]interface{}{
+		"method":  req.Method,
+		"url":     req.URL.String(),
+		"status":  resp.Status,
+		"headers": redactHeaders(resp.Header),
+		"body":    redactBody(respBody),
+	})
+	return resp, nil
+}
+
+// redactHeaders flattens h for logging, hiding the Authorization value.
+func redactHeaders(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		if strings.EqualFold(k, "Authorization") {
+			out[k] = redactedValue
+			continue
+		}
+		out[k] = strings.Join(v, ", ")
+	}
+	return out
+}
+
+// redactBody returns body as text with the values of secret JSON fields replaced.
+func redactBody(body []byte) string {
+	return secretFieldPattern.ReplaceAllString(string(body), `${1}"`+redactedValue+`"`)
+}
+
+// defaultMaxResponseSize bounds the size of an admin API response body.
+const defaultMaxResponseSize = 32 << 20
+
+// responseTooLargeError reports a response body larger than max_response_size.
+type responseTooLargeError struct {
+	path  string
+	limit int64
+}
+
+func (e *responseTooLargeError) Error() string {
+	return fmt.Sprintf("response from %s is larger than max_response_size (%d bytes)", e.path, e.limit)
+}
+
+// responseSizeTransport fails responses whose body exceeds limit instead of
+// letting the SDK decode them into memory. A declared Content-Length is checked
+// up front; other bodies fail once the limit is read past.
+type responseSizeTransport struct {
+	base  http.RoundTripper
+	limit int64
+}
+
+func (t *responseSizeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil || t.limit <= 0 {
+		return resp, err
+	}
+	tooLarge := &responseTooLargeError{path: req.URL.Path, limit: t.limit}
+	if resp.ContentLength > t.limit {
+		resp.Body.Close()
+		return nil, tooLarge
+	}
+	resp.Body = &limitedBody{ReadCloser: resp.Body, remaining: t.limit, err: tooLarge}
+	return resp, nil
+}
+
+// limitedBody returns err once more than remaining bytes are read.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	err       error
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, b.err
+	}
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return 0, b.err
+	}
+	return n, err
+}
+
+// tlsVersions maps accepted min_tls_version values to crypto/tls constants.
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// buildBaseTransport returns the transport shared by the SDK client and the v1
+// probe. A client certificate is installed for mutual TLS when both PEMs are set,
+// and minTLS (a crypto/tls version constant, 0 for the Go default) is enforced.
+func buildBaseTransport(certPEM, keyPEM string, minTLS uint16) (*http.Transport, error) {
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	if minTLS != 0 {
+		if tr.TLSClientConfig == nil {
+			tr.TLSClientConfig = &tls.Config{}
+		}
+		tr.TLSClientConfig.MinVersion = minTLS
+	}
+	if certPEM == "" && keyPEM == "" {
+		return tr, nil
+	}
+	if certPEM == "" || keyPEM == "" {
+		return nil, fmt.Errorf("client_cert_pem and client_key_pem must be set together")
+	}
+
+	cert, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+	if err != nil {
+		return nil, fmt.Errorf("loading client certificate: %w", err)
+	}
+	if tr.TLSClientConfig == nil {
+		tr.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	tr.TLSClientConfig.Certificates = []tls.Certificate{cert}
+	return tr, nil
+}
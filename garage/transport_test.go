@@ -0,0 +1,496 @@
+package garage
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	garageapi "git.deuxfleurs.fr/garage-sdk/garage-admin-sdk-golang"
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+)
+
+func TestDeadlineTransportContextDeadlinePreemptsClientTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := &http.Client{Transport: &deadlineTransport{timeout: time.Minute}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	start := time.Now()
+	_, err = client.Do(req)
+	if err == nil {
+		t.Fatalf("expected request to fail on context deadline")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("context deadline did not preempt client timeout, took %s", elapsed)
+	}
+}
+
+func TestDeadlineTransportAppliesClientTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := &http.Client{Transport: &deadlineTransport{timeout: 50 * time.Millisecond}}
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = client.Do(req)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected client timeout to apply, got %v", err)
+	}
+	var rt *requestTimeoutError
+	if !errors.As(err, &rt) || rt.timeout != 50*time.Millisecond {
+		t.Fatalf("expected the provider timeout to be reported, got %v", err)
+	}
+}
+
+func TestDeadlineTransportBodyReadableAfterReturn(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &deadlineTransport{timeout: time.Second}}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil || string(body) != "ok" {
+		t.Fatalf("expected body %q, got %q (err %v)", "ok", body, err)
+	}
+}
+
+func TestDeadlineTransportTimeoutByMethod(t *testing.T) {
+	var remaining time.Duration
+	base := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		deadline, ok := r.Context().Deadline()
+		if !ok {
+			t.Fatalf("expected a deadline on %s", r.Method)
+		}
+		remaining = time.Until(deadline)
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(http.NoBody)}, nil
+	})
+	tr := &deadlineTransport{base: base, timeout: time.Minute, readTimeout: 2 * time.Second, writeTimeout: 20 * time.Second}
+
+	cases := []struct {
+		method string
+		want   time.Duration
+	}{
+		{http.MethodGet, 2 * time.Second},
+		{http.MethodPost, 20 * time.Second},
+		{http.MethodDelete, 20 * time.Second},
+	}
+	for _, c := range cases {
+		req, _ := http.NewRequest(c.method, "https://example.com/v2/GetBucketInfo", nil)
+		if _, err := tr.RoundTrip(req); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if remaining > c.want || remaining < c.want-time.Second {
+			t.Fatalf("expected %s to get a %s deadline, got %s", c.method, c.want, remaining)
+		}
+	}
+}
+
+func TestDeadlineTransportFallsBackToRequestTimeout(t *testing.T) {
+	tr := &deadlineTransport{timeout: time.Minute, readTimeout: time.Second}
+	if got := tr.timeoutFor(http.MethodPost); got != time.Minute {
+		t.Fatalf("expected write requests to fall back to the request timeout, got %s", got)
+	}
+	tr = &deadlineTransport{timeout: time.Minute, writeTimeout: time.Second}
+	if got := tr.timeoutFor(http.MethodGet); got != time.Minute {
+		t.Fatalf("expected read requests to fall back to the request timeout, got %s", got)
+	}
+}
+
+func TestEffectiveTimeout(t *testing.T) {
+	if d, ok := effectiveTimeout(context.Background(), time.Second); !ok || d != time.Second {
+		t.Fatalf("expected provider timeout without context deadline, got %s %v", d, ok)
+	}
+
+	short, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, ok := effectiveTimeout(short, time.Minute); ok {
+		t.Fatalf("expected shorter context deadline to take precedence")
+	}
+
+	long, cancelLong := context.WithTimeout(context.Background(), time.Hour)
+	defer cancelLong()
+	if d, ok := effectiveTimeout(long, time.Second); !ok || d != time.Second {
+		t.Fatalf("expected provider timeout to win over a longer context deadline, got %s %v", d, ok)
+	}
+
+	if _, ok := effectiveTimeout(context.Background(), 0); ok {
+		t.Fatalf("expected no timeout when none is configured")
+	}
+}
+
+func testClientCertPEM(t *testing.T) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "terraform"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return string(certPEM), string(keyPEM)
+}
+
+func TestBuildBaseTransportClientCert(t *testing.T) {
+	certPEM, keyPEM := testClientCertPEM(t)
+
+	tr, err := buildBaseTransport(certPEM, keyPEM, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tr.TLSClientConfig == nil || len(tr.TLSClientConfig.Certificates) != 1 {
+		t.Fatalf("expected client certificate on transport, got %#v", tr.TLSClientConfig)
+	}
+}
+
+func TestBuildBaseTransportWithoutCert(t *testing.T) {
+	tr, err := buildBaseTransport("", "", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tr.TLSClientConfig != nil && len(tr.TLSClientConfig.Certificates) != 0 {
+		t.Fatalf("expected no client certificate")
+	}
+}
+
+func TestBuildBaseTransportMinTLSVersion(t *testing.T) {
+	certPEM, keyPEM := testClientCertPEM(t)
+
+	for _, pem := range [][2]string{{"", ""}, {certPEM, keyPEM}} {
+		tr, err := buildBaseTransport(pem[0], pem[1], tls.VersionTLS13)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if tr.TLSClientConfig == nil || tr.TLSClientConfig.MinVersion != tls.VersionTLS13 {
+			t.Fatalf("expected MinVersion TLS 1.3, got %#v", tr.TLSClientConfig)
+		}
+	}
+}
+
+func TestBuildBaseTransportInvalidPair(t *testing.T) {
+	certPEM, _ := testClientCertPEM(t)
+	_, otherKey := testClientCertPEM(t)
+
+	if _, err := buildBaseTransport(certPEM, otherKey, 0); err == nil {
+		t.Fatalf("expected mismatched key to be rejected")
+	}
+	if _, err := buildBaseTransport(certPEM, "", 0); err == nil {
+		t.Fatalf("expected missing key to be rejected")
+	}
+	if _, err := buildBaseTransport("not a cert", "not a key", 0); err == nil {
+		t.Fatalf("expected invalid PEM to be rejected")
+	}
+}
+
+func TestRetryTransportStopsAtElapsedCap(t *testing.T) {
+	calls := 0
+	base := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		calls++
+		return nil, errors.New("connection refused")
+	})
+	tr := &retryTransport{base: base, maxAttempts: 100, backoff: 20 * time.Millisecond, maxElapsed: 50 * time.Millisecond}
+
+	req, _ := http.NewRequest(http.MethodGet, "https://example.com/v2/GetClusterStatus", nil)
+	start := time.Now()
+	_, err := tr.RoundTrip(req)
+	if err == nil || err.Error() != "connection refused" {
+		t.Fatalf("expected last error to be returned, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("retries did not stop at the elapsed cap, took %s", elapsed)
+	}
+	// 20ms + 40ms of backoff already exceeds the 50ms cap
+	if calls != 2 {
+		t.Fatalf("expected 2 attempts within the cap, got %d", calls)
+	}
+}
+
+func TestRetryTransportRetriesTransientStatus(t *testing.T) {
+	calls := 0
+	base := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		calls++
+		status := http.StatusServiceUnavailable
+		if calls == 2 {
+			status = http.StatusOK
+		}
+		return &http.Response{StatusCode: status, Body: io.NopCloser(http.NoBody)}, nil
+	})
+	tr := &retryTransport{base: base, maxAttempts: 3, backoff: time.Millisecond, maxElapsed: time.Second}
+
+	req, _ := http.NewRequest(http.MethodGet, "https://example.com/v2/GetClusterStatus", nil)
+	resp, err := tr.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected success after retry, got %v / %v", resp, err)
+	}
+	if calls != 2 {
+		t.Fatalf("expected 2 attempts, got %d", calls)
+	}
+}
+
+func TestRetryTransportSkipsNonIdempotent(t *testing.T) {
+	calls := 0
+	base := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		calls++
+		return nil, errors.New("connection reset")
+	})
+	tr := &retryTransport{base: base, maxAttempts: 3, backoff: time.Millisecond, maxElapsed: time.Second}
+
+	req, _ := http.NewRequest(http.MethodPost, "https://example.com/v2/CreateBucket", nil)
+	if _, err := tr.RoundTrip(req); err == nil {
+		t.Fatalf("expected error")
+	}
+	if calls != 1 {
+		t.Fatalf("expected a single attempt for POST, got %d", calls)
+	}
+}
+
+func TestRetryTransportContextDeadlineWins(t *testing.T) {
+	calls := 0
+	base := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		calls++
+		return nil, errors.New("connection refused")
+	})
+	tr := &retryTransport{base: base, maxAttempts: 100, backoff: 100 * time.Millisecond, maxElapsed: time.Minute}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://example.com/v2/GetClusterStatus", nil)
+	if _, err := tr.RoundTrip(req); err == nil {
+		t.Fatalf("expected error")
+	}
+	if calls != 1 {
+		t.Fatalf("expected the context deadline to prevent retries, got %d attempts", calls)
+	}
+}
+
+func TestRetryTransportBudgetBoundsConcurrentRetries(t *testing.T) {
+	var calls int64
+	base := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		atomic.AddInt64(&calls, 1)
+		return nil, errors.New("connection refused")
+	})
+	const budget, workers, maxAttempts = 5, 20, 4
+	tr := &retryTransport{base: base, maxAttempts: maxAttempts, backoff: time.Millisecond, maxElapsed: time.Second, budget: newRetryBudget(budget)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest(http.MethodGet, "https://example.com/v2/GetClusterStatus", nil)
+			if _, err := tr.RoundTrip(req); err == nil {
+				t.Errorf("expected error")
+			}
+		}()
+	}
+	wg.Wait()
+
+	// every worker makes its first attempt; only the budget pays for retries
+	if retries := atomic.LoadInt64(&calls) - workers; retries != budget {
+		t.Fatalf("expected %d retries in total, got %d", budget, retries)
+	}
+}
+
+func TestRetryBudgetNilIsUnbounded(t *testing.T) {
+	var b *retryBudget
+	for i := 0; i < 100; i++ {
+		if !b.take() {
+			t.Fatalf("expected nil budget to always allow retries")
+		}
+	}
+}
+
+func TestCallCountingTransportCountsPerPath(t *testing.T) {
+	base := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(http.NoBody)}, nil
+	})
+	tr := &callCountingTransport{base: base}
+
+	for _, path := range []string{"/v2/GetBucketInfo", "/v2/GetBucketInfo", "/v2/UpdateBucket"} {
+		req, _ := http.NewRequest(http.MethodGet, "https://example.com"+path+"?id=bucket", nil)
+		if _, err := tr.RoundTrip(req); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	got := tr.snapshot()
+	if got["/v2/GetBucketInfo"] != 2 || got["/v2/UpdateBucket"] != 1 || len(got) != 2 {
+		t.Fatalf("unexpected counts %v", got)
+	}
+	// snapshots are copies
+	got["/v2/UpdateBucket"] = 10
+	if tr.snapshot()["/v2/UpdateBucket"] != 1 {
+		t.Fatalf("expected snapshot to be detached from the counter")
+	}
+}
+
+func TestBucketInfoInvalidatingTransportClearsOnWrite(t *testing.T) {
+	cache := &bucketInfoCache{ttl: time.Minute}
+	fetches := 0
+	fetch := func() (*garageapi.GetBucketInfoResponse, *http.Response, error) {
+		fetches++
+		return &garageapi.GetBucketInfoResponse{Id: "bucket-1"}, nil, nil
+	}
+	base := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(http.NoBody)}, nil
+	})
+	tr := &bucketInfoInvalidatingTransport{base: base, cache: cache}
+
+	_, _, _ = cache.get("bucket-1", fetch)
+	req, _ := http.NewRequest(http.MethodGet, "https://example.com/v2/GetBucketInfo?id=bucket-1", nil)
+	if _, err := tr.RoundTrip(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, _, _ = cache.get("bucket-1", fetch)
+	if fetches != 1 {
+		t.Fatalf("expected a read to keep the cache, got %d fetches", fetches)
+	}
+
+	req, _ = http.NewRequest(http.MethodPost, "https://example.com/v2/UpdateBucket?id=bucket-1", strings.NewReader("{}"))
+	if _, err := tr.RoundTrip(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, _, _ = cache.get("bucket-1", fetch)
+	if fetches != 2 {
+		t.Fatalf("expected a write to clear the cache, got %d fetches", fetches)
+	}
+}
+
+func TestDebugLoggingTransportRedactsCredentials(t *testing.T) {
+	const token = "admin-token-123"
+	const secret = "secret-key-456"
+	var gotBody string
+	transport := &debugLoggingTransport{base: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		b, _ := io.ReadAll(r.Body)
+		gotBody = string(b)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Status:     "200 OK",
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"accessKeyId":"GK1","secretAccessKey":"` + secret + `"}`)),
+		}, nil
+	})}
+
+	var logs bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &logs)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "https://garage.example.com/v2/ImportKey", strings.NewReader(`{"accessKeyId":"GK1","secretAccessKey":"`+secret+`"}`))
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), secret) || !strings.Contains(gotBody, secret) {
+		t.Fatalf("expected bodies to pass through unchanged, got request %q response %q", gotBody, body)
+	}
+
+	out := logs.String()
+	if strings.Contains(out, token) || strings.Contains(out, secret) {
+		t.Fatalf("expected credentials to be redacted, got %s", out)
+	}
+	if !strings.Contains(out, "Garage API request") || !strings.Contains(out, "Garage API response") || !strings.Contains(out, "redacted") {
+		t.Fatalf("expected redacted request and response logs, got %s", out)
+	}
+}
+
+func TestResponseSizeTransportRejectsOversizedResponses(t *testing.T) {
+	payload := strings.Repeat("x", 2048)
+	for _, declared := range []bool{true, false} {
+		base := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			resp := &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(payload)), ContentLength: -1}
+			if declared {
+				resp.ContentLength = int64(len(payload))
+			}
+			return resp, nil
+		})
+		tr := &responseSizeTransport{base: base, limit: 1024}
+
+		req, _ := http.NewRequest(http.MethodGet, "https://example.com/v2/GetBucketInfo", nil)
+		resp, err := tr.RoundTrip(req)
+		if err == nil {
+			_, err = io.ReadAll(resp.Body)
+		}
+		var tooLarge *responseTooLargeError
+		if !errors.As(err, &tooLarge) {
+			t.Fatalf("declared=%v: expected responseTooLargeError, got %v", declared, err)
+		}
+	}
+
+	// bodies within the limit are passed through unchanged
+	base := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(payload)), ContentLength: -1}, nil
+	})
+	req, _ := http.NewRequest(http.MethodGet, "https://example.com/v2/GetBucketInfo", nil)
+	resp, err := (&responseSizeTransport{base: base, limit: int64(len(payload))}).RoundTrip(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, err := io.ReadAll(resp.Body); err != nil || string(got) != payload {
+		t.Fatalf("expected full body, got %d bytes, err=%v", len(got), err)
+	}
+}